@@ -1,6 +1,7 @@
 package avro
 
 import (
+	"bytes"
 	"encoding/hex"
 	"testing"
 )
@@ -90,3 +91,118 @@ func TestString(t *testing.T) {
 		}
 	}
 }
+
+func TestSkipPrimitives(t *testing.T) {
+	// string "abc", bytes {1, 2}, fixed of size 3, int 1
+	dec := NewBinaryDecoder([]byte{0x06, 'a', 'b', 'c', 0x04, 0x01, 0x02, 0x0a, 0x0b, 0x0c, 0x02})
+	assert(t, dec.SkipString(), nil)
+	assert(t, dec.SkipBytes(), nil)
+	assert(t, dec.SkipFixed(3), nil)
+	value, err := dec.ReadInt()
+	assert(t, err, nil)
+	assert(t, value, int32(1))
+
+	assert(t, NewBinaryDecoder([]byte{0x06, 'a'}).SkipString(), EOF)
+	assert(t, NewBinaryDecoder([]byte{}).SkipString(), InvalidLong)
+	assert(t, NewBinaryDecoder([]byte{0x03}).SkipString(), InvalidStringLength)
+	assert(t, NewBinaryDecoder([]byte{0x03}).SkipBytes(), NegativeBytesLength)
+	assert(t, NewBinaryDecoder([]byte{0x01}).SkipFixed(2), EOF)
+}
+
+func TestSkipArray(t *testing.T) {
+	// a block of 2 ints with size in bytes, a block of 1 int without size, end of array, int 7
+	dec := NewBinaryDecoder([]byte{0x03, 0x04, 0x02, 0x04, 0x02, 0x06, 0x00, 0x0e})
+	count, err := dec.SkipArray()
+	assert(t, err, nil)
+	assert(t, count, int64(1))
+	_, err = dec.ReadInt()
+	assert(t, err, nil)
+	count, err = dec.SkipArray()
+	assert(t, err, nil)
+	assert(t, count, int64(0))
+	value, err := dec.ReadInt()
+	assert(t, err, nil)
+	assert(t, value, int32(7))
+
+	_, err = NewBinaryDecoder([]byte{0x03, 0x03}).SkipArray()
+	assert(t, err, NegativeBytesLength)
+	_, err = NewBinaryDecoder([]byte{0x03, 0x08, 0x02}).SkipArray()
+	assert(t, err, EOF)
+}
+
+func TestSkipMap(t *testing.T) {
+	// a block of 1 entry {"a": 1} with size in bytes, end of map, int 7
+	dec := NewBinaryDecoder([]byte{0x01, 0x06, 0x02, 'a', 0x02, 0x00, 0x0e})
+	count, err := dec.SkipMap()
+	assert(t, err, nil)
+	assert(t, count, int64(0))
+	value, err := dec.ReadInt()
+	assert(t, err, nil)
+	assert(t, value, int32(7))
+}
+
+func TestSkipValue(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Skipped", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "flag", "type": "boolean"},
+		{"name": "ratio", "type": "double"},
+		{"name": "name", "type": ["null", "string"]},
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 4}},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"]}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "props", "type": {"type": "map", "values": "int"}}
+	]}`)
+
+	record := NewGenericRecord(schema)
+	record.Set("id", int64(42))
+	record.Set("flag", true)
+	record.Set("ratio", 0.5)
+	record.Set("name", "skipped")
+	record.Set("hash", []byte{1, 2, 3, 4})
+	record.Set("kind", "B")
+	record.Set("tags", []interface{}{"a", "b", "c"})
+	record.Set("props", map[string]interface{}{"a": int32(1), "b": int32(2)})
+
+	buffer := &bytes.Buffer{}
+	writer := NewGenericDatumWriter()
+	writer.SetSchema(schema)
+	assert(t, writer.Write(record, NewBinaryEncoder(buffer)), nil)
+
+	dec := NewBinaryDecoder(buffer.Bytes())
+	assert(t, SkipValue(schema, dec), nil)
+	assert(t, dec.Tell(), int64(buffer.Len()))
+
+	assert(t, SkipValue(schema, NewBinaryDecoder(buffer.Bytes()[:buffer.Len()-1])), InvalidLong)
+	assert(t, SkipValue(schema.(*RecordSchema).Fields[3].Type, NewBinaryDecoder([]byte{0x04})), UnionTypeOverflow)
+}
+
+func TestBinaryDecoderLimits(t *testing.T) {
+	options := DecoderOptions{MaxBytesLength: 4, MaxStringLength: 4, MaxBlockCount: 100, MaxCollectionSize: 150}
+
+	_, err := NewBinaryDecoderWithOptions([]byte{0x10, 't', 'o', 'o', ' ', 'l', 'o', 'n', 'g'}, options).ReadString()
+	assert(t, err, &LimitError{Limit: "string length", Value: 8, Max: 4})
+	_, err = NewBinaryDecoderWithOptions([]byte{0x0a, 1, 2, 3, 4, 5}, options).ReadBytes()
+	assert(t, err, &LimitError{Limit: "bytes length", Value: 5, Max: 4})
+
+	// a huge block count fails before anything is allocated
+	_, err = NewBinaryDecoderWithOptions([]byte{0xfe, 0xff, 0xff, 0xff, 0x0f}, options).ReadArrayStart()
+	assert(t, err, &LimitError{Limit: "block count", Value: 2147483647, Max: 100})
+
+	buf := &bytes.Buffer{}
+	enc := NewBinaryEncoder(buf)
+	enc.WriteMapStart(100)
+	enc.WriteMapNext(0)
+	enc.WriteArrayStart(100)
+	enc.WriteArrayNext(100)
+	dec := NewBinaryDecoderWithOptions(buf.Bytes(), options)
+	count, err := dec.ReadMapStart()
+	assert(t, err, nil)
+	assert(t, count, int64(100))
+	count, err = dec.MapNext()
+	assert(t, err, nil)
+	assert(t, count, int64(0))
+	_, err = dec.ReadArrayStart()
+	assert(t, err, nil)
+	_, err = dec.ArrayNext()
+	assert(t, err, &LimitError{Limit: "collection size", Value: 200, Max: 150})
+}
@@ -2,6 +2,8 @@ package avro
 
 import (
 	"bytes"
+	"errors"
+	"io/ioutil"
 	"math/rand"
 	"testing"
 )
@@ -132,3 +134,80 @@ func testPrimitiveSerialization(t *testing.T, random func(int) interface{}, seri
 		}
 	}
 }
+
+// failingWriter accepts a given number of bytes and fails all writes after that.
+type failingWriter struct {
+	remaining int
+	flushed   bool
+}
+
+var errWriteFailed = errors.New("disk full")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.remaining {
+		n := w.remaining
+		w.remaining = 0
+		return n, errWriteFailed
+	}
+	w.remaining -= len(p)
+	return len(p), nil
+}
+
+func (w *failingWriter) Flush() error {
+	w.flushed = true
+	return nil
+}
+
+func TestBinaryEncoderStickyError(t *testing.T) {
+	output := &failingWriter{remaining: 3}
+	enc := NewBinaryEncoder(output)
+	enc.WriteInt(1)
+	enc.WriteDouble(1.5)
+	assert(t, enc.Err(), errWriteFailed)
+	assert(t, output.remaining, 0)
+
+	// writes after an error are ignored
+	output.remaining = 100
+	enc.WriteString("ignored")
+	assert(t, output.remaining, 100)
+	assert(t, enc.Flush(), errWriteFailed)
+	assert(t, output.flushed, false)
+
+	enc.Reset(output)
+	enc.WriteLong(1)
+	assert(t, enc.Flush(), nil)
+	assert(t, output.flushed, true)
+
+	writer := NewGenericDatumWriter()
+	writer.SetSchema(MustParseSchema(`"string"`))
+	err := writer.Write("too long", NewBinaryEncoder(&failingWriter{remaining: 4}))
+	assert(t, err, errWriteFailed)
+}
+
+func TestBinaryEncoderPool(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enc := AcquireBinaryEncoder(&failingWriter{})
+	enc.WriteBoolean(true)
+	assert(t, enc.Err(), errWriteFailed)
+	ReleaseBinaryEncoder(enc)
+
+	enc = AcquireBinaryEncoder(buf)
+	assert(t, enc.Err(), nil)
+	enc.WriteBoolean(true)
+	enc.WriteFloat(2.5)
+	enc.WriteString("ok")
+	ReleaseBinaryEncoder(enc)
+	assert(t, buf.Bytes(), []byte{0x01, 0x00, 0x00, 0x20, 0x40, 0x04, 'o', 'k'})
+}
+
+func TestBinaryEncoderAllocations(t *testing.T) {
+	enc := NewBinaryEncoder(ioutil.Discard)
+	allocs := testing.AllocsPerRun(100, func() {
+		enc.WriteBoolean(true)
+		enc.WriteInt(-12345)
+		enc.WriteLong(1 << 40)
+		enc.WriteFloat(1.5)
+		enc.WriteDouble(-2.5)
+	})
+	assert(t, allocs, float64(0))
+}
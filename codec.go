@@ -0,0 +1,160 @@
+package avro
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// Codec names as defined by the Avro specification. Only null, deflate and bzip2 codecs are registered by default, the
+// others depend on packages outside of Go standard library and are registered with github.com/elodina/go-avro/codecs.
+const (
+	NullCodec      = "null"
+	DeflateCodec   = "deflate"
+	SnappyCodec    = "snappy"
+	ZstandardCodec = "zstandard"
+	Bzip2Codec     = "bzip2"
+	XZCodec        = "xz"
+)
+
+// Codec compresses and decompresses data blocks of Avro Object Container Files.
+// Codecs must be safe for concurrent use.
+type Codec interface {
+	// Name returns the codec name that is stored in "avro.codec" file header field.
+	Name() string
+
+	// Encode compresses a given data block.
+	Encode(block []byte) ([]byte, error)
+
+	// Decode decompresses a given data block. Returns a LimitError without decompressing the rest of the block once
+	// the decompressed data gets longer than maxSize bytes.
+	Decode(block []byte, maxSize int64) ([]byte, error)
+}
+
+var codecs = make(map[string]Codec)
+var codecsLock sync.RWMutex
+
+func init() {
+	RegisterCodec(nullCodec{})
+	RegisterCodec(NewDeflateCodec(flate.DefaultCompression))
+	RegisterCodec(bzip2Codec{})
+}
+
+// RegisterCodec registers a given Codec so that DataFileReader is able to read files compressed with it.
+// Replaces a previously registered Codec with the same name.
+func RegisterCodec(codec Codec) {
+	codecsLock.Lock()
+	defer codecsLock.Unlock()
+	codecs[codec.Name()] = codec
+}
+
+// GetCodec returns a registered Codec with a given name. Returns an error if there is no such codec.
+func GetCodec(name string) (Codec, error) {
+	codecsLock.RLock()
+	defer codecsLock.RUnlock()
+	if codec, ok := codecs[name]; ok {
+		return codec, nil
+	}
+
+	return nil, fmt.Errorf("Unknown codec: %s", name)
+}
+
+// ReadBlock reads decompressed data from a given io.Reader for Codec implementations. Returns a LimitError if there
+// are more than maxSize bytes to read, without reading any further than that. Reads everything if maxSize is not positive.
+func ReadBlock(reader io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		return ioutil.ReadAll(reader)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if err = checkLimit("block size", int64(len(data)), maxSize); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+type nullCodec struct{}
+
+// Name returns the name of null codec.
+func (nullCodec) Name() string {
+	return NullCodec
+}
+
+// Encode returns the given block as is.
+func (nullCodec) Encode(block []byte) ([]byte, error) {
+	return block, nil
+}
+
+// Decode returns the given block as is.
+func (nullCodec) Decode(block []byte, maxSize int64) ([]byte, error) {
+	if err := checkLimit("block size", int64(len(block)), maxSize); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+type deflateCodec struct {
+	level int
+}
+
+// NewDeflateCodec creates a Codec that compresses data with deflate (RFC 1951) using a given compression level
+// as defined in compress/flate package.
+func NewDeflateCodec(level int) Codec {
+	return &deflateCodec{level: level}
+}
+
+// Name returns the name of deflate codec.
+func (*deflateCodec) Name() string {
+	return DeflateCodec
+}
+
+// Encode compresses a given block with deflate.
+func (c *deflateCodec) Encode(block []byte) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	writer, err := flate.NewWriter(buffer, c.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = writer.Write(block); err != nil {
+		return nil, err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Decode decompresses a given deflate compressed block.
+func (*deflateCodec) Decode(block []byte, maxSize int64) ([]byte, error) {
+	reader := flate.NewReader(bytes.NewReader(block))
+	defer reader.Close()
+	return ReadBlock(reader, maxSize)
+}
+
+// bzip2Codec can only decompress data as Go standard library does not provide a bzip2 compressor.
+type bzip2Codec struct{}
+
+// Name returns the name of bzip2 codec.
+func (bzip2Codec) Name() string {
+	return Bzip2Codec
+}
+
+// Encode always returns an error as writing bzip2 compressed blocks is not supported.
+func (bzip2Codec) Encode(block []byte) ([]byte, error) {
+	return nil, errors.New("Writing bzip2 compressed data is not supported")
+}
+
+// Decode decompresses a given bzip2 compressed block.
+func (bzip2Codec) Decode(block []byte, maxSize int64) ([]byte, error) {
+	return ReadBlock(bzip2.NewReader(bytes.NewReader(block)), maxSize)
+}
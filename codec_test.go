@@ -0,0 +1,137 @@
+package avro
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"testing"
+)
+
+func TestDataFileCodecs(t *testing.T) {
+	codecs := []Codec{
+		nullCodec{},
+		NewDeflateCodec(flate.BestCompression),
+	}
+
+	for _, codec := range codecs {
+		schema := MustParseSchema(primitiveSchemaRaw)
+		buf := &bytes.Buffer{}
+		dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter(), WithCodec(codec))
+		assert(t, err, nil)
+		for i := 0; i < 100; i++ {
+			assert(t, dfw.Write(&primitive{LongField: int64(i), StringField: "compressible compressible"}), nil)
+			if i%30 == 0 {
+				assert(t, dfw.Flush(), nil)
+			}
+		}
+		assert(t, dfw.Close(), nil)
+
+		dfr, err := newDataFileReaderBytes(buf.Bytes(), NewSpecificDatumReader())
+		assert(t, err, nil)
+		assert(t, string(dfr.header.Meta[codecKey]), codec.Name())
+		for i := 0; i < 100; i++ {
+			var p primitive
+			ok, err := dfr.Next(&p)
+			assert(t, err, nil)
+			assert(t, ok, true)
+			assert(t, p.LongField, int64(i))
+			assert(t, p.StringField, "compressible compressible")
+		}
+		ok, err := dfr.Next(&primitive{})
+		assert(t, err, nil)
+		assert(t, ok, false)
+	}
+}
+
+func TestDataFileReaderMaxBlockSize(t *testing.T) {
+	codecs := []Codec{
+		nullCodec{},
+		NewDeflateCodec(flate.BestCompression),
+	}
+
+	for _, codec := range codecs {
+		buf := &bytes.Buffer{}
+		dfw, err := NewDataFileWriter(buf, MustParseSchema(primitiveSchemaRaw), NewSpecificDatumWriter(), WithCodec(codec))
+		assert(t, err, nil)
+		for i := 0; i < 100; i++ {
+			assert(t, dfw.Write(&primitive{StringField: "compressible compressible"}), nil)
+		}
+		assert(t, dfw.Close(), nil)
+
+		for _, maxSize := range []int64{0, 10000} {
+			dfr, err := NewDataFileReaderWithOptions(bytes.NewReader(buf.Bytes()), NewSpecificDatumReader(), DecoderOptions{MaxBlockSize: maxSize})
+			assert(t, err, nil)
+			ok, err := dfr.Next(&primitive{})
+			assert(t, err, nil)
+			assert(t, ok, true)
+		}
+
+		dfr, err := NewDataFileReaderWithOptions(bytes.NewReader(buf.Bytes()), NewSpecificDatumReader(), DecoderOptions{MaxBlockSize: 1000})
+		assert(t, err, nil)
+		_, err = dfr.Next(&primitive{})
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) {
+			t.Fatalf("Expected a LimitError for %s codec, got %v", codec.Name(), err)
+		}
+		assert(t, limitErr.Limit, "block size")
+		assert(t, limitErr.Max, int64(1000))
+	}
+}
+
+func TestDataFileWriterCodecName(t *testing.T) {
+	_, err := NewDataFileWriter(&bytes.Buffer{}, MustParseSchema(primitiveSchemaRaw), NewSpecificDatumWriter(), WithCodecName("unknown"))
+	if err == nil {
+		t.Fatal("Expected an error for unknown codec")
+	}
+
+	buf := &bytes.Buffer{}
+	_, err = NewDataFileWriter(buf, MustParseSchema(primitiveSchemaRaw), NewSpecificDatumWriter(), WithCodecName(DeflateCodec))
+	assert(t, err, nil)
+	assert(t, bytes.Contains(buf.Bytes(), []byte(DeflateCodec)), true)
+}
+
+func TestDataFileReaderUnknownCodec(t *testing.T) {
+	RegisterCodec(&renamedCodec{Codec: nullCodec{}, name: "custom"})
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, MustParseSchema(primitiveSchemaRaw), NewSpecificDatumWriter(), WithCodecName("custom"))
+	assert(t, err, nil)
+	assert(t, dfw.Close(), nil)
+
+	_, err = newDataFileReaderBytes(buf.Bytes(), NewSpecificDatumReader())
+	assert(t, err, nil)
+
+	encoded := bytes.Replace(buf.Bytes(), []byte("custom"), []byte("brotli"), 1)
+	_, err = newDataFileReaderBytes(encoded, NewSpecificDatumReader())
+	assert(t, err.Error(), "Unknown codec: brotli")
+}
+
+type renamedCodec struct {
+	Codec
+	name string
+}
+
+func (c *renamedCodec) Name() string {
+	return c.name
+}
+
+func TestBzip2Codec(t *testing.T) {
+	compressed := []byte{0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x61, 0x79, 0x0e, 0xc8, 0x00, 0x00,
+		0x01, 0x99, 0x80, 0x40, 0x00, 0x10, 0x00, 0x38, 0x2c, 0xd1, 0x10, 0x20, 0x00, 0x31, 0x00, 0xd0, 0x01, 0x4c, 0x09,
+		0xe8, 0xca, 0x30, 0xc1, 0x3c, 0x84, 0x01, 0x25, 0x2a, 0xf8, 0xbb, 0x92, 0x29, 0xc2, 0x84, 0x83, 0x0b, 0xc8, 0x76,
+		0x40}
+
+	codec, err := GetCodec(Bzip2Codec)
+	assert(t, err, nil)
+	decoded, err := codec.Decode(compressed, 0)
+	assert(t, err, nil)
+	assert(t, string(decoded), "avro bzip2 block")
+
+	if _, err = codec.Encode(decoded); err == nil {
+		t.Fatal("Expected an error for bzip2 compression")
+	}
+
+	_, err = NewDataFileWriter(&bytes.Buffer{}, MustParseSchema(primitiveSchemaRaw), NewSpecificDatumWriter(), WithCodecName(Bzip2Codec))
+	assert(t, err.Error(), "Codec bzip2 cannot be used for writing: Writing bzip2 compressed data is not supported")
+	_, err = NewDataFileWriter(&bytes.Buffer{}, MustParseSchema(primitiveSchemaRaw), NewSpecificDatumWriter(), WithCodec(codec))
+	assert(t, err.Error(), "Codec bzip2 cannot be used for writing: Writing bzip2 compressed data is not supported")
+}
@@ -0,0 +1,156 @@
+// Package codecs provides the Avro Object Container File codecs that depend on packages outside of Go standard library:
+// snappy, zstandard and xz. Register them before reading or writing files compressed with any of them:
+//
+//	codecs.Register()
+//	writer, err := avro.NewDataFileWriter(output, schema, datumWriter, avro.WithCodecName(avro.SnappyCodec))
+package codecs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"sync"
+
+	"github.com/elodina/go-avro"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Register registers snappy, zstandard and xz codecs with their default settings, so that avro.DataFileReader is
+// able to read files compressed with them and avro.WithCodecName accepts their names.
+func Register() {
+	avro.RegisterCodec(NewSnappyCodec())
+	avro.RegisterCodec(NewZstandardCodec(zstd.SpeedDefault))
+	avro.RegisterCodec(NewXZCodec())
+}
+
+// snappyCodec compresses blocks with snappy and appends a big-endian CRC32 checksum of uncompressed data.
+type snappyCodec struct{}
+
+// NewSnappyCodec creates a Codec that compresses data with snappy.
+func NewSnappyCodec() avro.Codec {
+	return snappyCodec{}
+}
+
+// Name returns the name of snappy codec.
+func (snappyCodec) Name() string {
+	return avro.SnappyCodec
+}
+
+// Encode compresses a given block with snappy.
+func (snappyCodec) Encode(block []byte) ([]byte, error) {
+	encoded := snappy.Encode(nil, block)
+	checksum := make([]byte, 4)
+	binary.BigEndian.PutUint32(checksum, crc32.ChecksumIEEE(block))
+	return append(encoded, checksum...), nil
+}
+
+// Decode decompresses a given snappy compressed block and verifies its checksum.
+func (snappyCodec) Decode(block []byte, maxSize int64) ([]byte, error) {
+	if len(block) < 4 {
+		return nil, errors.New("Snappy block is too short")
+	}
+
+	// snappy blocks start with the decompressed length, so it is checked before allocating anything
+	length, err := snappy.DecodedLen(block[:len(block)-4])
+	if err != nil {
+		return nil, err
+	}
+	if maxSize > 0 && int64(length) > maxSize {
+		return nil, &avro.LimitError{Limit: "block size", Value: int64(length), Max: maxSize}
+	}
+
+	decoded, err := snappy.Decode(nil, block[:len(block)-4])
+	if err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(decoded) != binary.BigEndian.Uint32(block[len(block)-4:]) {
+		return nil, errors.New("Snappy block checksum mismatch")
+	}
+
+	return decoded, nil
+}
+
+type zstandardCodec struct {
+	level zstd.EncoderLevel
+
+	once    sync.Once
+	encoder *zstd.Encoder
+	err     error
+}
+
+// NewZstandardCodec creates a Codec that compresses data with Zstandard using a given compression level.
+func NewZstandardCodec(level zstd.EncoderLevel) avro.Codec {
+	return &zstandardCodec{level: level}
+}
+
+// Name returns the name of zstandard codec.
+func (*zstandardCodec) Name() string {
+	return avro.ZstandardCodec
+}
+
+func (c *zstandardCodec) init() error {
+	c.once.Do(func() {
+		c.encoder, c.err = zstd.NewWriter(nil, zstd.WithEncoderLevel(c.level))
+	})
+	return c.err
+}
+
+// Encode compresses a given block with Zstandard.
+func (c *zstandardCodec) Encode(block []byte) ([]byte, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	return c.encoder.EncodeAll(block, nil), nil
+}
+
+// Decode decompresses a given Zstandard compressed block. The block is decompressed as a stream, as opposed to
+// zstd.Decoder.DecodeAll, so that it can be stopped once the decompressed data exceeds the maximum size.
+func (*zstandardCodec) Decode(block []byte, maxSize int64) ([]byte, error) {
+	decoder, err := zstd.NewReader(bytes.NewReader(block), zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+	return avro.ReadBlock(decoder, maxSize)
+}
+
+type xzCodec struct{}
+
+// NewXZCodec creates a Codec that compresses data with xz.
+func NewXZCodec() avro.Codec {
+	return xzCodec{}
+}
+
+// Name returns the name of xz codec.
+func (xzCodec) Name() string {
+	return avro.XZCodec
+}
+
+// Encode compresses a given block with xz.
+func (xzCodec) Encode(block []byte) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	writer, err := xz.NewWriter(buffer)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = writer.Write(block); err != nil {
+		return nil, err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Decode decompresses a given xz compressed block.
+func (xzCodec) Decode(block []byte, maxSize int64) ([]byte, error) {
+	reader, err := xz.NewReader(bytes.NewReader(block))
+	if err != nil {
+		return nil, err
+	}
+	return avro.ReadBlock(reader, maxSize)
+}
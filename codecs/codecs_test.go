@@ -0,0 +1,103 @@
+package codecs
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/elodina/go-avro"
+	"github.com/klauspost/compress/zstd"
+)
+
+const valueSchemaRaw = `{"type": "record", "name": "Value", "fields": [{"name": "text", "type": "string"}]}`
+
+func assert(t *testing.T, actual interface{}, expected interface{}) {
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %v, actual %v", expected, actual)
+	}
+}
+
+func writeValues(t *testing.T, codec avro.Codec, count int) []byte {
+	schema := avro.MustParseSchema(valueSchemaRaw)
+	buf := &bytes.Buffer{}
+	dfw, err := avro.NewDataFileWriter(buf, schema, avro.NewGenericDatumWriter(), avro.WithCodec(codec))
+	assert(t, err, nil)
+	for i := 0; i < count; i++ {
+		record := avro.NewGenericRecord(schema)
+		record.Set("text", "compressible compressible")
+		assert(t, dfw.Write(record), nil)
+		if i%30 == 0 {
+			assert(t, dfw.Flush(), nil)
+		}
+	}
+	assert(t, dfw.Close(), nil)
+	return buf.Bytes()
+}
+
+func TestCodecs(t *testing.T) {
+	Register()
+	codecs := []avro.Codec{NewSnappyCodec(), NewZstandardCodec(zstd.SpeedFastest), NewXZCodec()}
+
+	for _, codec := range codecs {
+		dfr, err := avro.NewDataFileReaderFromReader(bytes.NewReader(writeValues(t, codec, 100)), avro.NewGenericDatumReader())
+		assert(t, err, nil)
+		assert(t, string(dfr.Metadata("avro.codec")), codec.Name())
+		for i := 0; i < 100; i++ {
+			record := avro.NewGenericRecord(avro.MustParseSchema(valueSchemaRaw))
+			ok, err := dfr.Next(record)
+			assert(t, err, nil)
+			assert(t, ok, true)
+			assert(t, record.Get("text"), "compressible compressible")
+		}
+		ok, err := dfr.Next(avro.NewGenericRecord(avro.MustParseSchema(valueSchemaRaw)))
+		assert(t, err, nil)
+		assert(t, ok, false)
+	}
+}
+
+func TestCodecsMaxBlockSize(t *testing.T) {
+	Register()
+	codecs := []avro.Codec{NewSnappyCodec(), NewZstandardCodec(zstd.SpeedFastest), NewXZCodec()}
+
+	for _, codec := range codecs {
+		file := writeValues(t, codec, 100)
+		dfr, err := avro.NewDataFileReaderWithOptions(bytes.NewReader(file), avro.NewGenericDatumReader(), avro.DecoderOptions{MaxBlockSize: 500})
+		assert(t, err, nil)
+		// the first value is flushed in a block of its own
+		ok, err := dfr.Next(avro.NewGenericRecord(avro.MustParseSchema(valueSchemaRaw)))
+		assert(t, err, nil)
+		assert(t, ok, true)
+
+		_, err = dfr.Next(avro.NewGenericRecord(avro.MustParseSchema(valueSchemaRaw)))
+		var limitErr *avro.LimitError
+		if !errors.As(err, &limitErr) {
+			t.Fatalf("Expected a LimitError for %s codec, got %v", codec.Name(), err)
+		}
+		assert(t, limitErr.Limit, "block size")
+		assert(t, limitErr.Max, int64(500))
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register()
+	for _, name := range []string{avro.SnappyCodec, avro.ZstandardCodec, avro.XZCodec} {
+		codec, err := avro.GetCodec(name)
+		assert(t, err, nil)
+		assert(t, codec.Name(), name)
+	}
+}
+
+func TestSnappyCodecChecksum(t *testing.T) {
+	encoded, err := NewSnappyCodec().Encode([]byte("some data"))
+	assert(t, err, nil)
+
+	decoded, err := NewSnappyCodec().Decode(encoded, 0)
+	assert(t, err, nil)
+	assert(t, decoded, []byte("some data"))
+
+	encoded[len(encoded)-1]++
+	if _, err = NewSnappyCodec().Decode(encoded, 0); err == nil {
+		t.Fatal("Expected an error for invalid snappy checksum")
+	}
+}
@@ -17,9 +17,14 @@ package avro
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"go/build/constraint"
 	"go/format"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -27,26 +32,95 @@ import (
 type CodeGenerator struct {
 	rawSchemas []string
 
-	structs           map[string]*bytes.Buffer
+	// name of the package for namespaces that are not mapped to other packages
+	packageName string
+
+	// Go import paths by Avro namespaces
+	namespaceMap map[string]string
+
+	// generated packages by import paths, the default package has an empty one
+	packages map[string]*goPackage
+
+	// package the code is currently generated for
+	pkg *goPackage
+
+	// namespaces of named types including those inherited from enclosing types
+	namespaces map[Schema]string
+
+	// counter for unique variable names in generated Read and Write methods
+	vars int
+
+	// comment put on top of each generated file
+	header string
+
+	// build constraint of generated files
+	buildTags string
+
+	// whether record schemas are generated as compact JSON constants
+	schemaConstants bool
+}
+
+// goPackage holds the code generated for a single Go package.
+type goPackage struct {
+	name       string
+	importPath string
+
+	// full Avro names of generated types by Go type names
+	types             map[string]string
 	codeSnippets      []*bytes.Buffer
 	schemaDefinitions *bytes.Buffer
+
+	// packages imported by the generated code
+	imports map[string]bool
 }
 
 // NewCodeGenerator creates a new CodeGenerator for given Avro schemas.
 func NewCodeGenerator(schemas []string) *CodeGenerator {
 	return &CodeGenerator{
-		rawSchemas:        schemas,
-		structs:           make(map[string]*bytes.Buffer),
-		codeSnippets:      make([]*bytes.Buffer, 0),
-		schemaDefinitions: &bytes.Buffer{},
+		rawSchemas:   schemas,
+		namespaceMap: make(map[string]string),
+		packages:     make(map[string]*goPackage),
+		namespaces:   make(map[Schema]string),
 	}
 }
 
+// SetPackage sets the name of the Go package for types in namespaces that are not mapped with MapNamespace.
+// Defaults to the last part of the namespace of the first schema.
+func (codegen *CodeGenerator) SetPackage(name string) {
+	codegen.packageName = name
+}
+
+// MapNamespace puts types of a given Avro namespace into a separate Go package with a given import path, e.g.
+// "com.example.users" to "github.com/example/project/users". The package name is the last element of the path.
+// Types in other packages are referenced with qualified names and imported.
+func (codegen *CodeGenerator) MapNamespace(namespace string, importPath string) {
+	codegen.namespaceMap[namespace] = importPath
+}
+
+// SetHeader sets a comment to put on top of each generated file, e.g. "Code generated by codegen. DO NOT EDIT.".
+// Lines of a given text are prefixed with "// ".
+func (codegen *CodeGenerator) SetHeader(header string) {
+	codegen.header = header
+}
+
+// SetBuildTags sets a build constraint expression of generated files, e.g. "linux && !appengine".
+// It is written as both a //go:build and a // +build line.
+func (codegen *CodeGenerator) SetBuildTags(buildTags string) {
+	codegen.buildTags = buildTags
+}
+
+// SetSchemaConstants makes record schemas generated as compact JSON constants parsed with avro.MustParseSchema
+// instead of variables parsed with avro.ParseSchema along with errors checked on each Schema call.
+func (codegen *CodeGenerator) SetSchemaConstants(schemaConstants bool) {
+	codegen.schemaConstants = schemaConstants
+}
+
 type recordSchemaInfo struct {
-	schema        *RecordSchema
-	typeName      string
-	schemaVarName string
-	schemaErrName string
+	schema         *RecordSchema
+	typeName       string
+	schemaVarName  string
+	schemaErrName  string
+	schemaJSONName string
 }
 
 func newRecordSchemaInfo(schema *RecordSchema) (*recordSchemaInfo, error) {
@@ -54,13 +128,14 @@ func newRecordSchemaInfo(schema *RecordSchema) (*recordSchemaInfo, error) {
 		return nil, errors.New("Name not set.")
 	}
 
-	typeName := fmt.Sprintf("%s%s", strings.ToUpper(schema.Name[:1]), schema.Name[1:])
+	typeName := goTypeName(schema.Name)
 
 	return &recordSchemaInfo{
-		schema:        schema,
-		typeName:      typeName,
-		schemaVarName: fmt.Sprintf("_%s_schema", typeName),
-		schemaErrName: fmt.Sprintf("_%s_schema_err", typeName),
+		schema:         schema,
+		typeName:       typeName,
+		schemaVarName:  fmt.Sprintf("_%s_schema", typeName),
+		schemaErrName:  fmt.Sprintf("_%s_schema_err", typeName),
+		schemaJSONName: fmt.Sprintf("_%s_schemaJSON", typeName),
 	}, nil
 }
 
@@ -76,99 +151,391 @@ func newEnumSchemaInfo(schema *EnumSchema) (*enumSchemaInfo, error) {
 
 	return &enumSchemaInfo{
 		schema:   schema,
-		typeName: fmt.Sprintf("%s%s", strings.ToUpper(schema.Name[:1]), schema.Name[1:]),
+		typeName: goTypeName(schema.Name),
 	}, nil
 }
 
+type unionSchemaInfo struct {
+	schema   *UnionSchema
+	typeName string
+
+	// Go field names of union branches, empty for null
+	fields []string
+}
+
+func newUnionSchemaInfo(schema *UnionSchema) (*unionSchemaInfo, error) {
+	info := &unionSchemaInfo{schema: schema, typeName: "Union", fields: make([]string, len(schema.Types))}
+	for i, branch := range schema.Types {
+		name, err := unionBranchName(branch)
+		if err != nil {
+			return nil, err
+		}
+		info.typeName += name
+		if branch.Type() != Null {
+			info.fields[i] = name
+		}
+	}
+
+	return info, nil
+}
+
+// unionBranchName returns a name of a union branch used for union type and field names, e.g. ArrayString.
+func unionBranchName(schema Schema) (string, error) {
+	var name string
+	switch schema.Type() {
+	case Array:
+		items, err := unionBranchName(schema.(*ArraySchema).Items)
+		return "Array" + items, err
+	case Map:
+		values, err := unionBranchName(schema.(*MapSchema).Values)
+		return "Map" + values, err
+	case Union:
+		info, err := newUnionSchemaInfo(schema.(*UnionSchema))
+		if err != nil {
+			return "", err
+		}
+		return info.typeName, nil
+	default:
+		name = schema.GetName()
+	}
+	if name == "" {
+		return "", errors.New("Name not set.")
+	}
+
+	return goTypeName(name), nil
+}
+
+// goTypeName returns the name of a Go type generated for a given Avro name without namespace.
+func goTypeName(name string) string {
+	name = name[strings.LastIndex(name, ".")+1:]
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
 // Generate generates source code for Avro schemas specified on creation.
 // The ouput is Go formatted source code that contains struct definitions for all given schemas.
-// May return an error if code generation fails, e.g. due to unparsable schema.
+// May return an error if code generation fails, e.g. due to unparsable schema, or if namespaces are mapped to
+// more than one package, GenerateFiles should be used then.
 func (codegen *CodeGenerator) Generate() (string, error) {
-	for index, rawSchema := range codegen.rawSchemas {
-		parsedSchema, err := ParseSchema(rawSchema)
+	files, err := codegen.GenerateFiles()
+	if err != nil {
+		return "", err
+	}
+	if len(files) > 1 {
+		return "", errors.New("Schemas are mapped to more than one package, use GenerateFiles.")
+	}
+
+	for _, code := range files {
+		return code, nil
+	}
+	return "", nil
+}
+
+// GenerateFiles generates source code for Avro schemas specified on creation with a file per Go package.
+// Returns Go formatted source code by import paths of packages namespaces are mapped to with MapNamespace,
+// the source code of the default package has an empty key.
+// May return an error if code generation fails, e.g. due to unparsable schema or conflicting type names.
+func (codegen *CodeGenerator) GenerateFiles() (map[string]string, error) {
+	schemas, err := codegen.parseSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, schema := range schemas {
+		err = codegen.writeType(schema)
 		if err != nil {
-			return "", err
+			return nil, err
+		}
+	}
+
+	fileHeader, err := codegen.fileHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	for importPath, pkg := range codegen.packages {
+		formatted, err := format.Source([]byte(fileHeader + pkg.collectResult()))
+		if err != nil {
+			return nil, err
+		}
+		files[importPath] = string(formatted)
+	}
+
+	return files, nil
+}
+
+// fileHeader returns the header comment and build constraints that go before the package clause of each file.
+func (codegen *CodeGenerator) fileHeader() (string, error) {
+	header := &bytes.Buffer{}
+	if codegen.header != "" {
+		for _, line := range strings.Split(strings.TrimRight(codegen.header, "\n"), "\n") {
+			fmt.Fprintf(header, "// %s\n", line)
 		}
+		header.WriteString("\n")
+	}
 
-		schema, ok := parsedSchema.(*RecordSchema)
-		if !ok {
-			return "", errors.New("Not a Record schema.")
+	if codegen.buildTags != "" {
+		expr, err := constraint.Parse("//go:build " + codegen.buildTags)
+		if err != nil {
+			return "", fmt.Errorf("Invalid build tags %s: %s", codegen.buildTags, err)
 		}
-		schemaInfo, err := newRecordSchemaInfo(schema)
+		plusBuildLines, err := constraint.PlusBuildLines(expr)
 		if err != nil {
-			return "", err
+			return "", fmt.Errorf("Invalid build tags %s: %s", codegen.buildTags, err)
 		}
+		fmt.Fprintf(header, "//go:build %s\n%s\n\n", expr, strings.Join(plusBuildLines, "\n"))
+	}
 
-		buffer := &bytes.Buffer{}
-		codegen.codeSnippets = append(codegen.codeSnippets, buffer)
+	return header.String(), nil
+}
 
-		// write package and import only once
-		if index == 0 {
-			err = codegen.writePackageName(schemaInfo)
-			if err != nil {
-				return "", err
+func (pkg *goPackage) collectResult() string {
+	results := make([]string, len(pkg.codeSnippets)+2)
+	results[0] = fmt.Sprintf("package %s\n\n%s", pkg.name, pkg.importStatement())
+	for i, snippet := range pkg.codeSnippets {
+		results[i+1] = snippet.String()
+	}
+	results[len(results)-1] = pkg.schemaDefinitions.String()
+
+	return strings.Join(results, "\n")
+}
+
+// importStatement returns the import declaration of all packages the generated code uses.
+func (pkg *goPackage) importStatement() string {
+	imports := make([]string, 0, len(pkg.imports))
+	for path := range pkg.imports {
+		imports = append(imports, fmt.Sprintf("%q\n", path))
+	}
+	sort.Strings(imports)
+
+	return fmt.Sprintf("import (\n%s)\n", strings.Join(imports, ""))
+}
+
+// parseSchemas parses schemas and protocols given on creation with a shared registry, so that named types defined
+// in one of them may be referenced from others regardless of their order. Returns top-level schemas and types
+// declared by protocols in the order they have been parsed.
+func (codegen *CodeGenerator) parseSchemas() ([]Schema, error) {
+	registry := make(map[string]Schema)
+	schemas := make([]Schema, 0, len(codegen.rawSchemas))
+	pending := codegen.rawSchemas
+	for len(pending) > 0 {
+		var failed []string
+		var firstErr error
+		for _, rawSchema := range pending {
+			// a schema that fails to parse may leave incomplete types in the registry, so it gets a copy
+			attempt := make(map[string]Schema, len(registry))
+			for name, schema := range registry {
+				attempt[name] = schema
 			}
 
-			err = codegen.writeImportStatement()
+			parsed, namespace, err := codegen.parseSchema(rawSchema, attempt)
 			if err != nil {
-				return "", err
+				failed = append(failed, rawSchema)
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			registry = attempt
+			for _, schema := range parsed {
+				codegen.collectNamespaces(schema, namespace)
 			}
+			schemas = append(schemas, parsed...)
 		}
 
-		err = codegen.writeStruct(schemaInfo)
-		if err != nil {
-			return "", err
+		// retry schemas that may reference types defined by schemas parsed after them until nothing changes
+		if len(failed) == len(pending) {
+			return nil, firstErr
 		}
+		pending = failed
 	}
 
-	formatted, err := format.Source([]byte(codegen.collectResult()))
-	if err != nil {
-		return "", err
+	return schemas, nil
+}
+
+// parseSchema parses a given schema or protocol and returns its types along with the namespace they are declared in.
+func (codegen *CodeGenerator) parseSchema(rawSchema string, registry map[string]Schema) ([]Schema, string, error) {
+	var v map[string]interface{}
+	if err := json.Unmarshal([]byte(rawSchema), &v); err == nil {
+		if _, ok := v[protocolProtocolField]; ok {
+			protocol, err := parseProtocolWithRegistry(rawSchema, registry)
+			if err != nil {
+				return nil, "", err
+			}
+			return append(protocol.Types, messageTypes(protocol)...), protocol.Namespace, nil
+		}
 	}
 
-	return string(formatted), nil
+	schema, err := ParseSchemaWithRegistry(rawSchema, registry)
+	if err != nil {
+		return nil, "", err
+	}
+	return []Schema{schema}, "", nil
 }
 
-func (codegen *CodeGenerator) collectResult() string {
-	results := make([]string, len(codegen.codeSnippets)+1)
-	for i, snippet := range codegen.codeSnippets {
-		results[i] = snippet.String()
+// writeType generates Go types for a given top-level schema. Fixed types are represented with byte slices and
+// unions, arrays and maps hold types to generate, so only named types they contain are generated.
+func (codegen *CodeGenerator) writeType(schema Schema) error {
+	switch schema := schema.(type) {
+	case *RecordSchema:
+		info, err := newRecordSchemaInfo(schema)
+		if err != nil {
+			return err
+		}
+		return codegen.writeStruct(info)
+	case *EnumSchema:
+		info, err := newEnumSchemaInfo(schema)
+		if err != nil {
+			return err
+		}
+		return codegen.writeEnum(info)
+	case *UnionSchema:
+		for _, branch := range schema.Types {
+			if err := codegen.writeType(branch); err != nil {
+				return err
+			}
+		}
+	case *ArraySchema:
+		return codegen.writeType(schema.Items)
+	case *MapSchema:
+		return codegen.writeType(schema.Values)
 	}
-	results[len(results)-1] = codegen.schemaDefinitions.String()
 
-	return strings.Join(results, "\n")
+	return nil
 }
 
-func (codegen *CodeGenerator) writePackageName(info *recordSchemaInfo) error {
-	buffer := codegen.codeSnippets[0]
-	_, err := buffer.WriteString("package ")
-	if err != nil {
-		return err
+// messageTypes returns schemas of request parameters, responses and errors of messages of a given protocol in
+// declaration order, as named types may be declared inline there rather than in protocol types.
+func messageTypes(protocol *Protocol) []Schema {
+	var schemas []Schema
+	for _, name := range protocol.messageNames {
+		message := protocol.Messages[name]
+		for _, field := range message.Request.Fields {
+			schemas = append(schemas, field.Type)
+		}
+		schemas = append(schemas, message.Response)
+		if message.Errors != nil {
+			// the first branch is "string" for system errors
+			schemas = append(schemas, message.Errors.Types[1:]...)
+		}
 	}
+	return schemas
+}
 
-	if info.schema.Namespace == "" {
-		info.schema.Namespace = "avro"
+// collectNamespaces remembers namespaces of named types, as types without a namespace inherit it from enclosing ones.
+func (codegen *CodeGenerator) collectNamespaces(schema Schema, enclosing string) {
+	if _, exists := codegen.namespaces[schema]; exists {
+		// named types are referenced after they are defined
+		return
 	}
 
-	packages := strings.Split(info.schema.Namespace, ".")
-	_, err = buffer.WriteString(fmt.Sprintf("%s\n\n", packages[len(packages)-1]))
-	if err != nil {
-		return err
+	switch schema := schema.(type) {
+	case *RecordSchema:
+		namespace := namespaceOfFullName(canonicalName(schema.Name, schema.Namespace, enclosing))
+		codegen.namespaces[schema] = namespace
+		for _, field := range schema.Fields {
+			codegen.collectNamespaces(field.Type, namespace)
+		}
+	case *EnumSchema:
+		codegen.namespaces[schema] = namespaceOfFullName(canonicalName(schema.Name, schema.Namespace, enclosing))
+	case *FixedSchema:
+		codegen.namespaces[schema] = namespaceOfFullName(canonicalName(schema.Name, schema.Namespace, enclosing))
+	case *ArraySchema:
+		codegen.collectNamespaces(schema.Items, enclosing)
+	case *MapSchema:
+		codegen.collectNamespaces(schema.Values, enclosing)
+	case *UnionSchema:
+		for _, branch := range schema.Types {
+			codegen.collectNamespaces(branch, enclosing)
+		}
 	}
+}
 
-	return nil
+// packageOf returns the package a given named type is generated in.
+func (codegen *CodeGenerator) packageOf(schema Schema) *goPackage {
+	if recursive, ok := schema.(*RecursiveSchema); ok {
+		schema = recursive.Actual
+	}
+	namespace := codegen.namespaces[schema]
+	importPath := codegen.namespaceMap[namespace]
+
+	pkg, exists := codegen.packages[importPath]
+	if !exists {
+		name := codegen.packageName
+		if importPath != "" {
+			name = strings.NewReplacer("-", "_", ".", "_").Replace(path.Base(importPath))
+		} else if name == "" {
+			// the default package is named after the namespace of the first type generated in it
+			name = namespace[strings.LastIndex(namespace, ".")+1:]
+			if name == "" {
+				name = "avro"
+			}
+		}
+		pkg = &goPackage{
+			name:              name,
+			importPath:        importPath,
+			types:             make(map[string]string),
+			schemaDefinitions: &bytes.Buffer{},
+			imports:           map[string]bool{"github.com/elodina/go-avro": true},
+		}
+		codegen.packages[importPath] = pkg
+	}
+	return pkg
 }
 
-func (codegen *CodeGenerator) writeStruct(info *recordSchemaInfo) error {
+// enterPackage makes the package of a given named type current and returns a function that restores the previous one.
+func (codegen *CodeGenerator) enterPackage(schema Schema) func() {
+	previous := codegen.pkg
+	codegen.pkg = codegen.packageOf(schema)
+	return func() {
+		codegen.pkg = previous
+	}
+}
+
+// addType registers a Go type with a given name and the full Avro name in the current package and returns a buffer
+// for its code. Returns a nil buffer if the type has already been generated.
+func (codegen *CodeGenerator) addType(typeName string, fullName string) (*bytes.Buffer, error) {
+	if existing, exists := codegen.pkg.types[typeName]; exists {
+		if existing != fullName {
+			return nil, fmt.Errorf("Types %s and %s are both generated as %s in package %s, map their namespaces to different packages.", existing, fullName, typeName, codegen.pkg.name)
+		}
+		return nil, nil
+	}
+
 	buffer := &bytes.Buffer{}
-	if _, exists := codegen.structs[info.typeName]; exists {
-		return nil
+	codegen.pkg.codeSnippets = append(codegen.pkg.codeSnippets, buffer)
+	codegen.pkg.types[typeName] = fullName
+	return buffer, nil
+}
+
+// qualify returns a given name of a Go type or function generated for a named Avro type qualified with its package
+// name if it is generated in a package other than the current one. The package is imported then.
+func (codegen *CodeGenerator) qualify(schema Schema, name string) (string, error) {
+	pkg := codegen.packageOf(schema)
+	if pkg == codegen.pkg {
+		return name, nil
+	}
+	if pkg.importPath == "" {
+		return "", fmt.Errorf("Type %s in an unmapped namespace can't be referenced from package %s.", schema.GetName(), codegen.pkg.importPath)
 	}
 
-	codegen.codeSnippets = append(codegen.codeSnippets, buffer)
-	codegen.structs[info.typeName] = buffer
+	codegen.pkg.imports[pkg.importPath] = true
+	return pkg.name + "." + name, nil
+}
 
-	err := codegen.writeStructSchemaVar(info)
+// fullName returns the full Avro name of a given named type.
+func (codegen *CodeGenerator) fullName(schema Schema) string {
+	return getFullName(schema.GetName(), codegen.namespaces[schema])
+}
+
+func (codegen *CodeGenerator) writeStruct(info *recordSchemaInfo) error {
+	defer codegen.enterPackage(info.schema)()
+	buffer, err := codegen.addType(info.typeName, codegen.fullName(info.schema))
+	if buffer == nil || err != nil {
+		return err
+	}
+
+	err = codegen.writeStructSchemaVar(info)
 	if err != nil {
 		return err
 	}
@@ -198,24 +565,233 @@ func (codegen *CodeGenerator) writeStruct(info *recordSchemaInfo) error {
 		return err
 	}
 
-	return codegen.writeSchemaGetter(info, buffer)
+	err = codegen.writeSchemaGetter(info, buffer)
+	if err != nil {
+		return err
+	}
+
+	_, err = buffer.WriteString("\n\n")
+	if err != nil {
+		return err
+	}
+
+	err = codegen.writeStructWriter(info, buffer)
+	if err != nil {
+		return err
+	}
+
+	_, err = buffer.WriteString("\n\n")
+	if err != nil {
+		return err
+	}
+
+	return codegen.writeStructReader(info, buffer)
 }
 
 func (codegen *CodeGenerator) writeEnum(info *enumSchemaInfo) error {
-	buffer := &bytes.Buffer{}
-	if _, exists := codegen.structs[info.typeName]; exists {
-		return nil
+	defer codegen.enterPackage(info.schema)()
+	buffer, err := codegen.addType(info.typeName, codegen.fullName(info.schema))
+	if buffer == nil || err != nil {
+		return err
 	}
 
-	codegen.codeSnippets = append(codegen.codeSnippets, buffer)
-	codegen.structs[info.typeName] = buffer
+	err = codegen.writeDoc("", info.schema.Doc, buffer)
+	if err != nil {
+		return err
+	}
 
-	err := codegen.writeEnumConstants(info, buffer)
+	_, err = buffer.WriteString(fmt.Sprintf("type %s int32\n\n", info.typeName))
 	if err != nil {
 		return err
 	}
 
-	return nil
+	err = codegen.writeEnumConstants(info, buffer)
+	if err != nil {
+		return err
+	}
+
+	return codegen.writeEnumMethods(info, buffer)
+}
+
+func (codegen *CodeGenerator) writeUnion(info *unionSchemaInfo) error {
+	// unions are generated in packages of records that use them
+	buffer, err := codegen.addType(info.typeName, info.typeName)
+	if buffer == nil || err != nil {
+		return err
+	}
+
+	err = codegen.writeUnionDefinition(info, buffer)
+	if err != nil {
+		return err
+	}
+
+	_, err = buffer.WriteString("\n\n")
+	if err != nil {
+		return err
+	}
+
+	err = codegen.writeUnionWrapper(info, buffer)
+	if err != nil {
+		return err
+	}
+
+	_, err = buffer.WriteString("\n\n")
+	if err != nil {
+		return err
+	}
+
+	err = codegen.writeUnionStructWriter(info, buffer)
+	if err != nil {
+		return err
+	}
+
+	_, err = buffer.WriteString("\n\n")
+	if err != nil {
+		return err
+	}
+
+	return codegen.writeUnionStructReader(info, buffer)
+}
+
+func (codegen *CodeGenerator) writeUnionDefinition(info *unionSchemaInfo, buffer *bytes.Buffer) error {
+	names := make([]string, len(info.schema.Types))
+	for i, branch := range info.schema.Types {
+		names[i] = branch.GetName()
+	}
+	fmt.Fprintf(buffer, "// %s represents union [%s] with a field per branch, at most one should be set.\n", info.typeName, strings.Join(names, ", "))
+	if info.fields[0] == "" {
+		fmt.Fprint(buffer, "// No field set means null.\n")
+	}
+	fmt.Fprintf(buffer, "type %s struct {\n", info.typeName)
+
+	for i, branch := range info.schema.Types {
+		if info.fields[i] == "" {
+			continue
+		}
+		goType, err := codegen.unionBranchType(branch)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buffer, "%s %s\n", info.fields[i], goType)
+	}
+
+	_, err := buffer.WriteString("}")
+	return err
+}
+
+// writeUnionWrapper writes methods that implement avro.UnionWrapper for a generated union type.
+func (codegen *CodeGenerator) writeUnionWrapper(info *unionSchemaInfo, buffer *bytes.Buffer) error {
+	nullIndex := -1
+	fmt.Fprint(buffer, "// UnionBranch implements avro.UnionWrapper.\n")
+	fmt.Fprintf(buffer, "func (u *%s) UnionBranch() (int, interface{}) {\nswitch {\n", info.typeName)
+	for i, field := range info.fields {
+		if field == "" {
+			nullIndex = i
+			continue
+		}
+		fmt.Fprintf(buffer, "case u.%s != nil:\nreturn %d, %s\n", field, i, codegen.unionBranchValue(info, i))
+	}
+	fmt.Fprintf(buffer, "}\nreturn %d, nil\n}\n\n", nullIndex)
+
+	fmt.Fprint(buffer, "// SetUnionBranch implements avro.UnionWrapper.\n")
+	fmt.Fprintf(buffer, "func (u *%s) SetUnionBranch(index int) (interface{}, error) {\n*u = %s{}\nswitch index {\n", info.typeName, info.typeName)
+	for i, field := range info.fields {
+		if field == "" {
+			fmt.Fprintf(buffer, "case %d:\nreturn nil, nil\n", i)
+			continue
+		}
+		goType, err := codegen.unionBranchType(info.schema.Types[i])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buffer, "case %d:\nu.%s = new(%s)\nreturn u.%s, nil\n", i, field, goType[1:], field)
+	}
+	_, err := buffer.WriteString("}\nreturn nil, avro.UnionTypeOverflow\n}")
+	return err
+}
+
+func (codegen *CodeGenerator) writeUnionStructWriter(info *unionSchemaInfo, buffer *bytes.Buffer) error {
+	codegen.vars = 0
+	fmt.Fprint(buffer, "// Write writes this union to a given Encoder without using reflection.\n")
+	fmt.Fprintf(buffer, "func (u *%s) Write(enc avro.Encoder) error {\nswitch {\n", info.typeName)
+	nullIndex := -1
+	for i, field := range info.fields {
+		if field == "" {
+			nullIndex = i
+			continue
+		}
+		fmt.Fprintf(buffer, "case u.%s != nil:\nenc.WriteLong(%d)\n", field, i)
+		if err := codegen.writeValueWriter(info.schema.Types[i], codegen.unionBranchValue(info, i), buffer); err != nil {
+			return err
+		}
+	}
+	if nullIndex == -1 {
+		fmt.Fprint(buffer, "default:\nreturn avro.InvalidUnionValue\n")
+	} else {
+		fmt.Fprintf(buffer, "default:\nenc.WriteLong(%d)\nenc.WriteNull(nil)\n", nullIndex)
+	}
+
+	_, err := buffer.WriteString("}\nreturn nil\n}")
+	return err
+}
+
+func (codegen *CodeGenerator) writeUnionStructReader(info *unionSchemaInfo, buffer *bytes.Buffer) error {
+	codegen.vars = 0
+	fmt.Fprint(buffer, "// Read reads this union from a given Decoder without using reflection.\n")
+	fmt.Fprintf(buffer, "func (u *%s) Read(dec avro.Decoder) error {\n", info.typeName)
+	fmt.Fprintf(buffer, "var err error\nvar index int32\n")
+	codegen.writePrimitiveReader("ReadInt", "index", buffer)
+	fmt.Fprintf(buffer, "*u = %s{}\nswitch index {\n", info.typeName)
+	for i, branch := range info.schema.Types {
+		fmt.Fprintf(buffer, "case %d:\n", i)
+		switch branch.Type() {
+		case Null:
+			fmt.Fprint(buffer, "if _, err = dec.ReadNull(); err != nil {\nreturn err\n}\n")
+			continue
+		case Record, Recursive:
+			// records are allocated by their readers
+		default:
+			goType, err := codegen.unionBranchType(branch)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(buffer, "u.%s = new(%s)\n", info.fields[i], goType[1:])
+		}
+		if err := codegen.writeValueReader(branch, codegen.unionBranchTarget(info, i), buffer); err != nil {
+			return err
+		}
+	}
+
+	_, err := buffer.WriteString("default:\nreturn avro.UnionTypeOverflow\n}\nreturn nil\n}")
+	return err
+}
+
+// unionBranchType returns the Go type of a union branch field, values of types that are not pointers are referenced
+// with pointers to tell whether the branch is set.
+func (codegen *CodeGenerator) unionBranchType(schema Schema) (string, error) {
+	goType, err := codegen.goType(schema)
+	if err != nil || strings.HasPrefix(goType, "*") {
+		return goType, err
+	}
+	return "*" + goType, nil
+}
+
+// unionBranchValue returns an expression of the value of a union branch field.
+func (codegen *CodeGenerator) unionBranchValue(info *unionSchemaInfo, index int) string {
+	switch info.schema.Types[index].Type() {
+	case Record, Recursive:
+		return "u." + info.fields[index]
+	}
+	return "*u." + info.fields[index]
+}
+
+// unionBranchTarget returns an expression a value of a union branch is read into.
+func (codegen *CodeGenerator) unionBranchTarget(info *unionSchemaInfo, index int) string {
+	switch info.schema.Types[index].Type() {
+	case Record, Recursive:
+		return "u." + info.fields[index]
+	}
+	return "(*u." + info.fields[index] + ")"
 }
 
 func (codegen *CodeGenerator) writeEnumConstants(info *enumSchemaInfo, buffer *bytes.Buffer) error {
@@ -234,7 +810,7 @@ func (codegen *CodeGenerator) writeEnumConstants(info *enumSchemaInfo, buffer *b
 	}
 
 	for index, symbol := range info.schema.Symbols {
-		_, err = buffer.WriteString(fmt.Sprintf("%s_%s int32 = %d\n", info.typeName, symbol, index))
+		_, err = buffer.WriteString(fmt.Sprintf("%s_%s %s = %d\n", info.typeName, symbol, info.typeName, index))
 		if err != nil {
 			return err
 		}
@@ -243,26 +819,141 @@ func (codegen *CodeGenerator) writeEnumConstants(info *enumSchemaInfo, buffer *b
 	return err
 }
 
-func (codegen *CodeGenerator) writeImportStatement() error {
-	buffer := codegen.codeSnippets[0]
-	_, err := buffer.WriteString(`import "github.com/elodina/go-avro"`)
-	if err != nil {
-		return err
+// writeEnumMethods writes methods that convert a generated enum type to and from Avro symbols.
+func (codegen *CodeGenerator) writeEnumMethods(info *enumSchemaInfo, buffer *bytes.Buffer) error {
+	codegen.pkg.imports["fmt"] = true
+	symbols := fmt.Sprintf("_%s_symbols", info.typeName)
+
+	fmt.Fprintf(buffer, "\n\nvar %s = []string{", symbols)
+	for _, symbol := range info.schema.Symbols {
+		fmt.Fprintf(buffer, "%q,", symbol)
 	}
-	_, err = buffer.WriteString("\n")
-	return err
+	fmt.Fprint(buffer, "}\n\n")
+
+	fmt.Fprintf(buffer, "// String returns the Avro symbol of this %s.\n", info.typeName)
+	fmt.Fprintf(buffer, "func (e %s) String() string {\n", info.typeName)
+	fmt.Fprintf(buffer, "if e < 0 || int(e) >= len(%s) {\nreturn fmt.Sprintf(\"%s(%%d)\", int32(e))\n}\n", symbols, info.typeName)
+	fmt.Fprintf(buffer, "return %s[e]\n}\n\n", symbols)
+
+	fmt.Fprintf(buffer, "// Parse%s returns the %s of a given Avro symbol.\n", info.typeName, info.typeName)
+	fmt.Fprintf(buffer, "func Parse%s(symbol string) (%s, error) {\n", info.typeName, info.typeName)
+	fmt.Fprintf(buffer, "for i, s := range %s {\nif s == symbol {\nreturn %s(i), nil\n}\n}\n", symbols, info.typeName)
+	fmt.Fprintf(buffer, "return 0, fmt.Errorf(\"Invalid %s symbol %%q\", symbol)\n}\n\n", info.typeName)
+
+	fmt.Fprint(buffer, "// MarshalText implements encoding.TextMarshaler.\n")
+	fmt.Fprintf(buffer, "func (e %s) MarshalText() ([]byte, error) {\n", info.typeName)
+	fmt.Fprintf(buffer, "if e < 0 || int(e) >= len(%s) {\nreturn nil, avro.InvalidEnumValue\n}\n", symbols)
+	fmt.Fprintf(buffer, "return []byte(%s[e]), nil\n}\n\n", symbols)
+
+	fmt.Fprint(buffer, "// UnmarshalText implements encoding.TextUnmarshaler.\n")
+	fmt.Fprintf(buffer, "func (e *%s) UnmarshalText(text []byte) error {\n", info.typeName)
+	fmt.Fprintf(buffer, "value, err := Parse%s(string(text))\nif err != nil {\nreturn err\n}\n*e = value\nreturn nil\n}", info.typeName)
+	return nil
 }
 
 func (codegen *CodeGenerator) writeStructSchemaVar(info *recordSchemaInfo) error {
-	buffer := codegen.schemaDefinitions
+	buffer := codegen.pkg.schemaDefinitions
 	_, err := buffer.WriteString("// Generated by codegen. Please do not modify.\n")
 	if err != nil {
 		return err
 	}
-	_, err = buffer.WriteString(fmt.Sprintf("var %s, %s = avro.ParseSchema(`%s`)\n\n", info.schemaVarName, info.schemaErrName, strings.Replace(info.schema.String(), "`", "'", -1)))
+	schema := codegen.selfContained(info.schema, "", make(map[string]bool)).(*RecordSchema)
+	if codegen.schemaConstants {
+		compact, err := json.Marshal(schema)
+		if err != nil {
+			return err
+		}
+		// the constant is parsed on package initialization, so it must not fail there
+		if _, err := ParseSchema(string(compact)); err != nil {
+			return fmt.Errorf("Invalid schema of type %s: %s", info.typeName, err)
+		}
+		_, err = fmt.Fprintf(buffer, "const %s = %s\n\nvar %s = avro.MustParseSchema(%s)\n\n", info.schemaJSONName, strconv.Quote(string(compact)), info.schemaVarName, info.schemaJSONName)
+		return err
+	}
+	_, err = buffer.WriteString(fmt.Sprintf("var %s, %s = avro.ParseSchema(`%s`)\n\n", info.schemaVarName, info.schemaErrName, strings.Replace(schema.String(), "`", "'", -1)))
 	return err
 }
 
+// selfContained returns a copy of a given schema that defines named types it references at their first use, so that
+// it can be parsed on its own even if they are defined in other schema files.
+func (codegen *CodeGenerator) selfContained(schema Schema, enclosing string, defined map[string]bool) Schema {
+	if recursive, ok := schema.(*RecursiveSchema); ok {
+		schema = recursive.Actual
+	}
+
+	switch s := schema.(type) {
+	case *RecordSchema, *EnumSchema, *FixedSchema:
+		fullName := codegen.fullName(schema)
+		namespace := codegen.namespaces[schema]
+		if defined[fullName] {
+			if namespace == enclosing {
+				return &schemaReference{Schema: schema, name: schema.GetName()}
+			}
+			return &schemaReference{Schema: schema, name: fullName}
+		}
+		defined[fullName] = true
+
+		// types inheriting their namespace need an explicit one if defined elsewhere
+		explicit := ""
+		if namespace != enclosing && !strings.ContainsRune(schema.GetName(), '.') {
+			explicit = namespace
+		}
+		switch s := s.(type) {
+		case *RecordSchema:
+			record := *s
+			if explicit != "" {
+				record.Namespace = explicit
+			}
+			record.Fields = make([]*SchemaField, len(s.Fields))
+			for i, field := range s.Fields {
+				recordField := *field
+				recordField.Type = codegen.selfContained(field.Type, namespace, defined)
+				record.Fields[i] = &recordField
+			}
+			return &record
+		case *EnumSchema:
+			enum := *s
+			if explicit != "" {
+				enum.Namespace = explicit
+			}
+			return &enum
+		case *FixedSchema:
+			fixed := *s
+			if explicit != "" {
+				fixed.Namespace = explicit
+			}
+			return &fixed
+		}
+	case *ArraySchema:
+		array := *s
+		array.Items = codegen.selfContained(s.Items, enclosing, defined)
+		return &array
+	case *MapSchema:
+		m := *s
+		m.Values = codegen.selfContained(s.Values, enclosing, defined)
+		return &m
+	case *UnionSchema:
+		union := &UnionSchema{Types: make([]Schema, len(s.Types))}
+		for i, branch := range s.Types {
+			union.Types[i] = codegen.selfContained(branch, enclosing, defined)
+		}
+		return union
+	}
+
+	return schema
+}
+
+// schemaReference is a named type referenced by name after it has been defined.
+type schemaReference struct {
+	Schema
+	name string
+}
+
+// MarshalJSON serializes this schemaReference as the name of the referenced type.
+func (s *schemaReference) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.name)
+}
+
 func (codegen *CodeGenerator) writeDoc(prefix string, doc string, buffer *bytes.Buffer) error {
 	if doc == "" {
 		return nil
@@ -355,7 +1046,11 @@ func (codegen *CodeGenerator) writeStructFieldType(schema Schema, buffer *bytes.
 				return err
 			}
 
-			_, err = buffer.WriteString("*avro.GenericEnum")
+			typeName, err := codegen.qualify(enumSchema, info.typeName)
+			if err != nil {
+				return err
+			}
+			_, err = buffer.WriteString(typeName)
 			if err != nil {
 				return err
 			}
@@ -381,7 +1076,11 @@ func (codegen *CodeGenerator) writeStructFieldType(schema Schema, buffer *bytes.
 				return err
 			}
 
-			_, err = buffer.WriteString(schemaInfo.typeName)
+			typeName, err := codegen.qualify(recordSchema, schemaInfo.typeName)
+			if err != nil {
+				return err
+			}
+			_, err = buffer.WriteString(typeName)
 			if err != nil {
 				return err
 			}
@@ -394,7 +1093,11 @@ func (codegen *CodeGenerator) writeStructFieldType(schema Schema, buffer *bytes.
 			if err != nil {
 				return err
 			}
-			_, err = buffer.WriteString(schema.(*RecursiveSchema).GetName())
+			typeName, err := codegen.qualify(schema, goTypeName(schema.GetName()))
+			if err != nil {
+				return err
+			}
+			_, err = buffer.WriteString(typeName)
 		}
 	}
 
@@ -402,24 +1105,41 @@ func (codegen *CodeGenerator) writeStructFieldType(schema Schema, buffer *bytes.
 }
 
 func (codegen *CodeGenerator) writeStructUnionType(schema *UnionSchema, buffer *bytes.Buffer) error {
-	var unionType Schema
-	if schema.Types[0].Type() == Null {
-		unionType = schema.Types[1]
-	} else if schema.Types[1].Type() == Null {
-		unionType = schema.Types[0]
+	if nullIndex, index := codegen.nullableUnion(schema); nullIndex != -1 {
+		return codegen.writeStructFieldType(schema.Types[index], buffer)
 	}
 
-	if unionType != nil && codegen.isNullable(unionType) {
-		return codegen.writeStructFieldType(unionType, buffer)
+	info, err := newUnionSchemaInfo(schema)
+	if err != nil {
+		return err
 	}
 
-	_, err := buffer.WriteString("interface{}")
-	return err
+	_, err = buffer.WriteString(info.typeName)
+	if err != nil {
+		return err
+	}
+
+	return codegen.writeUnion(info)
+}
+
+// nullableUnion returns indexes of null and the other branch of a union of null and a type that may be nil in Go,
+// such unions are represented with the Go type of the other branch. Returns -1 for other unions.
+func (codegen *CodeGenerator) nullableUnion(schema *UnionSchema) (int, int) {
+	if len(schema.Types) != 2 {
+		return -1, -1
+	}
+
+	for nullIndex, index := range []int{1, 0} {
+		if schema.Types[nullIndex].Type() == Null && codegen.isNullable(schema.Types[index]) {
+			return nullIndex, index
+		}
+	}
+	return -1, -1
 }
 
 func (codegen *CodeGenerator) isNullable(schema Schema) bool {
 	switch schema.(type) {
-	case *BooleanSchema, *IntSchema, *LongSchema, *FloatSchema, *DoubleSchema, *StringSchema:
+	case *BooleanSchema, *IntSchema, *LongSchema, *FloatSchema, *DoubleSchema, *StringSchema, *EnumSchema:
 		return false
 	default:
 		return true
@@ -469,17 +1189,7 @@ func (codegen *CodeGenerator) writeStructConstructorFieldValue(info *recordSchem
 	case *BooleanSchema:
 		_, err = buffer.WriteString(fmt.Sprintf("%t", field.Default))
 	case *StringSchema:
-		{
-			_, err = buffer.WriteString(`"`)
-			if err != nil {
-				return err
-			}
-			_, err = buffer.WriteString(fmt.Sprintf("%s", field.Default))
-			if err != nil {
-				return err
-			}
-			_, err = buffer.WriteString(`"`)
-		}
+		_, err = buffer.WriteString(strconv.Quote(fmt.Sprintf("%s", field.Default)))
 	case *IntSchema:
 		{
 			defaultValue, ok := field.Default.(float64)
@@ -540,22 +1250,25 @@ func (codegen *CodeGenerator) writeStructConstructorFieldValue(info *recordSchem
 		}
 	case *EnumSchema:
 		{
-			_, err = buffer.WriteString("avro.NewGenericEnum([]string{")
+			info, err := newEnumSchemaInfo(field.Type.(*EnumSchema))
 			if err != nil {
 				return err
 			}
-			enum := field.Type.(*EnumSchema)
-			for _, symbol := range enum.Symbols {
-				_, err = buffer.WriteString(fmt.Sprintf(`"%s",`, symbol))
-				if err != nil {
-					return err
-				}
+			symbol, err := codegen.qualify(field.Type, fmt.Sprintf("%s_%s", info.typeName, field.Default))
+			if err != nil {
+				return err
+			}
+			_, err = buffer.WriteString(symbol)
+			if err != nil {
+				return err
 			}
-			_, err = buffer.WriteString("})")
 		}
 	case *UnionSchema:
 		{
 			union := field.Type.(*UnionSchema)
+			if nullIndex, _ := codegen.nullableUnion(union); nullIndex == -1 {
+				return codegen.writeUnionConstructorValue(info, field, union, buffer)
+			}
 			unionField := &SchemaField{}
 			*unionField = *field
 			unionField.Type = union.Types[0]
@@ -571,7 +1284,11 @@ func (codegen *CodeGenerator) writeStructConstructorFieldValue(info *recordSchem
 			if err != nil {
 				return err
 			}
-			_, err = buffer.WriteString(fmt.Sprintf("New%s()", info.typeName))
+			constructor, err := codegen.qualify(field.Type, "New"+info.typeName)
+			if err != nil {
+				return err
+			}
+			_, err = buffer.WriteString(constructor + "()")
 			if err != nil {
 				return err
 			}
@@ -581,13 +1298,39 @@ func (codegen *CodeGenerator) writeStructConstructorFieldValue(info *recordSchem
 	return err
 }
 
+// writeUnionConstructorValue writes a generated union type value with the first branch set to the field default.
+func (codegen *CodeGenerator) writeUnionConstructorValue(info *recordSchemaInfo, field *SchemaField, union *UnionSchema, buffer *bytes.Buffer) error {
+	unionInfo, err := newUnionSchemaInfo(union)
+	if err != nil {
+		return err
+	}
+	goType, err := codegen.goType(union.Types[0])
+	if err != nil {
+		return err
+	}
+
+	branchField := &SchemaField{}
+	*branchField = *field
+	branchField.Type = union.Types[0]
+	if strings.HasPrefix(goType, "*") {
+		fmt.Fprintf(buffer, "%s{%s: ", unionInfo.typeName, unionInfo.fields[0])
+		err = codegen.writeStructConstructorFieldValue(info, branchField, buffer)
+	} else {
+		fmt.Fprintf(buffer, "%s{%s: func() *%s {\nv := ", unionInfo.typeName, unionInfo.fields[0], goType)
+		err = codegen.writeStructConstructorFieldValue(info, branchField, buffer)
+		fmt.Fprint(buffer, "\nreturn &v\n}()")
+	}
+	fmt.Fprint(buffer, "}")
+	return err
+}
+
 func (codegen *CodeGenerator) needWriteField(field *SchemaField) bool {
 	if field.Default != nil {
 		return true
 	}
 
 	switch field.Type.(type) {
-	case *BytesSchema, *ArraySchema, *MapSchema, *EnumSchema, *FixedSchema, *RecordSchema:
+	case *BytesSchema, *ArraySchema, *MapSchema, *FixedSchema, *RecordSchema:
 		return true
 	}
 
@@ -613,6 +1356,10 @@ func (codegen *CodeGenerator) writeSchemaGetter(info *recordSchemaInfo, buffer *
 	if err != nil {
 		return err
 	}
+	if codegen.schemaConstants {
+		_, err = buffer.WriteString(fmt.Sprintf("return %s\n}", info.schemaVarName))
+		return err
+	}
 	_, err = buffer.WriteString(fmt.Sprintf("if %s != nil {\n\t\tpanic(%s)\n\t}\n\t", info.schemaErrName, info.schemaErrName))
 	if err != nil {
 		return err
@@ -620,3 +1367,265 @@ func (codegen *CodeGenerator) writeSchemaGetter(info *recordSchemaInfo, buffer *
 	_, err = buffer.WriteString(fmt.Sprintf("return %s\n}", info.schemaVarName))
 	return err
 }
+
+func (codegen *CodeGenerator) writeStructWriter(info *recordSchemaInfo, buffer *bytes.Buffer) error {
+	codegen.vars = 0
+	_, err := buffer.WriteString(fmt.Sprintf("// Write writes this %s to a given Encoder without using reflection.\n", info.typeName))
+	if err != nil {
+		return err
+	}
+	_, err = buffer.WriteString(fmt.Sprintf("func (o *%s) Write(enc avro.Encoder) error {\n", info.typeName))
+	if err != nil {
+		return err
+	}
+
+	for _, field := range info.schema.Fields {
+		err = codegen.writeValueWriter(field.Type, "o."+codegen.goFieldName(field), buffer)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = buffer.WriteString("return nil\n}")
+	return err
+}
+
+// writeValueWriter writes code that encodes a Go value given as an expression according to a given schema.
+func (codegen *CodeGenerator) writeValueWriter(schema Schema, value string, buffer *bytes.Buffer) error {
+	switch schema.Type() {
+	case Null:
+		fmt.Fprint(buffer, "enc.WriteNull(nil)\n")
+	case Boolean:
+		fmt.Fprintf(buffer, "enc.WriteBoolean(%s)\n", value)
+	case Int:
+		fmt.Fprintf(buffer, "enc.WriteInt(%s)\n", value)
+	case Long:
+		fmt.Fprintf(buffer, "enc.WriteLong(%s)\n", value)
+	case Float:
+		fmt.Fprintf(buffer, "enc.WriteFloat(%s)\n", value)
+	case Double:
+		fmt.Fprintf(buffer, "enc.WriteDouble(%s)\n", value)
+	case Bytes:
+		fmt.Fprintf(buffer, "enc.WriteBytes(%s)\n", value)
+	case String:
+		fmt.Fprintf(buffer, "enc.WriteString(%s)\n", value)
+	case Fixed:
+		fmt.Fprintf(buffer, "if len(%s) != %d {\nreturn avro.InvalidFixedSize\n}\n", value, schema.(*FixedSchema).Size)
+		fmt.Fprintf(buffer, "enc.WriteRaw(%s)\n", value)
+	case Enum:
+		fmt.Fprintf(buffer, "if %s < 0 || %s >= %d {\nreturn avro.InvalidEnumValue\n}\n", value, value, len(schema.(*EnumSchema).Symbols))
+		fmt.Fprintf(buffer, "enc.WriteInt(int32(%s))\n", value)
+	case Array:
+		item := codegen.newVar("item")
+		fmt.Fprintf(buffer, "if len(%s) > 0 {\nenc.WriteArrayStart(int64(len(%s)))\n", value, value)
+		fmt.Fprintf(buffer, "for _, %s := range %s {\n", item, value)
+		if err := codegen.writeValueWriter(schema.(*ArraySchema).Items, item, buffer); err != nil {
+			return err
+		}
+		fmt.Fprint(buffer, "}\n}\nenc.WriteArrayNext(0)\n")
+	case Map:
+		key, item := codegen.newVar("key"), codegen.newVar("value")
+		fmt.Fprintf(buffer, "if len(%s) > 0 {\nenc.WriteMapStart(int64(len(%s)))\n", value, value)
+		fmt.Fprintf(buffer, "for %s, %s := range %s {\nenc.WriteString(%s)\n", key, item, value, key)
+		if err := codegen.writeValueWriter(schema.(*MapSchema).Values, item, buffer); err != nil {
+			return err
+		}
+		fmt.Fprint(buffer, "}\n}\nenc.WriteMapNext(0)\n")
+	case Union:
+		return codegen.writeUnionWriter(schema.(*UnionSchema), value, buffer)
+	case Record, Recursive:
+		fmt.Fprintf(buffer, "if err := %s.Write(enc); err != nil {\nreturn err\n}\n", value)
+	default:
+		return fmt.Errorf("Unsupported schema type %s", schema.GetName())
+	}
+
+	return nil
+}
+
+func (codegen *CodeGenerator) writeUnionWriter(schema *UnionSchema, value string, buffer *bytes.Buffer) error {
+	if nullIndex, index := codegen.nullableUnion(schema); nullIndex != -1 {
+		fmt.Fprintf(buffer, "if %s == nil {\nenc.WriteLong(%d)\nenc.WriteNull(nil)\n} else {\nenc.WriteLong(%d)\n", value, nullIndex, index)
+		if err := codegen.writeValueWriter(schema.Types[index], value, buffer); err != nil {
+			return err
+		}
+		fmt.Fprint(buffer, "}\n")
+		return nil
+	}
+
+	// other unions are generated union types that write themselves
+	fmt.Fprintf(buffer, "if err := %s.Write(enc); err != nil {\nreturn err\n}\n", value)
+	return nil
+}
+
+func (codegen *CodeGenerator) writeStructReader(info *recordSchemaInfo, buffer *bytes.Buffer) error {
+	codegen.vars = 0
+	_, err := buffer.WriteString(fmt.Sprintf("// Read reads this %s from a given Decoder without using reflection.\n", info.typeName))
+	if err != nil {
+		return err
+	}
+	_, err = buffer.WriteString(fmt.Sprintf("func (o *%s) Read(dec avro.Decoder) error {\n", info.typeName))
+	if err != nil {
+		return err
+	}
+	if len(info.schema.Fields) > 0 {
+		_, err = buffer.WriteString("var err error\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, field := range info.schema.Fields {
+		err = codegen.writeValueReader(field.Type, "o."+codegen.goFieldName(field), buffer)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = buffer.WriteString("return nil\n}")
+	return err
+}
+
+// writeValueReader writes code that decodes a value of a given schema into a Go variable given as an expression.
+func (codegen *CodeGenerator) writeValueReader(schema Schema, target string, buffer *bytes.Buffer) error {
+	switch schema.Type() {
+	case Null:
+		fmt.Fprintf(buffer, "if _, err = dec.ReadNull(); err != nil {\nreturn err\n}\n%s = nil\n", target)
+	case Boolean:
+		codegen.writePrimitiveReader("ReadBoolean", target, buffer)
+	case Int:
+		codegen.writePrimitiveReader("ReadInt", target, buffer)
+	case Long:
+		codegen.writePrimitiveReader("ReadLong", target, buffer)
+	case Float:
+		codegen.writePrimitiveReader("ReadFloat", target, buffer)
+	case Double:
+		codegen.writePrimitiveReader("ReadDouble", target, buffer)
+	case Bytes:
+		codegen.writePrimitiveReader("ReadBytes", target, buffer)
+	case String:
+		codegen.writePrimitiveReader("ReadString", target, buffer)
+	case Fixed:
+		fmt.Fprintf(buffer, "%s = make([]byte, %d)\nif err = dec.ReadFixed(%s); err != nil {\nreturn err\n}\n", target, schema.(*FixedSchema).Size, target)
+	case Enum:
+		index := codegen.newVar("index")
+		fmt.Fprintf(buffer, "var %s int32\n", index)
+		codegen.writePrimitiveReader("ReadEnum", index, buffer)
+		goType, err := codegen.goType(schema)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buffer, "if %s < 0 || %s >= %d {\nreturn avro.InvalidEnumValue\n}\n", index, index, len(schema.(*EnumSchema).Symbols))
+		fmt.Fprintf(buffer, "%s = %s(%s)\n", target, goType, index)
+	case Array:
+		return codegen.writeBlocksReader(schema, schema.(*ArraySchema).Items, target, buffer)
+	case Map:
+		return codegen.writeBlocksReader(schema, schema.(*MapSchema).Values, target, buffer)
+	case Union:
+		return codegen.writeUnionReader(schema.(*UnionSchema), target, buffer)
+	case Record, Recursive:
+		goType, err := codegen.goType(schema)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buffer, "%s = new(%s)\nif err = %s.Read(dec); err != nil {\nreturn err\n}\n", target, goType[1:], target)
+	default:
+		return fmt.Errorf("Unsupported schema type %s", schema.GetName())
+	}
+
+	return nil
+}
+
+func (codegen *CodeGenerator) writePrimitiveReader(method string, target string, buffer *bytes.Buffer) {
+	fmt.Fprintf(buffer, "if %s, err = dec.%s(); err != nil {\nreturn err\n}\n", target, method)
+}
+
+// writeBlocksReader writes code that reads array items or map values block by block.
+func (codegen *CodeGenerator) writeBlocksReader(schema Schema, items Schema, target string, buffer *bytes.Buffer) error {
+	goType, err := codegen.goType(schema)
+	if err != nil {
+		return err
+	}
+	itemType, err := codegen.goType(items)
+	if err != nil {
+		return err
+	}
+
+	start, next := "ReadArrayStart", "ArrayNext"
+	if schema.Type() == Map {
+		start, next = "ReadMapStart", "MapNext"
+	}
+
+	count, i := codegen.newVar("count"), codegen.newVar("i")
+	key := ""
+	if schema.Type() == Map {
+		key = codegen.newVar("key")
+	}
+	item := codegen.newVar("item")
+	fmt.Fprintf(buffer, "var %s int64\n", count)
+	codegen.writePrimitiveReader(start, count, buffer)
+	if schema.Type() == Array {
+		fmt.Fprintf(buffer, "%s = make(%s, 0)\n", target, goType)
+	} else {
+		fmt.Fprintf(buffer, "%s = make(%s)\n", target, goType)
+	}
+	fmt.Fprintf(buffer, "for %s != 0 {\nfor %s := int64(0); %s < %s; %s++ {\n", count, i, i, count, i)
+	if schema.Type() == Map {
+		fmt.Fprintf(buffer, "var %s string\n", key)
+		codegen.writePrimitiveReader("ReadString", key, buffer)
+		fmt.Fprintf(buffer, "var %s %s\n", item, itemType)
+		if err := codegen.writeValueReader(items, item, buffer); err != nil {
+			return err
+		}
+		fmt.Fprintf(buffer, "%s[%s] = %s\n", target, key, item)
+	} else {
+		fmt.Fprintf(buffer, "var %s %s\n", item, itemType)
+		if err := codegen.writeValueReader(items, item, buffer); err != nil {
+			return err
+		}
+		fmt.Fprintf(buffer, "%s = append(%s, %s)\n", target, target, item)
+	}
+	fmt.Fprint(buffer, "}\n")
+	codegen.writePrimitiveReader(next, count, buffer)
+	fmt.Fprint(buffer, "}\n")
+	return nil
+}
+
+func (codegen *CodeGenerator) writeUnionReader(schema *UnionSchema, target string, buffer *bytes.Buffer) error {
+	if nullIndex, _ := codegen.nullableUnion(schema); nullIndex == -1 {
+		// other unions are generated union types that read themselves
+		fmt.Fprintf(buffer, "if err = %s.Read(dec); err != nil {\nreturn err\n}\n", target)
+		return nil
+	}
+
+	index := codegen.newVar("index")
+	fmt.Fprintf(buffer, "var %s int32\n", index)
+	codegen.writePrimitiveReader("ReadInt", index, buffer)
+	fmt.Fprintf(buffer, "switch %s {\n", index)
+	for i, unionType := range schema.Types {
+		fmt.Fprintf(buffer, "case %d:\n", i)
+		if err := codegen.writeValueReader(unionType, target, buffer); err != nil {
+			return err
+		}
+	}
+	fmt.Fprint(buffer, "default:\nreturn avro.UnionTypeOverflow\n}\n")
+	return nil
+}
+
+// goType returns the Go type of struct fields of a given schema.
+func (codegen *CodeGenerator) goType(schema Schema) (string, error) {
+	buffer := &bytes.Buffer{}
+	if err := codegen.writeStructFieldType(schema, buffer); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func (codegen *CodeGenerator) goFieldName(field *SchemaField) string {
+	return fmt.Sprintf("%s%s", strings.ToUpper(field.Name[:1]), field.Name[1:])
+}
+
+// newVar returns a unique name of a local variable in generated Read and Write methods.
+func (codegen *CodeGenerator) newVar(prefix string) string {
+	codegen.vars++
+	return fmt.Sprintf("%s%d", prefix, codegen.vars)
+}
@@ -16,11 +16,15 @@ limitations under the License. */
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"github.com/elodina/go-avro"
+	"go/build"
 	"io/ioutil"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
 )
 
@@ -35,30 +39,85 @@ func (i *schemas) Set(value string) error {
 	return nil
 }
 
+type namespaceMap map[string]string
+
+func (m namespaceMap) String() string {
+	return fmt.Sprintf("%v", map[string]string(m))
+}
+
+func (m namespaceMap) Set(value string) error {
+	index := strings.Index(value, "=")
+	if index <= 0 || index == len(value)-1 {
+		return fmt.Errorf("Invalid namespace mapping %s, expected <namespace>=<import path>", value)
+	}
+	m[value[:index]] = value[index+1:]
+	return nil
+}
+
 var schema schemas
-var output = flag.String("out", "", "Output file name.")
+var namespaces = make(namespaceMap)
+var output = flag.String("out", "", "Output file name, relative to --out-dir if it is given.")
+var outputDir = flag.String("out-dir", "", "Output directory, the output file is named "+defaultOutput+" unless --out is given.")
+var packageName = flag.String("package", "", "Go package name, defaults to the last part of the first schema namespace.")
+var header = flag.String("header", "Code generated by codegen. DO NOT EDIT.", "Comment on top of generated files, empty for none.")
+var buildTags = flag.String("tags", "", "Build constraint of generated files, e.g. \"linux && !appengine\".")
+var schemaConstants = flag.Bool("schema-const", false, "Generate record schemas as compact JSON constants instead of variables with parse errors.")
+var check = flag.Bool("check", false, "Do not write files, exit with non-zero status if any of them is not up to date.")
+
+// defaultOutput is the output file name used with --out-dir when --out is not given.
+const defaultOutput = "avro_gen.go"
 
 func main() {
 	parseAndValidateArgs()
 
 	var schemas []string
-	for _, schema := range schema {
-		contents, err := ioutil.ReadFile(schema)
+	for _, file := range schemaFiles() {
+		contents, err := ioutil.ReadFile(file)
 		checkErr(err)
 		schemas = append(schemas, string(contents))
 	}
 
 	gen := avro.NewCodeGenerator(schemas)
-	code, err := gen.Generate()
+	gen.SetPackage(*packageName)
+	gen.SetHeader(*header)
+	gen.SetBuildTags(*buildTags)
+	gen.SetSchemaConstants(*schemaConstants)
+	for namespace, importPath := range namespaces {
+		gen.MapNamespace(namespace, importPath)
+	}
+	files, err := gen.GenerateFiles()
 	checkErr(err)
 
-	createDirs()
-	err = ioutil.WriteFile(*output, []byte(code), 0664)
-	checkErr(err)
+	outdated := false
+	for importPath, code := range files {
+		file := outputFile()
+		if importPath != "" {
+			dir, err := packageDir(importPath, filepath.Dir(file))
+			checkErr(err)
+			file = filepath.Join(dir, path.Base(importPath)+".go")
+		}
+
+		if *check {
+			if !upToDate(file, code) {
+				fmt.Printf("%s is not up to date.\n", file)
+				outdated = true
+			}
+			continue
+		}
+
+		checkErr(os.MkdirAll(filepath.Dir(file), 0777))
+		err = ioutil.WriteFile(file, []byte(code), 0664)
+		checkErr(err)
+	}
+
+	if outdated {
+		os.Exit(1)
+	}
 }
 
 func parseAndValidateArgs() {
-	flag.Var(&schema, "schema", "Path to avsc schema file.")
+	flag.Var(&schema, "schema", "Path to avsc schema file, avpr protocol file or a directory with them.")
+	flag.Var(namespaces, "namespace-map", "Mapping of Avro namespace to Go import path, e.g. com.example.users=github.com/example/users.")
 	flag.Parse()
 
 	if len(schema) == 0 {
@@ -66,19 +125,105 @@ func parseAndValidateArgs() {
 		os.Exit(1)
 	}
 
-	if *output == "" {
-		fmt.Println("--out flag is required.")
+	if *output == "" && *outputDir == "" {
+		fmt.Println("--out or --out-dir flag is required.")
 		os.Exit(1)
 	}
 }
 
-func createDirs() {
-	index := strings.LastIndex(*output, "/")
-	if index != -1 {
-		path := (*output)[:index]
-		err := os.MkdirAll(path, 0777)
+func outputFile() string {
+	if *outputDir == "" {
+		return *output
+	}
+	if *output == "" {
+		return filepath.Join(*outputDir, defaultOutput)
+	}
+	return filepath.Join(*outputDir, *output)
+}
+
+// upToDate checks whether a given file exists and has given contents.
+func upToDate(file string, code string) bool {
+	contents, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return false
+	}
+	checkErr(err)
+	return bytes.Equal(contents, []byte(code))
+}
+
+// schemaFiles returns schema files given with --schema flags, directories are searched recursively for .avsc and
+// .avpr files.
+func schemaFiles() []string {
+	var files []string
+	for _, path := range schema {
+		info, err := os.Stat(path)
 		checkErr(err)
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.Mode().IsRegular() && (strings.HasSuffix(file, ".avsc") || strings.HasSuffix(file, ".avpr")) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		checkErr(err)
+	}
+
+	return files
+}
+
+// packageDir returns the directory of a package with a given import path. The package has to belong to the module
+// that contains a given output directory or, if there is no module, to the GOPATH that contains it.
+func packageDir(importPath string, outputDir string) (string, error) {
+	outputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return "", err
+	}
+
+	for dir := outputDir; ; dir = filepath.Dir(dir) {
+		contents, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			module := modulePath(contents)
+			if importPath == module {
+				return dir, nil
+			}
+			if module != "" && strings.HasPrefix(importPath, module+"/") {
+				return filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(importPath, module+"/"))), nil
+			}
+			return "", fmt.Errorf("Package %s is not in module %s, generate it separately", importPath, module)
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	for _, gopath := range filepath.SplitList(build.Default.GOPATH) {
+		src := filepath.Join(gopath, "src")
+		if strings.HasPrefix(outputDir, src+string(filepath.Separator)) {
+			return filepath.Join(src, filepath.FromSlash(importPath)), nil
+		}
+	}
+	return "", fmt.Errorf("Cannot find where package %s goes, %s is neither in a module nor in GOPATH", importPath, outputDir)
+}
+
+// modulePath returns the module path declared in a given go.mod file or an empty string if there is none.
+func modulePath(goMod []byte) string {
+	for _, line := range strings.Split(string(goMod), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], "\"`")
+		}
 	}
+	return ""
 }
 
 func checkErr(err error) {
@@ -0,0 +1,937 @@
+package avro_test
+
+import (
+	"fmt"
+	"github.com/elodina/go-avro"
+)
+
+/* Record covering all types supported by codegen. */
+type CodegenRecord struct {
+	Id       int64
+	Flag     bool
+	Count    int32
+	Ratio    float32
+	Score    float64
+	Name     string
+	Payload  []byte
+	Hash     []byte
+	Suit     Suit
+	Tags     []string
+	Counters map[string]int64
+	Child    *CodegenChild
+	Children []*CodegenChild
+	Matrix   map[string][]int32
+	Choice   UnionNullStringLongCodegenChild
+	Nickname UnionNullString
+	Content  UnionStringBytes
+	Shape    UnionIntArrayStringMapLong
+}
+
+func NewCodegenRecord() *CodegenRecord {
+	return &CodegenRecord{
+		Payload:  []byte{},
+		Hash:     make([]byte, 4),
+		Tags:     make([]string, 0),
+		Counters: make(map[string]int64),
+		Children: make([]*CodegenChild, 0),
+		Matrix:   make(map[string][]int32),
+		Content: UnionStringBytes{String: func() *string {
+			v := "none"
+			return &v
+		}()},
+		Shape: UnionIntArrayStringMapLong{Int: func() *int32 {
+			v := int32(0)
+			return &v
+		}()},
+	}
+}
+
+func (o *CodegenRecord) Schema() avro.Schema {
+	if _CodegenRecord_schema_err != nil {
+		panic(_CodegenRecord_schema_err)
+	}
+	return _CodegenRecord_schema
+}
+
+// Write writes this CodegenRecord to a given Encoder without using reflection.
+func (o *CodegenRecord) Write(enc avro.Encoder) error {
+	enc.WriteLong(o.Id)
+	enc.WriteBoolean(o.Flag)
+	enc.WriteInt(o.Count)
+	enc.WriteFloat(o.Ratio)
+	enc.WriteDouble(o.Score)
+	enc.WriteString(o.Name)
+	enc.WriteBytes(o.Payload)
+	if len(o.Hash) != 4 {
+		return avro.InvalidFixedSize
+	}
+	enc.WriteRaw(o.Hash)
+	if o.Suit < 0 || o.Suit >= 4 {
+		return avro.InvalidEnumValue
+	}
+	enc.WriteInt(int32(o.Suit))
+	if len(o.Tags) > 0 {
+		enc.WriteArrayStart(int64(len(o.Tags)))
+		for _, item1 := range o.Tags {
+			enc.WriteString(item1)
+		}
+	}
+	enc.WriteArrayNext(0)
+	if len(o.Counters) > 0 {
+		enc.WriteMapStart(int64(len(o.Counters)))
+		for key2, value3 := range o.Counters {
+			enc.WriteString(key2)
+			enc.WriteLong(value3)
+		}
+	}
+	enc.WriteMapNext(0)
+	if o.Child == nil {
+		enc.WriteLong(0)
+		enc.WriteNull(nil)
+	} else {
+		enc.WriteLong(1)
+		if err := o.Child.Write(enc); err != nil {
+			return err
+		}
+	}
+	if len(o.Children) > 0 {
+		enc.WriteArrayStart(int64(len(o.Children)))
+		for _, item4 := range o.Children {
+			if err := item4.Write(enc); err != nil {
+				return err
+			}
+		}
+	}
+	enc.WriteArrayNext(0)
+	if len(o.Matrix) > 0 {
+		enc.WriteMapStart(int64(len(o.Matrix)))
+		for key5, value6 := range o.Matrix {
+			enc.WriteString(key5)
+			if len(value6) > 0 {
+				enc.WriteArrayStart(int64(len(value6)))
+				for _, item7 := range value6 {
+					enc.WriteInt(item7)
+				}
+			}
+			enc.WriteArrayNext(0)
+		}
+	}
+	enc.WriteMapNext(0)
+	if err := o.Choice.Write(enc); err != nil {
+		return err
+	}
+	if err := o.Nickname.Write(enc); err != nil {
+		return err
+	}
+	if err := o.Content.Write(enc); err != nil {
+		return err
+	}
+	if err := o.Shape.Write(enc); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Read reads this CodegenRecord from a given Decoder without using reflection.
+func (o *CodegenRecord) Read(dec avro.Decoder) error {
+	var err error
+	if o.Id, err = dec.ReadLong(); err != nil {
+		return err
+	}
+	if o.Flag, err = dec.ReadBoolean(); err != nil {
+		return err
+	}
+	if o.Count, err = dec.ReadInt(); err != nil {
+		return err
+	}
+	if o.Ratio, err = dec.ReadFloat(); err != nil {
+		return err
+	}
+	if o.Score, err = dec.ReadDouble(); err != nil {
+		return err
+	}
+	if o.Name, err = dec.ReadString(); err != nil {
+		return err
+	}
+	if o.Payload, err = dec.ReadBytes(); err != nil {
+		return err
+	}
+	o.Hash = make([]byte, 4)
+	if err = dec.ReadFixed(o.Hash); err != nil {
+		return err
+	}
+	var index1 int32
+	if index1, err = dec.ReadEnum(); err != nil {
+		return err
+	}
+	if index1 < 0 || index1 >= 4 {
+		return avro.InvalidEnumValue
+	}
+	o.Suit = Suit(index1)
+	var count2 int64
+	if count2, err = dec.ReadArrayStart(); err != nil {
+		return err
+	}
+	o.Tags = make([]string, 0)
+	for count2 != 0 {
+		for i3 := int64(0); i3 < count2; i3++ {
+			var item4 string
+			if item4, err = dec.ReadString(); err != nil {
+				return err
+			}
+			o.Tags = append(o.Tags, item4)
+		}
+		if count2, err = dec.ArrayNext(); err != nil {
+			return err
+		}
+	}
+	var count5 int64
+	if count5, err = dec.ReadMapStart(); err != nil {
+		return err
+	}
+	o.Counters = make(map[string]int64)
+	for count5 != 0 {
+		for i6 := int64(0); i6 < count5; i6++ {
+			var key7 string
+			if key7, err = dec.ReadString(); err != nil {
+				return err
+			}
+			var item8 int64
+			if item8, err = dec.ReadLong(); err != nil {
+				return err
+			}
+			o.Counters[key7] = item8
+		}
+		if count5, err = dec.MapNext(); err != nil {
+			return err
+		}
+	}
+	var index9 int32
+	if index9, err = dec.ReadInt(); err != nil {
+		return err
+	}
+	switch index9 {
+	case 0:
+		if _, err = dec.ReadNull(); err != nil {
+			return err
+		}
+		o.Child = nil
+	case 1:
+		o.Child = new(CodegenChild)
+		if err = o.Child.Read(dec); err != nil {
+			return err
+		}
+	default:
+		return avro.UnionTypeOverflow
+	}
+	var count10 int64
+	if count10, err = dec.ReadArrayStart(); err != nil {
+		return err
+	}
+	o.Children = make([]*CodegenChild, 0)
+	for count10 != 0 {
+		for i11 := int64(0); i11 < count10; i11++ {
+			var item12 *CodegenChild
+			item12 = new(CodegenChild)
+			if err = item12.Read(dec); err != nil {
+				return err
+			}
+			o.Children = append(o.Children, item12)
+		}
+		if count10, err = dec.ArrayNext(); err != nil {
+			return err
+		}
+	}
+	var count13 int64
+	if count13, err = dec.ReadMapStart(); err != nil {
+		return err
+	}
+	o.Matrix = make(map[string][]int32)
+	for count13 != 0 {
+		for i14 := int64(0); i14 < count13; i14++ {
+			var key15 string
+			if key15, err = dec.ReadString(); err != nil {
+				return err
+			}
+			var item16 []int32
+			var count17 int64
+			if count17, err = dec.ReadArrayStart(); err != nil {
+				return err
+			}
+			item16 = make([]int32, 0)
+			for count17 != 0 {
+				for i18 := int64(0); i18 < count17; i18++ {
+					var item19 int32
+					if item19, err = dec.ReadInt(); err != nil {
+						return err
+					}
+					item16 = append(item16, item19)
+				}
+				if count17, err = dec.ArrayNext(); err != nil {
+					return err
+				}
+			}
+			o.Matrix[key15] = item16
+		}
+		if count13, err = dec.MapNext(); err != nil {
+			return err
+		}
+	}
+	if err = o.Choice.Read(dec); err != nil {
+		return err
+	}
+	if err = o.Nickname.Read(dec); err != nil {
+		return err
+	}
+	if err = o.Content.Read(dec); err != nil {
+		return err
+	}
+	if err = o.Shape.Read(dec); err != nil {
+		return err
+	}
+	return nil
+}
+
+type Suit int32
+
+// Enum values for Suit
+const (
+	Suit_SPADES   Suit = 0
+	Suit_HEARTS   Suit = 1
+	Suit_DIAMONDS Suit = 2
+	Suit_CLUBS    Suit = 3
+)
+
+var _Suit_symbols = []string{"SPADES", "HEARTS", "DIAMONDS", "CLUBS"}
+
+// String returns the Avro symbol of this Suit.
+func (e Suit) String() string {
+	if e < 0 || int(e) >= len(_Suit_symbols) {
+		return fmt.Sprintf("Suit(%d)", int32(e))
+	}
+	return _Suit_symbols[e]
+}
+
+// ParseSuit returns the Suit of a given Avro symbol.
+func ParseSuit(symbol string) (Suit, error) {
+	for i, s := range _Suit_symbols {
+		if s == symbol {
+			return Suit(i), nil
+		}
+	}
+	return 0, fmt.Errorf("Invalid Suit symbol %q", symbol)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (e Suit) MarshalText() ([]byte, error) {
+	if e < 0 || int(e) >= len(_Suit_symbols) {
+		return nil, avro.InvalidEnumValue
+	}
+	return []byte(_Suit_symbols[e]), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (e *Suit) UnmarshalText(text []byte) error {
+	value, err := ParseSuit(string(text))
+	if err != nil {
+		return err
+	}
+	*e = value
+	return nil
+}
+
+type CodegenChild struct {
+	Value int32
+	Next  *CodegenChild
+}
+
+func NewCodegenChild() *CodegenChild {
+	return &CodegenChild{}
+}
+
+func (o *CodegenChild) Schema() avro.Schema {
+	if _CodegenChild_schema_err != nil {
+		panic(_CodegenChild_schema_err)
+	}
+	return _CodegenChild_schema
+}
+
+// Write writes this CodegenChild to a given Encoder without using reflection.
+func (o *CodegenChild) Write(enc avro.Encoder) error {
+	enc.WriteInt(o.Value)
+	if o.Next == nil {
+		enc.WriteLong(0)
+		enc.WriteNull(nil)
+	} else {
+		enc.WriteLong(1)
+		if err := o.Next.Write(enc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read reads this CodegenChild from a given Decoder without using reflection.
+func (o *CodegenChild) Read(dec avro.Decoder) error {
+	var err error
+	if o.Value, err = dec.ReadInt(); err != nil {
+		return err
+	}
+	var index1 int32
+	if index1, err = dec.ReadInt(); err != nil {
+		return err
+	}
+	switch index1 {
+	case 0:
+		if _, err = dec.ReadNull(); err != nil {
+			return err
+		}
+		o.Next = nil
+	case 1:
+		o.Next = new(CodegenChild)
+		if err = o.Next.Read(dec); err != nil {
+			return err
+		}
+	default:
+		return avro.UnionTypeOverflow
+	}
+	return nil
+}
+
+// UnionNullStringLongCodegenChild represents union [null, string, long, CodegenChild] with a field per branch, at most one should be set.
+// No field set means null.
+type UnionNullStringLongCodegenChild struct {
+	String       *string
+	Long         *int64
+	CodegenChild *CodegenChild
+}
+
+// UnionBranch implements avro.UnionWrapper.
+func (u *UnionNullStringLongCodegenChild) UnionBranch() (int, interface{}) {
+	switch {
+	case u.String != nil:
+		return 1, *u.String
+	case u.Long != nil:
+		return 2, *u.Long
+	case u.CodegenChild != nil:
+		return 3, u.CodegenChild
+	}
+	return 0, nil
+}
+
+// SetUnionBranch implements avro.UnionWrapper.
+func (u *UnionNullStringLongCodegenChild) SetUnionBranch(index int) (interface{}, error) {
+	*u = UnionNullStringLongCodegenChild{}
+	switch index {
+	case 0:
+		return nil, nil
+	case 1:
+		u.String = new(string)
+		return u.String, nil
+	case 2:
+		u.Long = new(int64)
+		return u.Long, nil
+	case 3:
+		u.CodegenChild = new(CodegenChild)
+		return u.CodegenChild, nil
+	}
+	return nil, avro.UnionTypeOverflow
+}
+
+// Write writes this union to a given Encoder without using reflection.
+func (u *UnionNullStringLongCodegenChild) Write(enc avro.Encoder) error {
+	switch {
+	case u.String != nil:
+		enc.WriteLong(1)
+		enc.WriteString(*u.String)
+	case u.Long != nil:
+		enc.WriteLong(2)
+		enc.WriteLong(*u.Long)
+	case u.CodegenChild != nil:
+		enc.WriteLong(3)
+		if err := u.CodegenChild.Write(enc); err != nil {
+			return err
+		}
+	default:
+		enc.WriteLong(0)
+		enc.WriteNull(nil)
+	}
+	return nil
+}
+
+// Read reads this union from a given Decoder without using reflection.
+func (u *UnionNullStringLongCodegenChild) Read(dec avro.Decoder) error {
+	var err error
+	var index int32
+	if index, err = dec.ReadInt(); err != nil {
+		return err
+	}
+	*u = UnionNullStringLongCodegenChild{}
+	switch index {
+	case 0:
+		if _, err = dec.ReadNull(); err != nil {
+			return err
+		}
+	case 1:
+		u.String = new(string)
+		if (*u.String), err = dec.ReadString(); err != nil {
+			return err
+		}
+	case 2:
+		u.Long = new(int64)
+		if (*u.Long), err = dec.ReadLong(); err != nil {
+			return err
+		}
+	case 3:
+		u.CodegenChild = new(CodegenChild)
+		if err = u.CodegenChild.Read(dec); err != nil {
+			return err
+		}
+	default:
+		return avro.UnionTypeOverflow
+	}
+	return nil
+}
+
+// UnionNullString represents union [null, string] with a field per branch, at most one should be set.
+// No field set means null.
+type UnionNullString struct {
+	String *string
+}
+
+// UnionBranch implements avro.UnionWrapper.
+func (u *UnionNullString) UnionBranch() (int, interface{}) {
+	switch {
+	case u.String != nil:
+		return 1, *u.String
+	}
+	return 0, nil
+}
+
+// SetUnionBranch implements avro.UnionWrapper.
+func (u *UnionNullString) SetUnionBranch(index int) (interface{}, error) {
+	*u = UnionNullString{}
+	switch index {
+	case 0:
+		return nil, nil
+	case 1:
+		u.String = new(string)
+		return u.String, nil
+	}
+	return nil, avro.UnionTypeOverflow
+}
+
+// Write writes this union to a given Encoder without using reflection.
+func (u *UnionNullString) Write(enc avro.Encoder) error {
+	switch {
+	case u.String != nil:
+		enc.WriteLong(1)
+		enc.WriteString(*u.String)
+	default:
+		enc.WriteLong(0)
+		enc.WriteNull(nil)
+	}
+	return nil
+}
+
+// Read reads this union from a given Decoder without using reflection.
+func (u *UnionNullString) Read(dec avro.Decoder) error {
+	var err error
+	var index int32
+	if index, err = dec.ReadInt(); err != nil {
+		return err
+	}
+	*u = UnionNullString{}
+	switch index {
+	case 0:
+		if _, err = dec.ReadNull(); err != nil {
+			return err
+		}
+	case 1:
+		u.String = new(string)
+		if (*u.String), err = dec.ReadString(); err != nil {
+			return err
+		}
+	default:
+		return avro.UnionTypeOverflow
+	}
+	return nil
+}
+
+// UnionStringBytes represents union [string, bytes] with a field per branch, at most one should be set.
+type UnionStringBytes struct {
+	String *string
+	Bytes  *[]byte
+}
+
+// UnionBranch implements avro.UnionWrapper.
+func (u *UnionStringBytes) UnionBranch() (int, interface{}) {
+	switch {
+	case u.String != nil:
+		return 0, *u.String
+	case u.Bytes != nil:
+		return 1, *u.Bytes
+	}
+	return -1, nil
+}
+
+// SetUnionBranch implements avro.UnionWrapper.
+func (u *UnionStringBytes) SetUnionBranch(index int) (interface{}, error) {
+	*u = UnionStringBytes{}
+	switch index {
+	case 0:
+		u.String = new(string)
+		return u.String, nil
+	case 1:
+		u.Bytes = new([]byte)
+		return u.Bytes, nil
+	}
+	return nil, avro.UnionTypeOverflow
+}
+
+// Write writes this union to a given Encoder without using reflection.
+func (u *UnionStringBytes) Write(enc avro.Encoder) error {
+	switch {
+	case u.String != nil:
+		enc.WriteLong(0)
+		enc.WriteString(*u.String)
+	case u.Bytes != nil:
+		enc.WriteLong(1)
+		enc.WriteBytes(*u.Bytes)
+	default:
+		return avro.InvalidUnionValue
+	}
+	return nil
+}
+
+// Read reads this union from a given Decoder without using reflection.
+func (u *UnionStringBytes) Read(dec avro.Decoder) error {
+	var err error
+	var index int32
+	if index, err = dec.ReadInt(); err != nil {
+		return err
+	}
+	*u = UnionStringBytes{}
+	switch index {
+	case 0:
+		u.String = new(string)
+		if (*u.String), err = dec.ReadString(); err != nil {
+			return err
+		}
+	case 1:
+		u.Bytes = new([]byte)
+		if (*u.Bytes), err = dec.ReadBytes(); err != nil {
+			return err
+		}
+	default:
+		return avro.UnionTypeOverflow
+	}
+	return nil
+}
+
+// UnionIntArrayStringMapLong represents union [int, array, map] with a field per branch, at most one should be set.
+type UnionIntArrayStringMapLong struct {
+	Int         *int32
+	ArrayString *[]string
+	MapLong     *map[string]int64
+}
+
+// UnionBranch implements avro.UnionWrapper.
+func (u *UnionIntArrayStringMapLong) UnionBranch() (int, interface{}) {
+	switch {
+	case u.Int != nil:
+		return 0, *u.Int
+	case u.ArrayString != nil:
+		return 1, *u.ArrayString
+	case u.MapLong != nil:
+		return 2, *u.MapLong
+	}
+	return -1, nil
+}
+
+// SetUnionBranch implements avro.UnionWrapper.
+func (u *UnionIntArrayStringMapLong) SetUnionBranch(index int) (interface{}, error) {
+	*u = UnionIntArrayStringMapLong{}
+	switch index {
+	case 0:
+		u.Int = new(int32)
+		return u.Int, nil
+	case 1:
+		u.ArrayString = new([]string)
+		return u.ArrayString, nil
+	case 2:
+		u.MapLong = new(map[string]int64)
+		return u.MapLong, nil
+	}
+	return nil, avro.UnionTypeOverflow
+}
+
+// Write writes this union to a given Encoder without using reflection.
+func (u *UnionIntArrayStringMapLong) Write(enc avro.Encoder) error {
+	switch {
+	case u.Int != nil:
+		enc.WriteLong(0)
+		enc.WriteInt(*u.Int)
+	case u.ArrayString != nil:
+		enc.WriteLong(1)
+		if len(*u.ArrayString) > 0 {
+			enc.WriteArrayStart(int64(len(*u.ArrayString)))
+			for _, item1 := range *u.ArrayString {
+				enc.WriteString(item1)
+			}
+		}
+		enc.WriteArrayNext(0)
+	case u.MapLong != nil:
+		enc.WriteLong(2)
+		if len(*u.MapLong) > 0 {
+			enc.WriteMapStart(int64(len(*u.MapLong)))
+			for key2, value3 := range *u.MapLong {
+				enc.WriteString(key2)
+				enc.WriteLong(value3)
+			}
+		}
+		enc.WriteMapNext(0)
+	default:
+		return avro.InvalidUnionValue
+	}
+	return nil
+}
+
+// Read reads this union from a given Decoder without using reflection.
+func (u *UnionIntArrayStringMapLong) Read(dec avro.Decoder) error {
+	var err error
+	var index int32
+	if index, err = dec.ReadInt(); err != nil {
+		return err
+	}
+	*u = UnionIntArrayStringMapLong{}
+	switch index {
+	case 0:
+		u.Int = new(int32)
+		if (*u.Int), err = dec.ReadInt(); err != nil {
+			return err
+		}
+	case 1:
+		u.ArrayString = new([]string)
+		var count1 int64
+		if count1, err = dec.ReadArrayStart(); err != nil {
+			return err
+		}
+		(*u.ArrayString) = make([]string, 0)
+		for count1 != 0 {
+			for i2 := int64(0); i2 < count1; i2++ {
+				var item3 string
+				if item3, err = dec.ReadString(); err != nil {
+					return err
+				}
+				(*u.ArrayString) = append((*u.ArrayString), item3)
+			}
+			if count1, err = dec.ArrayNext(); err != nil {
+				return err
+			}
+		}
+	case 2:
+		u.MapLong = new(map[string]int64)
+		var count4 int64
+		if count4, err = dec.ReadMapStart(); err != nil {
+			return err
+		}
+		(*u.MapLong) = make(map[string]int64)
+		for count4 != 0 {
+			for i5 := int64(0); i5 < count4; i5++ {
+				var key6 string
+				if key6, err = dec.ReadString(); err != nil {
+					return err
+				}
+				var item7 int64
+				if item7, err = dec.ReadLong(); err != nil {
+					return err
+				}
+				(*u.MapLong)[key6] = item7
+			}
+			if count4, err = dec.MapNext(); err != nil {
+				return err
+			}
+		}
+	default:
+		return avro.UnionTypeOverflow
+	}
+	return nil
+}
+
+// Generated by codegen. Please do not modify.
+var _CodegenRecord_schema, _CodegenRecord_schema_err = avro.ParseSchema(`{
+    "type": "record",
+    "namespace": "codegen.avro_test",
+    "name": "CodegenRecord",
+    "doc": "Record covering all types supported by codegen.",
+    "fields": [
+        {
+            "name": "id",
+            "type": "long"
+        },
+        {
+            "name": "flag",
+            "type": "boolean"
+        },
+        {
+            "name": "count",
+            "type": "int"
+        },
+        {
+            "name": "ratio",
+            "type": "float"
+        },
+        {
+            "name": "score",
+            "type": "double"
+        },
+        {
+            "name": "name",
+            "type": "string"
+        },
+        {
+            "name": "payload",
+            "type": "bytes"
+        },
+        {
+            "name": "hash",
+            "type": {
+                "type": "fixed",
+                "size": 4,
+                "name": "Hash"
+            }
+        },
+        {
+            "name": "suit",
+            "type": {
+                "type": "enum",
+                "name": "Suit",
+                "symbols": [
+                    "SPADES",
+                    "HEARTS",
+                    "DIAMONDS",
+                    "CLUBS"
+                ]
+            }
+        },
+        {
+            "name": "tags",
+            "type": {
+                "type": "array",
+                "items": "string"
+            }
+        },
+        {
+            "name": "counters",
+            "type": {
+                "type": "map",
+                "values": "long"
+            }
+        },
+        {
+            "name": "child",
+            "default": null,
+            "type": [
+                "null",
+                {
+                    "type": "record",
+                    "name": "CodegenChild",
+                    "fields": [
+                        {
+                            "name": "value",
+                            "type": "int"
+                        },
+                        {
+                            "name": "next",
+                            "default": null,
+                            "type": [
+                                "null",
+                                "CodegenChild"
+                            ]
+                        }
+                    ]
+                }
+            ]
+        },
+        {
+            "name": "children",
+            "type": {
+                "type": "array",
+                "items": "CodegenChild"
+            }
+        },
+        {
+            "name": "matrix",
+            "type": {
+                "type": "map",
+                "values": {
+                    "type": "array",
+                    "items": "int"
+                }
+            }
+        },
+        {
+            "name": "choice",
+            "default": null,
+            "type": [
+                "null",
+                "string",
+                "long",
+                "CodegenChild"
+            ]
+        },
+        {
+            "name": "nickname",
+            "default": null,
+            "type": [
+                "null",
+                "string"
+            ]
+        },
+        {
+            "name": "content",
+            "default": "none",
+            "type": [
+                "string",
+                "bytes"
+            ]
+        },
+        {
+            "name": "shape",
+            "default": 0,
+            "type": [
+                "int",
+                {
+                    "type": "array",
+                    "items": "string"
+                },
+                {
+                    "type": "map",
+                    "values": "long"
+                }
+            ]
+        }
+    ]
+}`)
+
+// Generated by codegen. Please do not modify.
+var _CodegenChild_schema, _CodegenChild_schema_err = avro.ParseSchema(`{
+    "type": "record",
+    "namespace": "codegen.avro_test",
+    "name": "CodegenChild",
+    "fields": [
+        {
+            "name": "value",
+            "type": "int"
+        },
+        {
+            "name": "next",
+            "default": null,
+            "type": [
+                "null",
+                "CodegenChild"
+            ]
+        }
+    ]
+}`)
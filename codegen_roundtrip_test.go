@@ -0,0 +1,186 @@
+package avro_test
+
+import (
+	"bytes"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/elodina/go-avro"
+)
+
+// reflectiveCodegenRecord has the same fields as CodegenRecord but no Read and Write methods, so it is read and
+// written by SpecificDatumReader and SpecificDatumWriter using reflection.
+type reflectiveCodegenRecord CodegenRecord
+
+func check(t *testing.T, actual interface{}, expected interface{}) {
+	if !reflect.DeepEqual(actual, expected) {
+		_, fn, line, _ := runtime.Caller(1)
+		t.Fatalf("Expected %v, actual %v\n@%s:%d", expected, actual, fn, line)
+	}
+}
+
+func newTestCodegenRecord() *CodegenRecord {
+	record := NewCodegenRecord()
+	record.Id = 42
+	record.Flag = true
+	record.Count = -7
+	record.Ratio = 0.5
+	record.Score = 3.25
+	record.Name = "codegen"
+	record.Payload = []byte{1, 2, 3}
+	record.Hash = []byte{4, 5, 6, 7}
+	record.Suit = Suit_DIAMONDS
+	record.Tags = []string{"a", "b"}
+	record.Counters = map[string]int64{"x": 1}
+	record.Child = &CodegenChild{Value: 1, Next: &CodegenChild{Value: 2}}
+	record.Children = []*CodegenChild{{Value: 3}, {Value: 4, Next: &CodegenChild{Value: 5}}}
+	record.Matrix = map[string][]int32{"m": {1, 2, 3}}
+	record.Choice = UnionNullStringLongCodegenChild{Long: int64Pointer(100)}
+	record.Nickname = UnionNullString{String: stringPointer("nick")}
+	record.Content = UnionStringBytes{Bytes: &[]byte{8, 9}}
+	record.Shape = UnionIntArrayStringMapLong{MapLong: &map[string]int64{"y": 2}}
+	return record
+}
+
+func stringPointer(s string) *string {
+	return &s
+}
+
+func int64Pointer(i int64) *int64 {
+	return &i
+}
+
+func writeCodegenRecord(t *testing.T, record interface{}) []byte {
+	buffer := &bytes.Buffer{}
+	writer := avro.NewSpecificDatumWriter()
+	writer.SetSchema(NewCodegenRecord().Schema())
+	check(t, writer.Write(record, avro.NewBinaryEncoder(buffer)), nil)
+	return buffer.Bytes()
+}
+
+func TestGeneratedCodeMatchesReflection(t *testing.T) {
+	record := newTestCodegenRecord()
+
+	generated := writeCodegenRecord(t, record)
+	check(t, writeCodegenRecord(t, (*reflectiveCodegenRecord)(record)), generated)
+
+	reader := avro.NewSpecificDatumReader()
+	reader.SetSchema(record.Schema())
+
+	decoded := &CodegenRecord{}
+	check(t, reader.Read(decoded, avro.NewBinaryDecoder(generated)), nil)
+	check(t, decoded, record)
+
+	reflective := &reflectiveCodegenRecord{}
+	check(t, reader.Read(reflective, avro.NewBinaryDecoder(generated)), nil)
+	check(t, (*CodegenRecord)(reflective), record)
+}
+
+func TestGeneratedCodeUnions(t *testing.T) {
+	reader := avro.NewSpecificDatumReader()
+	reader.SetSchema(NewCodegenRecord().Schema())
+
+	record := newTestCodegenRecord()
+	choices := []UnionNullStringLongCodegenChild{{}, {String: stringPointer("text")}, {Long: int64Pointer(-1)}, {CodegenChild: &CodegenChild{Value: 9}}}
+	contents := []UnionStringBytes{{String: stringPointer("text")}, {Bytes: &[]byte{}}}
+	shapes := []UnionIntArrayStringMapLong{{Int: new(int32)}, {ArrayString: &[]string{"a"}}, {MapLong: &map[string]int64{}}}
+	for i, choice := range choices {
+		record.Choice = choice
+		record.Nickname = UnionNullString{}
+		record.Child = nil
+		record.Content = contents[i%len(contents)]
+		record.Shape = shapes[i%len(shapes)]
+
+		generated := writeCodegenRecord(t, record)
+		check(t, writeCodegenRecord(t, (*reflectiveCodegenRecord)(record)), generated)
+
+		decoded := &CodegenRecord{}
+		check(t, decoded.Read(avro.NewBinaryDecoder(generated)), nil)
+		check(t, decoded, record)
+
+		reflective := &reflectiveCodegenRecord{}
+		check(t, reader.Read(reflective, avro.NewBinaryDecoder(generated)), nil)
+		check(t, (*CodegenRecord)(reflective), record)
+	}
+
+	record.Content = UnionStringBytes{}
+	check(t, record.Write(avro.NewBinaryEncoder(&bytes.Buffer{})), avro.InvalidUnionValue)
+
+	record.Content = contents[0]
+	record.Hash = []byte{1}
+	check(t, record.Write(avro.NewBinaryEncoder(&bytes.Buffer{})), avro.InvalidFixedSize)
+}
+
+func TestGeneratedCodeErrors(t *testing.T) {
+	// generated Read and Write methods report errors like reflection does, with or without schemas set
+	generated := writeCodegenRecord(t, newTestCodegenRecord())
+	record := newTestCodegenRecord()
+	record.Content = UnionStringBytes{}
+	for _, schema := range []avro.Schema{record.Schema(), nil} {
+		reader := avro.NewSpecificDatumReader()
+		writer := avro.NewSpecificDatumWriter()
+		if schema != nil {
+			reader.SetSchema(schema)
+			writer.SetSchema(schema)
+		}
+
+		err := reader.Read(&CodegenRecord{}, avro.NewBinaryDecoder(generated[:10]))
+		decodeErr, ok := err.(*avro.DecodeError)
+		check(t, ok, true)
+		check(t, decodeErr.Path, "CodegenRecord")
+		check(t, decodeErr.Type, avro.Record)
+		check(t, decodeErr.Offset, int64(7))
+
+		err = writer.Write(record, avro.NewBinaryEncoder(&bytes.Buffer{}))
+		check(t, err, &avro.EncodeError{Path: "CodegenRecord", Type: avro.Record, Err: avro.InvalidUnionValue})
+	}
+}
+
+func TestGeneratedUnionDefaults(t *testing.T) {
+	record := NewCodegenRecord()
+	check(t, *record.Content.String, "none")
+	check(t, *record.Shape.Int, int32(0))
+
+	index, value := record.Choice.UnionBranch()
+	check(t, index, 0)
+	check(t, value, nil)
+
+	_, err := record.Content.SetUnionBranch(2)
+	check(t, err, avro.UnionTypeOverflow)
+}
+
+func TestGeneratedCodeJSON(t *testing.T) {
+	record := newTestCodegenRecord()
+	schema := record.Schema()
+
+	buffer := &bytes.Buffer{}
+	enc := avro.NewJSONEncoder(schema, buffer)
+	check(t, record.Write(enc), nil)
+	check(t, enc.Err(), nil)
+
+	decoded := &CodegenRecord{}
+	check(t, decoded.Read(avro.NewJSONDecoder(schema, buffer)), nil)
+	check(t, decoded, record)
+}
+
+func TestGeneratedEnum(t *testing.T) {
+	check(t, Suit_HEARTS.String(), "HEARTS")
+	check(t, Suit(7).String(), "Suit(7)")
+
+	suit, err := ParseSuit("CLUBS")
+	check(t, err, nil)
+	check(t, suit, Suit_CLUBS)
+	_, err = ParseSuit("JOKER")
+	check(t, err != nil, true)
+
+	text, err := Suit_SPADES.MarshalText()
+	check(t, err, nil)
+	check(t, string(text), "SPADES")
+	check(t, suit.UnmarshalText([]byte("DIAMONDS")), nil)
+	check(t, suit, Suit_DIAMONDS)
+
+	record := newTestCodegenRecord()
+	record.Suit = Suit(4)
+	check(t, record.Write(avro.NewBinaryEncoder(&bytes.Buffer{})), avro.InvalidEnumValue)
+}
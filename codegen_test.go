@@ -0,0 +1,151 @@
+package avro
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// codegen_generated_test.go is generated from test/codegen/codegen.avsc and is used by round-trip tests, regenerate
+// it whenever the generator output changes.
+func TestCodeGeneratorOutput(t *testing.T) {
+	schema, err := ioutil.ReadFile("test/codegen/codegen.avsc")
+	assert(t, err, nil)
+	expected, err := ioutil.ReadFile("codegen_generated_test.go")
+	assert(t, err, nil)
+
+	code, err := NewCodeGenerator([]string{string(schema)}).Generate()
+	assert(t, err, nil)
+	if code != string(expected) {
+		t.Fatal("Generated code differs from codegen_generated_test.go, regenerate it from test/codegen/codegen.avsc")
+	}
+}
+
+const codegenNamespacesSchema = `{"type": "record", "name": "Order", "namespace": "com.example.orders", "fields": [
+    {"name": "shipping", "type": {"type": "record", "name": "Address", "namespace": "com.example.geo", "fields": [
+        {"name": "city", "type": "string"},
+        {"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["HOME", "WORK"]}}
+    ]}},
+    {"name": "billing", "type": {"type": "record", "name": "Address", "fields": [
+        {"name": "iban", "type": "string"}
+    ]}},
+    {"name": "kind", "type": "com.example.geo.Kind", "default": "WORK"},
+    {"name": "previous", "type": ["null", "com.example.geo.Address"]}
+]}`
+
+func TestCodeGeneratorNamespaces(t *testing.T) {
+	codegen := NewCodeGenerator([]string{codegenNamespacesSchema})
+	codegen.SetPackage("orders")
+	codegen.MapNamespace("com.example.geo", "github.com/example/project/geo-types")
+
+	files, err := codegen.GenerateFiles()
+	assert(t, err, nil)
+	assert(t, len(files), 2)
+
+	orders, geo := files[""], files["github.com/example/project/geo-types"]
+	for _, expected := range []string{
+		"package orders\n",
+		"\t\"github.com/example/project/geo-types\"\n",
+		"\tShipping *geo_types.Address\n",
+		"\tBilling  *Address\n",
+		"\tKind     geo_types.Kind\n",
+		"\tPrevious *geo_types.Address\n",
+		"Shipping: geo_types.NewAddress(),",
+		"Kind:     geo_types.Kind_WORK,",
+		"o.Shipping = new(geo_types.Address)",
+		"type Address struct {\n\tIban string\n}",
+	} {
+		if !strings.Contains(orders, expected) {
+			t.Fatalf("Expected %q in generated code:\n%s", expected, orders)
+		}
+	}
+	for _, expected := range []string{"package geo_types\n", "type Address struct {\n\tCity string\n\tKind Kind\n}", "type Kind int32"} {
+		if !strings.Contains(geo, expected) {
+			t.Fatalf("Expected %q in generated code:\n%s", expected, geo)
+		}
+	}
+
+	codegen = NewCodeGenerator([]string{codegenNamespacesSchema})
+	codegen.MapNamespace("com.example.geo", "github.com/example/project/geo")
+	_, err = codegen.Generate()
+	assert(t, err != nil, true)
+}
+
+func TestCodeGeneratorTypeConflict(t *testing.T) {
+	_, err := NewCodeGenerator([]string{codegenNamespacesSchema}).Generate()
+	assert(t, err.Error(), "Types com.example.geo.Address and com.example.orders.Address are both generated as Address in package orders, map their namespaces to different packages.")
+}
+
+func TestCodeGeneratorSharedTypes(t *testing.T) {
+	order := `{"type": "record", "name": "Order", "namespace": "com.example", "fields": [
+        {"name": "address", "type": "com.example.geo.Address"},
+        {"name": "status", "type": "Status"}
+    ]}`
+	address := `{"type": "record", "name": "Address", "namespace": "com.example.geo", "fields": [{"name": "city", "type": "string"}]}`
+	status := `{"type": "enum", "name": "Status", "namespace": "com.example", "symbols": ["NEW", "DONE"]}`
+	shapes := `[{"type": "record", "name": "Circle", "namespace": "com.example", "fields": [{"name": "radius", "type": "double"}]},
+        {"type": "fixed", "name": "Id", "namespace": "com.example", "size": 16}]`
+	protocol := `{"protocol": "Orders", "namespace": "com.example", "types": [
+        {"type": "record", "name": "Receipt", "fields": [{"name": "order", "type": "Order"}, {"name": "id", "type": "Id"}]}
+    ], "messages": {}}`
+
+	code, err := NewCodeGenerator([]string{protocol, order, shapes, address, status}).Generate()
+	assert(t, err, nil)
+
+	for _, typeName := range []string{"Order struct", "Address struct", "Status int32", "Circle struct", "Receipt struct"} {
+		assert(t, strings.Count(code, "type "+typeName), 1)
+	}
+	assert(t, strings.Contains(code, "\tId    []byte\n"), true)
+
+	// schemas of generated types define the types they reference
+	receipt := code[strings.Index(code, "_Receipt_schema_err = avro.ParseSchema(`")+40:]
+	parsed, err := ParseSchema(receipt[:strings.Index(receipt, "`")])
+	assert(t, err, nil)
+	assert(t, parsed.(*RecordSchema).Fields[0].Type.(*RecordSchema).Fields[0].Type.(*RecordSchema).Namespace, "com.example.geo")
+}
+
+func TestCodeGeneratorProtocolMessages(t *testing.T) {
+	protocol, err := ioutil.ReadFile("test/codegen/messages.avpr")
+	assert(t, err, nil)
+
+	code, err := NewCodeGenerator([]string{string(protocol)}).Generate()
+	assert(t, err, nil)
+
+	// types declared inline in requests, responses and errors are generated as well as protocol types
+	for _, typeName := range []string{"Order struct", "Priority int32", "Receipt struct", "Rejected struct", "Summary struct"} {
+		assert(t, strings.Count(code, "type "+typeName), 1)
+	}
+	assert(t, strings.Contains(code, "type Rejected struct {\n\tReason string\n}"), true)
+}
+
+func TestCodeGeneratorFileOptions(t *testing.T) {
+	codegen := NewCodeGenerator([]string{`{"type": "record", "name": "Quote", "namespace": "com.example", "fields": [
+        {"name": "text", "type": "string", "default": "a \"quoted\" ` + "`text`" + `"}
+    ]}`})
+	codegen.SetHeader("Code generated by codegen. DO NOT EDIT.\nSource: quote.avsc")
+	codegen.SetBuildTags("linux && !appengine")
+	codegen.SetSchemaConstants(true)
+	code, err := codegen.Generate()
+	assert(t, err, nil)
+
+	assert(t, strings.HasPrefix(code, "// Code generated by codegen. DO NOT EDIT.\n// Source: quote.avsc\n\n"+
+		"//go:build linux && !appengine\n// +build linux,!appengine\n\npackage example\n"), true)
+	assert(t, strings.Contains(code, "var _Quote_schema = avro.MustParseSchema(_Quote_schemaJSON)"), true)
+	assert(t, strings.Contains(code, "_schema_err"), false)
+
+	// the constant is a compact JSON that keeps backquotes
+	constant := code[strings.Index(code, "const _Quote_schemaJSON = ")+26:]
+	constant = constant[:strings.Index(constant, "\n")]
+	assert(t, strings.Contains(constant, "\\n"), false)
+	value, err := strconv.Unquote(constant)
+	assert(t, err, nil)
+	schema, err := ParseSchema(value)
+	assert(t, err, nil)
+	assert(t, schema.(*RecordSchema).Fields[0].Default, "a \"quoted\" `text`")
+
+	codegen = NewCodeGenerator([]string{`{"type": "record", "name": "Quote", "fields": []}`})
+	codegen.SetBuildTags("linux &&")
+	_, err = codegen.Generate()
+	assert(t, err != nil, true)
+}
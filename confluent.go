@@ -0,0 +1,117 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+)
+
+// confluentMagicByte starts each value in Confluent wire format.
+const confluentMagicByte = 0
+
+// confluentHeaderSize is the size of the magic byte and the big-endian schema ID.
+const confluentHeaderSize = 5
+
+// ConfluentSerializer writes values in Confluent wire format used by Kafka clients: a zero magic byte,
+// the big-endian 4-byte ID of the writer schema in Schema Registry and the binary encoded value.
+// The writer schema is registered on first use. It is safe for concurrent use if the given DatumWriter is.
+type ConfluentSerializer struct {
+	registry SchemaRegistry
+	subject  string
+	schema   Schema
+	datum    DatumWriter
+
+	header []byte
+	lock   sync.Mutex
+}
+
+// NewConfluentSerializer creates a new ConfluentSerializer that writes values of a given schema with a given DatumWriter
+// and registers the schema under a given subject, e.g. "<topic>-value".
+func NewConfluentSerializer(registry SchemaRegistry, subject string, schema Schema, datumWriter DatumWriter) *ConfluentSerializer {
+	datumWriter.SetSchema(schema)
+	return &ConfluentSerializer{registry: registry, subject: subject, schema: schema, datum: datumWriter}
+}
+
+// Serialize returns a given value in Confluent wire format. Returns an error if the schema cannot be registered or
+// the value cannot be encoded.
+func (s *ConfluentSerializer) Serialize(obj interface{}) ([]byte, error) {
+	header, err := s.getHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := &bytes.Buffer{}
+	buffer.Write(header)
+	enc := AcquireBinaryEncoder(buffer)
+	defer ReleaseBinaryEncoder(enc)
+	if err := s.datum.Write(obj, enc); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (s *ConfluentSerializer) getHeader() ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.header != nil {
+		return s.header, nil
+	}
+
+	id, err := s.registry.Register(s.subject, s.schema)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, confluentHeaderSize)
+	header[0] = confluentMagicByte
+	binary.BigEndian.PutUint32(header[1:], uint32(id))
+	s.header = header
+	return header, nil
+}
+
+// ConfluentDeserializer reads values in Confluent wire format looking up writer schemas in Schema Registry.
+// It is safe for concurrent use if DatumReaders created by the given factory are.
+type ConfluentDeserializer struct {
+	registry SchemaRegistry
+	readers  *datumReaderCache
+}
+
+// NewConfluentDeserializer creates a new ConfluentDeserializer that looks up writer schemas in a given SchemaRegistry
+// and reads values with DatumReaders created by a given factory, one per writer schema.
+func NewConfluentDeserializer(registry SchemaRegistry, newDatumReader func() DatumReader) *ConfluentDeserializer {
+	return &ConfluentDeserializer{registry: registry, readers: newDatumReaderCache(newDatumReader)}
+}
+
+// SetReaderSchema sets the schema values should be read as. Values are resolved from their writer schemas according
+// to Avro schema resolution rules, this requires DatumReaders to support SetReaderSchema as GenericDatumReader and
+// SpecificDatumReader do. Should be called before reading any value.
+func (d *ConfluentDeserializer) SetReaderSchema(schema Schema) {
+	d.readers.setReaderSchema(schema)
+}
+
+// Deserialize reads a value in Confluent wire format from a given buffer into a given value that MUST be of pointer
+// type. Returns an error if the buffer is not in Confluent wire format, the writer schema cannot be looked up or
+// the value cannot be decoded.
+func (d *ConfluentDeserializer) Deserialize(obj interface{}, data []byte) error {
+	id, err := ConfluentSchemaID(data)
+	if err != nil {
+		return err
+	}
+
+	reader, err := d.readers.get(uint64(id), func() (Schema, error) { return d.registry.GetByID(id) })
+	if err != nil {
+		return err
+	}
+
+	return reader.Read(obj, NewBinaryDecoder(data[confluentHeaderSize:]))
+}
+
+// ConfluentSchemaID returns the writer schema ID of a given value in Confluent wire format.
+// Returns NotConfluentMessage if the value does not start with the magic byte.
+func ConfluentSchemaID(data []byte) (int, error) {
+	if len(data) < confluentHeaderSize || data[0] != confluentMagicByte {
+		return 0, NotConfluentMessage
+	}
+
+	return int(binary.BigEndian.Uint32(data[1:confluentHeaderSize])), nil
+}
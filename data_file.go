@@ -1,16 +1,22 @@
 package avro
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/rand"
+	"encoding/binary"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"math"
+	"os"
+	"sort"
+	"strings"
 )
 
 // Support decoding the avro Object Container File format.
 // Spec: http://avro.apache.org/docs/1.7.7/spec.html#Object+Container+Files
 
+// objHeaderSchemaRaw is the schema of the file header that readObjFileHeader and writeObjFileHeader follow.
 const objHeaderSchemaRaw = `{"type": "record", "name": "org.apache.avro.file.Header",
  "fields" : [
    {"name": "magic", "type": {"type": "fixed", "name": "Magic", "size": 4}},
@@ -19,26 +25,32 @@ const objHeaderSchemaRaw = `{"type": "record", "name": "org.apache.avro.file.Hea
   ]
 }`
 
-var objHeaderSchema = MustParseSchema(objHeaderSchemaRaw)
-
 const (
 	version   byte = 1
 	syncSize       = 16
 	schemaKey      = "avro.schema"
 	codecKey       = "avro.codec"
+
+	// metadata keys starting with this prefix are reserved by Avro
+	reservedMetaPrefix = "avro."
 )
 
 var magic = []byte{'O', 'b', 'j', version}
 
 // DataFileReader is a reader for Avro Object Container Files.
 // More here: https://avro.apache.org/docs/current/spec.html#Object+Container+Files
+// Data is read from the underlying io.Reader one block at a time, so only the current block is kept in memory.
+// Close must be called once a DataFileReader is no longer needed to release the underlying io.Reader.
 type DataFileReader struct {
-	data         []byte
+	source       io.Reader
+	input        *bufio.Reader
 	header       *objFileHeader
+	codec        Codec
+	compressed   []byte
 	block        *DataBlock
-	dec          Decoder
 	blockDecoder Decoder
 	datum        DatumReader
+	maxBlockSize int64
 }
 
 // The header for object container files
@@ -48,41 +60,108 @@ type objFileHeader struct {
 	Sync  []byte            `avro:"sync"`
 }
 
-func readObjFileHeader(dec *BinaryDecoder) (*objFileHeader, error) {
-	reader := NewSpecificDatumReader()
-	reader.SetSchema(objHeaderSchema)
-	header := &objFileHeader{}
-	err := reader.Read(header, dec)
-	return header, err
+func readObjFileHeader(input *bufio.Reader) (*objFileHeader, error) {
+	header := &objFileHeader{
+		Magic: make([]byte, len(magic)),
+		Meta:  make(map[string][]byte),
+		Sync:  make([]byte, syncSize),
+	}
+
+	if _, err := io.ReadFull(input, header.Magic); err != nil || !bytes.Equal(magic, header.Magic) {
+		return nil, NotAvroFile
+	}
+
+	for {
+		count, err := readStreamLong(input)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if count == 0 {
+			break
+		}
+		if count < 0 {
+			// negative count is followed by the block size in bytes which is not needed here
+			count = -count
+			if _, err := readStreamLong(input); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+		}
+
+		for i := int64(0); i < count; i++ {
+			key, err := readStreamBytes(input)
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			value, err := readStreamBytes(input)
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			header.Meta[string(key)] = value
+		}
+	}
+
+	if _, err := io.ReadFull(input, header.Sync); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	return header, nil
 }
 
 // NewDataFileReader creates a new DataFileReader for a given file and using the given DatumReader to read the data from that file.
+// The schema stored in file header is set to the DatumReader with SetSchema, so if the DatumReader has a reader schema
+// set, the data is resolved against it.
+//
+// The file is read one block at a time and therefore stays open until Close is called, which callers MUST do once
+// they are done with the DataFileReader, e.g. with defer, to not leak a file descriptor per reader.
 // May return an error if the file contains invalid data or is just missing.
 func NewDataFileReader(filename string, datumReader DatumReader) (*DataFileReader, error) {
-	buf, err := ioutil.ReadFile(filename)
+	file, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
 
-	return newDataFileReaderBytes(buf, datumReader)
+	reader, err := NewDataFileReaderFromReader(file, datumReader)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return reader, nil
 }
 
-// separated out mainly for testing currently, will be refactored later for io.Reader paradigm
-func newDataFileReaderBytes(buf []byte, datumReader DatumReader) (reader *DataFileReader, err error) {
-	if len(buf) < len(magic) || !bytes.Equal(magic, buf[0:4]) {
-		return nil, NotAvroFile
-	}
+// NewDataFileReaderFromReader creates a new DataFileReader that reads an object container file from a given io.Reader
+// using the given DatumReader. Only the file header is read here, data blocks are read one at a time as values are
+// consumed with Next. The schema stored in file header is set to the DatumReader with SetSchema.
+// May return an error if the header is invalid, the file is compressed with an unknown codec or the io.Reader fails.
+func NewDataFileReaderFromReader(input io.Reader, datumReader DatumReader) (*DataFileReader, error) {
+	return NewDataFileReaderWithOptions(input, datumReader, DecoderOptions{})
+}
 
-	dec := NewBinaryDecoder(buf)
-	blockDecoder := NewBinaryDecoder(nil)
-	reader = &DataFileReader{
-		data:         buf,
-		dec:          dec,
-		blockDecoder: blockDecoder,
+// NewDataFileReaderWithOptions creates a new DataFileReader like NewDataFileReaderFromReader that limits the size of
+// decompressed data blocks and the values read from them with given DecoderOptions.
+func NewDataFileReaderWithOptions(input io.Reader, datumReader DatumReader, options DecoderOptions) (*DataFileReader, error) {
+	reader := &DataFileReader{
+		source:       input,
+		input:        bufio.NewReader(input),
+		block:        &DataBlock{},
+		blockDecoder: NewBinaryDecoderWithOptions(nil, options),
 		datum:        datumReader,
+		maxBlockSize: options.MaxBlockSize,
+	}
+	if reader.maxBlockSize <= 0 {
+		reader.maxBlockSize = math.MaxInt32
 	}
 
-	if reader.header, err = readObjFileHeader(dec); err != nil {
+	var err error
+	if reader.header, err = readObjFileHeader(reader.input); err != nil {
+		return nil, err
+	}
+
+	codecName := string(reader.header.Meta[codecKey])
+	if codecName == "" {
+		codecName = NullCodec
+	}
+	if reader.codec, err = GetCodec(codecName); err != nil {
 		return nil, err
 	}
 
@@ -91,42 +170,43 @@ func newDataFileReaderBytes(buf []byte, datumReader DatumReader) (reader *DataFi
 		return nil, err
 	}
 	reader.datum.SetSchema(schema)
-	reader.block = &DataBlock{}
-
-	if reader.hasNextBlock() {
-		if err := reader.NextBlock(); err != nil {
-			return nil, err
-		}
-	}
 
 	return reader, nil
 }
 
-// Seek switches the reading position in this DataFileReader to a provided value.
+// separated out mainly for testing
+func newDataFileReaderBytes(buf []byte, datumReader DatumReader) (reader *DataFileReader, err error) {
+	return NewDataFileReaderFromReader(bytes.NewReader(buf), datumReader)
+}
+
+// Seek switches the reading position in this DataFileReader to a provided offset from the start of the file. The
+// offset should point to the start of a data block. Has no effect if the underlying io.Reader is not an io.Seeker.
 func (reader *DataFileReader) Seek(pos int64) {
-	reader.dec.Seek(pos)
+	if seeker, ok := reader.source.(io.Seeker); ok {
+		if _, err := seeker.Seek(pos, 0); err == nil {
+			reader.input.Reset(reader.source)
+			reader.block.BlockRemaining = 0
+			reader.block.BlockSize = 0
+			reader.blockDecoder.SetBlock(reader.block)
+		}
+	}
 }
 
 func (reader *DataFileReader) hasNext() (bool, error) {
-	if reader.block.BlockRemaining == 0 {
+	for reader.block.BlockRemaining == 0 {
 		if int64(reader.block.BlockSize) != reader.blockDecoder.Tell() {
 			return false, BlockNotFinished
 		}
-		if reader.hasNextBlock() {
-			if err := reader.NextBlock(); err != nil {
-				return false, err
+		if err := reader.NextBlock(); err != nil {
+			if err == io.EOF {
+				return false, nil
 			}
-		} else {
-			return false, nil
+			return false, err
 		}
 	}
 	return true, nil
 }
 
-func (reader *DataFileReader) hasNextBlock() bool {
-	return int64(len(reader.data)) > reader.dec.Tell()
-}
-
 // Next reads the next value from file and fills the given value with data.
 // First return value indicates whether the read was successful.
 // Second return value indicates whether there was an error while reading data.
@@ -150,46 +230,119 @@ func (reader *DataFileReader) Next(v interface{}) (bool, error) {
 }
 
 // NextBlock tells this DataFileReader to skip current block and move to next one.
-// May return an error if the block is malformed or no more blocks left to read.
+// Returns io.EOF if no more blocks left to read, io.ErrUnexpectedEOF if the input ends in the middle of a block
+// or another error if the block is malformed.
 func (reader *DataFileReader) NextBlock() error {
-	blockCount, err := reader.dec.ReadLong()
+	blockCount, err := readStreamLong(reader.input)
 	if err != nil {
 		return err
 	}
 
-	blockSize, err := reader.dec.ReadLong()
+	blockSize, err := readStreamLong(reader.input)
 	if err != nil {
-		return err
+		return unexpectedEOF(err)
 	}
 
 	if blockSize > math.MaxInt32 || blockSize < 0 {
 		return fmt.Errorf("Block size invalid or too large: %d", blockSize)
 	}
-
-	block := reader.block
-	if block.Data == nil || int64(len(block.Data)) < blockSize {
-		block.Data = make([]byte, blockSize)
+	if blockCount < 0 {
+		return fmt.Errorf("Block count invalid: %d", blockCount)
 	}
-	block.BlockRemaining = blockCount
-	block.NumEntries = blockCount
-	block.BlockSize = int(blockSize)
-	err = reader.dec.ReadFixedWithBounds(block.Data, 0, int(block.BlockSize))
+
+	data, err := readStreamN(reader.input, blockSize, reader.compressed)
 	if err != nil {
-		return err
+		return unexpectedEOF(err)
 	}
+	reader.compressed = data
 	syncBuffer := make([]byte, syncSize)
-	err = reader.dec.ReadFixed(syncBuffer)
-	if err != nil {
-		return err
+	if _, err = io.ReadFull(reader.input, syncBuffer); err != nil {
+		return unexpectedEOF(err)
 	}
 	if !bytes.Equal(syncBuffer, reader.header.Sync) {
 		return InvalidSync
 	}
+
+	if data, err = reader.codec.Decode(data, reader.maxBlockSize); err != nil {
+		return fmt.Errorf("Invalid %s compressed block: %w", reader.codec.Name(), err)
+	}
+
+	block := reader.block
+	block.Data = data
+	block.BlockRemaining = blockCount
+	block.NumEntries = blockCount
+	block.BlockSize = len(data)
 	reader.blockDecoder.SetBlock(reader.block)
 
 	return nil
 }
 
+// Metadata returns the value of a given key of the file metadata, e.g. one written with WithMetadata, or nil if the
+// file does not have it.
+func (reader *DataFileReader) Metadata(key string) []byte {
+	return reader.header.Meta[key]
+}
+
+// Close releases the underlying io.Reader if it is an io.Closer. This DataFileReader cannot be used after Close.
+func (reader *DataFileReader) Close() error {
+	reader.block = &DataBlock{}
+	if closer, ok := reader.source.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// readStreamLong reads a zig-zag encoded Avro long from a given stream.
+func readStreamLong(input io.ByteReader) (int64, error) {
+	value, err := binary.ReadVarint(input)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return 0, LongOverflow
+	}
+	return value, err
+}
+
+// readStreamBytes reads a length-prefixed Avro bytes value from a given stream.
+func readStreamBytes(input *bufio.Reader) ([]byte, error) {
+	length, err := readStreamLong(input)
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 {
+		return nil, NegativeBytesLength
+	}
+	if length > math.MaxInt32 {
+		return nil, InvalidStringLength
+	}
+
+	return readStreamN(input, length, nil)
+}
+
+// readStreamN reads a given number of bytes from a given stream into a given buffer if it is large enough. Otherwise
+// lengths over streamReadChunk are read in chunks into a growing buffer, so that a corrupted length does not allocate
+// more memory than the data that is actually there.
+func readStreamN(input io.Reader, length int64, buf []byte) ([]byte, error) {
+	if int64(cap(buf)) < length && length <= streamReadChunk {
+		buf = make([]byte, length)
+	}
+	if int64(cap(buf)) >= length {
+		buf = buf[:length]
+		_, err := io.ReadFull(input, buf)
+		return buf, err
+	}
+
+	chunked := bytes.NewBuffer(make([]byte, 0, streamReadChunk))
+	_, err := io.CopyN(chunked, input, length)
+	return chunked.Bytes(), err
+}
+
+// unexpectedEOF converts io.EOF to io.ErrUnexpectedEOF for reads that are not allowed to end the stream.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
 ////////// DATA FILE WRITER
 
 // DataFileWriter lets you write object container files.
@@ -198,61 +351,182 @@ type DataFileWriter struct {
 	outputEnc   *BinaryEncoder
 	datumWriter DatumWriter
 	sync        []byte
+	codec       Codec
+	meta        map[string][]byte
+
+	// current block is buffered until flush or until it reaches any of the max sizes
+	blockBuf      *bytes.Buffer
+	blockCount    int64
+	blockEnc      *BinaryEncoder
+	maxBlockCount int64
+	maxBlockSize  int
+}
+
+// DataFileWriterOption configures a DataFileWriter created with NewDataFileWriter.
+type DataFileWriterOption func(*DataFileWriter) error
+
+// WithCodec tells a DataFileWriter to compress data blocks with a given Codec, e.g. NewDeflateCodec(flate.BestSpeed).
+// Data blocks are not compressed by default. Fails if the Codec cannot compress data, e.g. the bzip2 one.
+func WithCodec(codec Codec) DataFileWriterOption {
+	return func(writer *DataFileWriter) error {
+		return writer.setCodec(codec)
+	}
+}
+
+// WithCodecName tells a DataFileWriter to compress data blocks with a registered Codec with a given name.
+// Fails if there is no such Codec or it cannot compress data.
+func WithCodecName(name string) DataFileWriterOption {
+	return func(writer *DataFileWriter) error {
+		codec, err := GetCodec(name)
+		if err != nil {
+			return err
+		}
+		return writer.setCodec(codec)
+	}
+}
+
+// setCodec makes sure a given Codec is able to compress data before using it, so that a decode-only codec is
+// rejected when a DataFileWriter is created instead of on the first Flush.
+func (w *DataFileWriter) setCodec(codec Codec) error {
+	if _, err := codec.Encode(nil); err != nil {
+		return fmt.Errorf("Codec %s cannot be used for writing: %s", codec.Name(), err)
+	}
+	w.codec = codec
+	return nil
+}
+
+// WithSyncMarker tells a DataFileWriter to separate data blocks with a given 16 bytes long sync marker instead of a
+// random one, e.g. to produce the same output for the same data.
+func WithSyncMarker(sync []byte) DataFileWriterOption {
+	return func(writer *DataFileWriter) error {
+		if len(sync) != syncSize {
+			return fmt.Errorf("Sync marker must be %d bytes long, got %d", syncSize, len(sync))
+		}
+		writer.sync = append([]byte(nil), sync...)
+		return nil
+	}
+}
 
-	// current block is buffered until flush
-	blockBuf   *bytes.Buffer
-	blockCount int64
-	blockEnc   *BinaryEncoder
+// WithBlockCount tells a DataFileWriter to flush a data block as soon as it has a given number of datums.
+// Data blocks are only flushed with Flush and Close by default.
+func WithBlockCount(count int64) DataFileWriterOption {
+	return func(writer *DataFileWriter) error {
+		if count <= 0 {
+			return fmt.Errorf("Block count must be positive, got %d", count)
+		}
+		writer.maxBlockCount = count
+		return nil
+	}
+}
+
+// WithBlockSize tells a DataFileWriter to flush a data block as soon as its datums take a given number of bytes
+// before compression. Data blocks are only flushed with Flush and Close by default.
+func WithBlockSize(size int) DataFileWriterOption {
+	return func(writer *DataFileWriter) error {
+		if size <= 0 {
+			return fmt.Errorf("Block size must be positive, got %d", size)
+		}
+		writer.maxBlockSize = size
+		return nil
+	}
+}
+
+// WithMetadata adds a given key and value to the metadata of a file written by a DataFileWriter. Keys starting with
+// "avro." are reserved and rejected.
+func WithMetadata(key string, value []byte) DataFileWriterOption {
+	return func(writer *DataFileWriter) error {
+		if strings.HasPrefix(key, reservedMetaPrefix) {
+			return fmt.Errorf("Metadata key %s is reserved", key)
+		}
+		writer.meta[key] = value
+		return nil
+	}
 }
 
 // NewDataFileWriter creates a new DataFileWriter for given output and schema using the given DatumWriter to write the data to that Writer.
-// May return an error if writing fails.
-func NewDataFileWriter(output io.Writer, schema Schema, datumWriter DatumWriter) (writer *DataFileWriter, err error) {
+// Data blocks are separated with a random sync marker unless WithSyncMarker is given.
+// May return an error if writing fails or any of the given options fails.
+func NewDataFileWriter(output io.Writer, schema Schema, datumWriter DatumWriter, options ...DataFileWriterOption) (writer *DataFileWriter, err error) {
 	encoder := NewBinaryEncoder(output)
 	datumWriter.SetSchema(schema)
-	sync := []byte("1234567890abcdef") // TODO come up with other sync value
 
-	header := &objFileHeader{
-		Magic: magic,
-		Meta: map[string][]byte{
-			schemaKey: []byte(schema.String()),
-			codecKey:  []byte("null"),
-		},
-		Sync: sync,
-	}
-	headerWriter := NewSpecificDatumWriter()
-	headerWriter.SetSchema(objHeaderSchema)
-	if err = headerWriter.Write(header, encoder); err != nil {
-		return
-	}
 	blockBuf := &bytes.Buffer{}
 	writer = &DataFileWriter{
 		output:      output,
 		outputEnc:   encoder,
 		datumWriter: datumWriter,
-		sync:        sync,
+		codec:       nullCodec{},
+		meta:        make(map[string][]byte),
 		blockBuf:    blockBuf,
 		blockEnc:    NewBinaryEncoder(blockBuf),
 	}
+	for _, option := range options {
+		if err = option(writer); err != nil {
+			return nil, err
+		}
+	}
+	if writer.sync == nil {
+		writer.sync = make([]byte, syncSize)
+		if _, err = rand.Read(writer.sync); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.meta[schemaKey] = []byte(schema.String())
+	writer.meta[codecKey] = []byte(writer.codec.Name())
+	if err = writeObjFileHeader(encoder, writer.meta, writer.sync); err != nil {
+		return nil, err
+	}
 
 	return
 }
 
+// writeObjFileHeader writes a file header with a given metadata and sync marker. Metadata is written in key order,
+// so that the same options always produce the same header.
+func writeObjFileHeader(enc *BinaryEncoder, meta map[string][]byte, sync []byte) error {
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	enc.WriteRaw(magic)
+	enc.WriteMapStart(int64(len(keys)))
+	for _, key := range keys {
+		enc.WriteString(key)
+		enc.WriteBytes(meta[key])
+	}
+	enc.WriteMapNext(0)
+	enc.WriteRaw(sync)
+	return enc.Err()
+}
+
 // Write out a single datum.
 //
 // Encoded datums are buffered internally and will not be written to the
-// underlying io.Writer until Flush() is called.
+// underlying io.Writer until Flush() is called or the block reaches the
+// size given with WithBlockCount or WithBlockSize.
+// A datum that fails to be written is not added to the block.
 func (w *DataFileWriter) Write(v interface{}) error {
+	size := w.blockBuf.Len()
+	if err := w.datumWriter.Write(v, w.blockEnc); err != nil {
+		w.blockBuf.Truncate(size)
+		return err
+	}
 	w.blockCount++
-	err := w.datumWriter.Write(v, w.blockEnc)
-	return err
+
+	if (w.maxBlockCount > 0 && w.blockCount >= w.maxBlockCount) || (w.maxBlockSize > 0 && w.blockBuf.Len() >= w.maxBlockSize) {
+		return w.actuallyFlush()
+	}
+	return nil
 }
 
 // Flush out any previously written datums to our underlying io.Writer.
 // Does nothing if no datums had previously been written.
 //
-// It's up to the library user to decide how often to flush; doing it
-// often will spend a lot of time on tiny I/O but save memory.
+// It's up to the library user to decide how often to flush, either by
+// calling Flush or with WithBlockCount and WithBlockSize; doing it often
+// will spend a lot of time on tiny I/O but save memory.
 func (w *DataFileWriter) Flush() error {
 	if w.blockCount > 0 {
 		return w.actuallyFlush()
@@ -261,19 +535,17 @@ func (w *DataFileWriter) Flush() error {
 }
 
 func (w *DataFileWriter) actuallyFlush() error {
-	// Write the block count and length directly to output
-	w.outputEnc.WriteLong(w.blockCount)
-	w.outputEnc.WriteLong(int64(w.blockBuf.Len()))
-
-	// copy the buffer which is the block buf to output
-	_, err := io.Copy(w.output, w.blockBuf)
+	block, err := w.codec.Encode(w.blockBuf.Bytes())
 	if err != nil {
 		return err
 	}
 
-	// write the sync bytes
-	_, err = w.output.Write(w.sync)
-	if err != nil {
+	// Write the block count and length directly to output followed by the compressed block and the sync bytes
+	w.outputEnc.WriteLong(w.blockCount)
+	w.outputEnc.WriteLong(int64(len(block)))
+	w.outputEnc.WriteRaw(block)
+	w.outputEnc.WriteRaw(w.sync)
+	if err := w.outputEnc.Flush(); err != nil {
 		return err
 	}
 
@@ -2,6 +2,8 @@ package avro
 
 import (
 	"bytes"
+	"io"
+	"runtime"
 	"testing"
 )
 
@@ -59,3 +61,242 @@ func TestDataFileWriter(t *testing.T) {
 	assert(t, err, nil)
 	assert(t, p.LongField, int64(1))
 }
+
+// oneByteReader returns a single byte per Read call to make sure nothing relies on reading the whole input at once.
+type oneByteReader struct {
+	data []byte
+}
+
+func (r *oneByteReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	p[0] = r.data[0]
+	r.data = r.data[1:]
+	return 1, nil
+}
+
+func writeTestDataFile(t *testing.T, count int, flushEvery int) []byte {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	for i := 0; i < count; i++ {
+		assert(t, dfw.Write(&primitive{LongField: int64(i)}), nil)
+		if (i+1)%flushEvery == 0 {
+			assert(t, dfw.Flush(), nil)
+		}
+	}
+	assert(t, dfw.Close(), nil)
+	return buf.Bytes()
+}
+
+func TestDataFileWriterOutputError(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	_, err := NewDataFileWriter(&failingWriter{remaining: 10}, schema, NewSpecificDatumWriter())
+	assert(t, err, errWriteFailed)
+
+	output := &failingWriter{remaining: 1000}
+	dfw, err := NewDataFileWriter(output, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	for i := 0; i < 100; i++ {
+		assert(t, dfw.Write(&primitive{LongField: int64(i)}), nil)
+	}
+	assert(t, dfw.Flush(), errWriteFailed)
+}
+
+func TestDataFileWriterOptions(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	write := func(count int, options ...DataFileWriterOption) []byte {
+		buf := &bytes.Buffer{}
+		dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter(), options...)
+		assert(t, err, nil)
+		for i := 0; i < count; i++ {
+			assert(t, dfw.Write(&primitive{LongField: int64(i)}), nil)
+		}
+		assert(t, dfw.Close(), nil)
+		return buf.Bytes()
+	}
+	open := func(data []byte) *DataFileReader {
+		dfr, err := newDataFileReaderBytes(data, NewSpecificDatumReader())
+		assert(t, err, nil)
+		return dfr
+	}
+	blockCounts := func(data []byte) []int64 {
+		dfr := open(data)
+		var counts []int64
+		for {
+			err := dfr.NextBlock()
+			if err == io.EOF {
+				return counts
+			}
+			assert(t, err, nil)
+			counts = append(counts, dfr.block.NumEntries)
+		}
+	}
+
+	// sync markers are random unless given
+	if bytes.Equal(open(write(1)).header.Sync, open(write(1)).header.Sync) {
+		t.Fatal("Expected different random sync markers")
+	}
+	sync := []byte("0123456789abcdef")
+	assert(t, write(1, WithSyncMarker(sync)), write(1, WithSyncMarker(sync)))
+	assert(t, open(write(1, WithSyncMarker(sync))).header.Sync, sync)
+
+	// blocks are flushed automatically, each primitive takes 17 bytes and Close writes an empty block
+	assert(t, blockCounts(write(7)), []int64{7, 0})
+	assert(t, blockCounts(write(7, WithBlockCount(3))), []int64{3, 3, 1, 0})
+	assert(t, blockCounts(write(7, WithBlockSize(40))), []int64{3, 3, 1, 0})
+	assert(t, blockCounts(write(6, WithBlockCount(5), WithBlockSize(40))), []int64{3, 3, 0})
+
+	dfr := open(write(1, WithMetadata("created.by", []byte("test")), WithCodecName(DeflateCodec)))
+	assert(t, dfr.Metadata("created.by"), []byte("test"))
+	assert(t, string(dfr.Metadata(codecKey)), DeflateCodec)
+	assert(t, dfr.Metadata("missing"), []byte(nil))
+
+	for _, option := range []DataFileWriterOption{
+		WithSyncMarker([]byte("short")),
+		WithBlockCount(0),
+		WithBlockSize(-1),
+		WithMetadata(schemaKey, []byte("{}")),
+	} {
+		if _, err := NewDataFileWriter(&bytes.Buffer{}, schema, NewSpecificDatumWriter(), option); err == nil {
+			t.Fatal("Expected an invalid option error")
+		}
+	}
+}
+
+func TestDataFileWriterInvalidDatum(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "a", "type": "long"},
+		{"name": "b", "type": "string"}
+	]}`)
+	type rec struct {
+		A int64
+		B interface{}
+	}
+
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	assert(t, dfw.Write(&rec{A: 1, B: "b"}), nil)
+	if err := dfw.Write(&rec{A: 2, B: 2}); err == nil {
+		t.Fatal("Expected an invalid datum error")
+	}
+	assert(t, dfw.Write(&rec{A: 3, B: "b"}), nil)
+	assert(t, dfw.Close(), nil)
+
+	// the partially written datum is not in the file
+	dfr, err := newDataFileReaderBytes(buf.Bytes(), NewSpecificDatumReader())
+	assert(t, err, nil)
+	var values []int64
+	for {
+		var value rec
+		ok, err := dfr.Next(&value)
+		assert(t, err, nil)
+		if !ok {
+			break
+		}
+		values = append(values, value.A)
+	}
+	assert(t, values, []int64{1, 3})
+}
+
+func TestDataFileReaderFromReader(t *testing.T) {
+	encoded := writeTestDataFile(t, 10, 3)
+
+	dfr, err := NewDataFileReaderFromReader(&oneByteReader{data: encoded}, NewSpecificDatumReader())
+	assert(t, err, nil)
+
+	for i := 0; i < 10; i++ {
+		var p primitive
+		ok, err := dfr.Next(&p)
+		assert(t, err, nil)
+		assert(t, ok, true)
+		assert(t, p.LongField, int64(i))
+	}
+
+	// the trailing empty block and the end of input are not errors
+	var p primitive
+	ok, err := dfr.Next(&p)
+	assert(t, err, nil)
+	assert(t, ok, false)
+	assert(t, dfr.NextBlock(), io.EOF)
+	assert(t, dfr.Close(), nil)
+}
+
+func TestDataFileReaderTruncated(t *testing.T) {
+	encoded := writeTestDataFile(t, 10, 5)
+
+	_, err := NewDataFileReaderFromReader(bytes.NewReader(encoded[:20]), NewSpecificDatumReader())
+	assert(t, err, io.ErrUnexpectedEOF)
+
+	_, err = NewDataFileReaderFromReader(bytes.NewReader([]byte("Obj")), NewSpecificDatumReader())
+	assert(t, err, NotAvroFile)
+
+	dfr, err := NewDataFileReaderFromReader(bytes.NewReader(encoded[:len(encoded)-30]), NewSpecificDatumReader())
+	assert(t, err, nil)
+	read := 0
+	for {
+		var p primitive
+		ok, err := dfr.Next(&p)
+		if !ok {
+			assert(t, err, io.ErrUnexpectedEOF)
+			break
+		}
+		read++
+	}
+	assert(t, read, 5)
+}
+
+func TestDataFileReaderCorruptedLengths(t *testing.T) {
+	// lengths of 2^31-1 bytes followed by a few bytes only
+	hugeLength := []byte{0xfe, 0xff, 0xff, 0xff, 0x0f}
+	header := append(append([]byte{}, magic...), 0x02)
+	header = append(append(header, hugeLength...), "avro.schema"...)
+
+	encoded := writeTestDataFile(t, 1, 1)
+	dfr, err := newDataFileReaderBytes(encoded, NewSpecificDatumReader())
+	assert(t, err, nil)
+	headerSize := len(encoded) - dfr.input.Buffered()
+	block := append(append(encoded[:headerSize:headerSize], 0x02), hugeLength...)
+	block = append(block, 0, 0, 0, 0)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err = newDataFileReaderBytes(header, NewSpecificDatumReader())
+	assert(t, err, io.ErrUnexpectedEOF)
+	dfr, err = newDataFileReaderBytes(block, NewSpecificDatumReader())
+	assert(t, err, nil)
+	assert(t, dfr.NextBlock(), io.ErrUnexpectedEOF)
+	runtime.ReadMemStats(&after)
+
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<24 {
+		t.Fatalf("Expected corrupted lengths not to be allocated upfront, allocated %d bytes", allocated)
+	}
+}
+
+func TestDataFileReaderSeek(t *testing.T) {
+	encoded := writeTestDataFile(t, 4, 2)
+
+	dfr, err := newDataFileReaderBytes(encoded, NewSpecificDatumReader())
+	assert(t, err, nil)
+
+	var p primitive
+	for i := 0; i < 3; i++ {
+		_, err = dfr.Next(&p)
+		assert(t, err, nil)
+	}
+
+	// data blocks start right after the sync marker that ends the header
+	dfr.Seek(int64(bytes.Index(encoded, dfr.header.Sync) + syncSize))
+	for i := 0; i < 4; i++ {
+		ok, err := dfr.Next(&p)
+		assert(t, err, nil)
+		assert(t, ok, true)
+		assert(t, p.LongField, int64(i))
+	}
+}
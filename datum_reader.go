@@ -21,7 +21,7 @@ type DatumReader interface {
 	Read(interface{}, Decoder) error
 
 	// Sets the schema for this DatumReader to know the data structure.
-	// Note that it must be called before calling Read.
+	// This is the schema the data was written with. Note that it must be called before calling Read.
 	SetSchema(Schema)
 }
 
@@ -74,11 +74,45 @@ func (enum *GenericEnum) Set(symbol string) {
 	}
 }
 
+// newCachedGenericEnum creates a GenericEnum for a given schema sharing symbol lookup tables between enums of the same name.
+func newCachedGenericEnum(schema *EnumSchema, index int32) *GenericEnum {
+	fullName := GetFullName(schema)
+
+	var symbolsToIndex map[string]int32
+	enumSymbolsToIndexCacheLock.Lock()
+	if symbolsToIndex = enumSymbolsToIndexCache[fullName]; symbolsToIndex == nil {
+		symbolsToIndex = NewGenericEnum(schema.Symbols).symbolsToIndex
+		enumSymbolsToIndexCache[fullName] = symbolsToIndex
+	} else if !sameSymbols(symbolsToIndex, schema.Symbols) {
+		// different versions of the same enum may be in use when resolving schemas
+		symbolsToIndex = NewGenericEnum(schema.Symbols).symbolsToIndex
+	}
+	enumSymbolsToIndexCacheLock.Unlock()
+
+	return &GenericEnum{
+		Symbols:        schema.Symbols,
+		symbolsToIndex: symbolsToIndex,
+		index:          index,
+	}
+}
+
+func sameSymbols(symbolsToIndex map[string]int32, symbols []string) bool {
+	if len(symbolsToIndex) != len(symbols) {
+		return false
+	}
+	for index, symbol := range symbols {
+		if i, exists := symbolsToIndex[symbol]; !exists || i != int32(index) {
+			return false
+		}
+	}
+	return true
+}
+
 // SpecificDatumReader implements DatumReader and is used for filling Go structs with data.
 // Each value passed to Read is expected to be a pointer.
 type SpecificDatumReader struct {
 	sDatumReader
-	schema Schema
+	schemas schemaResolution
 }
 
 // NewSpecificDatumReader creates a new SpecificDatumReader.
@@ -87,9 +121,17 @@ func NewSpecificDatumReader() *SpecificDatumReader {
 }
 
 // SetSchema sets the schema for this SpecificDatumReader to know the data structure.
-// Note that it must be called before calling Read.
+// This is the schema the data was written with. Note that it must be called before calling Read.
 func (reader *SpecificDatumReader) SetSchema(schema Schema) {
-	reader.schema = schema
+	reader.schemas.setWriterSchema(schema)
+}
+
+// SetReaderSchema sets the schema this SpecificDatumReader should read data as. If it differs from the schema set
+// with SetSchema, data is resolved according to Avro schema resolution rules: fields are matched by name, fields
+// missing in writer schema are filled with default values, unknown writer fields are skipped, numeric values are
+// promoted and enum symbols are mapped by name. Read returns an error if the schemas are incompatible.
+func (reader *SpecificDatumReader) SetReaderSchema(schema Schema) {
+	reader.schemas.setReaderSchema(schema)
 }
 
 // Read reads a single structured entry using this SpecificDatumReader.
@@ -100,18 +142,29 @@ func (reader *SpecificDatumReader) SetSchema(schema Schema) {
 // your struct field as follows: SomeValue int32 `avro:"some_field"`).
 // May return an error indicating a read failure.
 func (reader *SpecificDatumReader) Read(v interface{}, dec Decoder) error {
-	if reader, ok := v.(Reader); ok {
-		return reader.Read(dec)
+	resetLimits(dec)
+	if fastReader, ok := v.(Reader); ok && !reader.schemas.resolving() {
+		err := fastReader.Read(dec)
+		schema, _ := reader.schemas.schema()
+		if schema = fastPathSchema(schema, v); err != nil && schema != nil {
+			return decodeError(err, rootElement(schema), schema, dec)
+		}
+		return err
 	}
 
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
 		return errors.New("Not applicable for non-pointer types or nil")
 	}
-	if reader.schema == nil {
-		return SchemaNotSet
+	schema, err := reader.schemas.schema()
+	if err != nil {
+		return err
 	}
-	return reader.fillRecord(reader.schema, rv, dec)
+	if err := enterValue(dec); err != nil {
+		return decodeError(err, rootElement(schema), schema, dec)
+	}
+	defer leaveValue(dec)
+	return decodeError(reader.fillRecord(schema, rv, dec), rootElement(schema), schema, dec)
 }
 
 // It turns out that SpecificDatumReader as an instance is not needed
@@ -137,9 +190,14 @@ func (reader sDatumReader) findAndSet(v reflect.Value, field *SchemaField, dec D
 }
 
 func (reader sDatumReader) readValue(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+	if logicalType := GetLogicalType(field); logicalType != nil {
+		return reader.mapLogical(field, logicalType, reflectField, dec)
+	}
+
 	switch field.Type() {
 	case Null:
-		return reflect.ValueOf(nil), nil
+		_, err := dec.ReadNull()
+		return reflect.ValueOf(nil), err
 	case Boolean:
 		return reader.mapPrimitive(func() (interface{}, error) { return dec.ReadBoolean() })
 	case Int:
@@ -157,17 +215,27 @@ func (reader sDatumReader) readValue(field Schema, reflectField reflect.Value, d
 	case Array:
 		return reader.mapArray(field, reflectField, dec)
 	case Enum:
-		return reader.mapEnum(field, dec)
+		return reader.mapEnum(field, reflectField, dec)
 	case Map:
 		return reader.mapMap(field, reflectField, dec)
 	case Union:
 		return reader.mapUnion(field, reflectField, dec)
 	case Fixed:
-		return reader.mapFixed(field, dec)
+		return reader.mapFixed(field, reflectField, dec)
 	case Record:
 		return reader.mapRecord(field, reflectField, dec)
 	case Recursive:
 		return reader.mapRecord(field.(*RecursiveSchema).Actual, reflectField, dec)
+	case resolvedRecord:
+		return reader.mapRecord(field, reflectField, dec)
+	case resolvedEnum:
+		return reader.mapResolvedEnum(field.(*resolvedEnumSchema), reflectField, dec)
+	case resolvedUnion:
+		return reader.mapResolvedUnion(field.(*resolvedUnionSchema), reflectField, dec)
+	case unionBranch:
+		return reader.mapUnionBranch(field.(*unionBranchSchema), reflectField, dec)
+	case promoted:
+		return reader.mapPromoted(field.(*promotedSchema), reflectField, dec)
 	}
 
 	return reflect.ValueOf(nil), fmt.Errorf("Unknown field type: %d", field.Type())
@@ -176,6 +244,15 @@ func (reader sDatumReader) readValue(field Schema, reflectField reflect.Value, d
 func (reader sDatumReader) setValue(field *SchemaField, where reflect.Value, what reflect.Value) {
 	zero := reflect.Value{}
 	if zero != what {
+		if what.Kind() == reflect.Ptr && where.Kind() != reflect.Ptr && what.Type().Elem() == where.Type() {
+			// records are read as pointers but may be embedded by value
+			what = what.Elem()
+		} else if where.Kind() == reflect.Ptr && what.Kind() != reflect.Ptr && what.Type() == where.Type().Elem() {
+			// optional values are pointers in Go
+			pointer := reflect.New(what.Type())
+			pointer.Elem().Set(what)
+			what = pointer
+		}
 		where.Set(what)
 	}
 }
@@ -189,7 +266,27 @@ func (reader sDatumReader) mapPrimitive(readerFunc func() (interface{}, error))
 	return reflect.ValueOf(value), nil
 }
 
+// mapLogical converts values of logical types only if the target is of a corresponding Go type (e.g. time.Time for
+// timestamps), so that fields of underlying types (e.g. int64) keep working.
+func (reader sDatumReader) mapLogical(field Schema, logicalType *LogicalType, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+	value, err := readUnderlying(field, dec)
+	if err != nil {
+		return reflect.ValueOf(value), err
+	}
+
+	target := reflectField.Type()
+	if target.Kind() == reflect.Map {
+		target = target.Elem()
+	}
+	return logicalType.specificValue(value, target)
+}
+
 func (reader sDatumReader) mapArray(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+	if err := enterValue(dec); err != nil {
+		return reflect.Value{}, err
+	}
+	defer leaveValue(dec)
+
 	arrayLength, err := dec.ReadArrayStart()
 	if err != nil {
 		return reflect.ValueOf(arrayLength), err
@@ -202,13 +299,17 @@ func (reader sDatumReader) mapArray(field Schema, reflectField reflect.Value, de
 			break
 		}
 
-		arrayPart := reflect.MakeSlice(reflectField.Type(), int(arrayLength), int(arrayLength))
+		// blocks are read in parts to allocate items only as they are read
+		partLength := preallocatedItems(arrayLength)
+		arrayLength -= partLength
+
+		arrayPart := reflect.MakeSlice(reflectField.Type(), int(partLength), int(partLength))
 		var i int64
-		for ; i < arrayLength; i++ {
+		for ; i < partLength; i++ {
 			current := arrayPart.Index(int(i))
 			val, err := reader.readValue(field.(*ArraySchema).Items, current, dec)
 			if err != nil {
-				return reflect.ValueOf(arrayLength), err
+				return reflect.ValueOf(arrayLength), decodeError(err, indexElement(int64(array.Len())+i), field.(*ArraySchema).Items, dec)
 			}
 
 			// The only time `val` would not be valid is if it's an explicit null value.
@@ -228,6 +329,9 @@ func (reader sDatumReader) mapArray(field Schema, reflectField reflect.Value, de
 		} else {
 			array = reflect.AppendSlice(array, arrayPart)
 		}
+		if arrayLength > 0 {
+			continue
+		}
 		arrayLength, err = dec.ArrayNext()
 		if err != nil {
 			return reflect.ValueOf(arrayLength), err
@@ -237,12 +341,18 @@ func (reader sDatumReader) mapArray(field Schema, reflectField reflect.Value, de
 }
 
 func (reader sDatumReader) mapMap(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+	if err := enterValue(dec); err != nil {
+		return reflect.Value{}, err
+	}
+	defer leaveValue(dec)
+
 	mapLength, err := dec.ReadMapStart()
 	if err != nil {
 		return reflect.ValueOf(mapLength), err
 	}
 
 	resultMap := reflect.MakeMap(reflectField.Type())
+	elemType := reflectField.Type().Elem()
 	for {
 		if mapLength == 0 {
 			break
@@ -254,11 +364,11 @@ func (reader sDatumReader) mapMap(field Schema, reflectField reflect.Value, dec
 			if err != nil {
 				return reflect.ValueOf(mapLength), err
 			}
-			val, err := reader.readValue(field.(*MapSchema).Values, reflectField, dec)
+			val, err := reader.readValue(field.(*MapSchema).Values, reflect.New(elemType).Elem(), dec)
 			if err != nil {
-				return reflect.ValueOf(mapLength), nil
+				return reflect.ValueOf(mapLength), decodeError(err, keyElement(key.String()), field.(*MapSchema).Values, dec)
 			}
-			if val.Kind() == reflect.Ptr {
+			if val.Kind() == reflect.Ptr && elemType.Kind() != reflect.Ptr {
 				resultMap.SetMapIndex(key, val.Elem())
 			} else {
 				resultMap.SetMapIndex(key, val)
@@ -273,29 +383,50 @@ func (reader sDatumReader) mapMap(field Schema, reflectField reflect.Value, dec
 	return resultMap, nil
 }
 
-func (reader sDatumReader) mapEnum(field Schema, dec Decoder) (reflect.Value, error) {
+func (reader sDatumReader) mapEnum(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
 	enumIndex, err := dec.ReadEnum()
 	if err != nil {
 		return reflect.ValueOf(enumIndex), err
 	}
 
-	schema := field.(*EnumSchema)
-	fullName := GetFullName(schema)
+	return reader.enumValue(field.(*EnumSchema), enumIndex, reflectField)
+}
 
-	var symbolsToIndex map[string]int32
-	enumSymbolsToIndexCacheLock.Lock()
-	if symbolsToIndex = enumSymbolsToIndexCache[fullName]; symbolsToIndex == nil {
-		symbolsToIndex = NewGenericEnum(schema.Symbols).symbolsToIndex
-		enumSymbolsToIndexCache[fullName] = symbolsToIndex
+func (reader sDatumReader) mapResolvedEnum(field *resolvedEnumSchema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+	enumIndex, err := dec.ReadEnum()
+	if err != nil {
+		return reflect.ValueOf(enumIndex), err
 	}
-	enumSymbolsToIndexCacheLock.Unlock()
 
-	enum := &GenericEnum{
-		Symbols:        schema.Symbols,
-		symbolsToIndex: symbolsToIndex,
-		index:          enumIndex,
+	enumIndex, err = field.readerIndex(enumIndex)
+	if err != nil {
+		return reflect.ValueOf(enumIndex), err
 	}
-	return reflect.ValueOf(enum), nil
+	return reader.enumValue(field.EnumSchema, enumIndex, reflectField)
+}
+
+// enumValue converts an enum index to the Go type of a given field: integer types get the index, string types get
+// the symbol and all others get a GenericEnum.
+func (reader sDatumReader) enumValue(schema *EnumSchema, index int32, reflectField reflect.Value) (reflect.Value, error) {
+	if index < 0 || int(index) >= len(schema.Symbols) {
+		return reflect.ValueOf(index), fmt.Errorf("Invalid enum index %d for enum %s", index, schema.Name)
+	}
+
+	if reflectField.IsValid() {
+		t := reflectField.Type()
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return reflect.ValueOf(index).Convert(t), nil
+		case reflect.String:
+			return reflect.ValueOf(schema.Symbols[index]).Convert(t), nil
+		}
+	}
+
+	return reflect.ValueOf(newCachedGenericEnum(schema, index)), nil
 }
 
 func (reader sDatumReader) mapUnion(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
@@ -303,16 +434,95 @@ func (reader sDatumReader) mapUnion(field Schema, reflectField reflect.Value, de
 	if err != nil {
 		return reflect.ValueOf(unionType), err
 	}
+	if unionType < 0 || unionType >= int32(len(field.(*UnionSchema).Types)) {
+		return reflect.ValueOf(unionType), UnionTypeOverflow
+	}
 
 	union := field.(*UnionSchema).Types[unionType]
+	if reader.isUnionWrapper(reflectField) {
+		return reader.mapUnionWrapper(int(unionType), union, reflectField, dec)
+	}
 	return reader.readValue(union, reflectField, dec)
 }
 
-func (reader sDatumReader) mapFixed(field Schema, dec Decoder) (reflect.Value, error) {
+func (reader sDatumReader) mapResolvedUnion(field *resolvedUnionSchema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+	unionType, err := dec.ReadInt()
+	if err != nil {
+		return reflect.ValueOf(unionType), err
+	}
+
+	union, err := field.branch(unionType)
+	if err != nil {
+		return reflect.ValueOf(unionType), err
+	}
+	return reader.readValue(union, reflectField, dec)
+}
+
+func (reader sDatumReader) mapUnionBranch(field *unionBranchSchema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+	if reader.isUnionWrapper(reflectField) {
+		return reader.mapUnionWrapper(field.index, field.Schema, reflectField, dec)
+	}
+	return reader.readValue(field.Schema, reflectField, dec)
+}
+
+// isUnionWrapper checks whether a given field is of a type or a pointer to a type that implements UnionWrapper.
+func (reader sDatumReader) isUnionWrapper(reflectField reflect.Value) bool {
+	if !reflectField.IsValid() {
+		return false
+	}
+	t := reflectField.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() != reflect.Interface && reflect.PtrTo(t).Implements(unionWrapperType)
+}
+
+// mapUnionWrapper reads a union branch with a given index into a new UnionWrapper of the field type.
+func (reader sDatumReader) mapUnionWrapper(index int, branch Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+	t := reflectField.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	wrapper := reflect.New(t)
+	target, err := wrapper.Interface().(UnionWrapper).SetUnionBranch(index)
+	if err != nil {
+		return wrapper, err
+	}
+
+	if target == nil {
+		_, err = reader.readValue(branch, reflect.Value{}, dec)
+		return wrapper, err
+	}
+
+	targetValue := reflect.ValueOf(target).Elem()
+	value, err := reader.readValue(branch, targetValue, dec)
+	if err != nil {
+		return wrapper, err
+	}
+	reader.setValue(nil, targetValue, value)
+	return wrapper, nil
+}
+
+func (reader sDatumReader) mapPromoted(field *promotedSchema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+	value, err := reader.readValue(field.writer, reflectField, dec)
+	if err != nil {
+		return value, err
+	}
+
+	return reflect.ValueOf(field.promote(value.Interface())), nil
+}
+
+func (reader sDatumReader) mapFixed(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
 	fixed := make([]byte, field.(*FixedSchema).Size)
 	if err := dec.ReadFixed(fixed); err != nil {
 		return reflect.ValueOf(fixed), err
 	}
+	if reflectField.Kind() == reflect.Array && reflectField.Len() == len(fixed) {
+		// fixed values may be read into byte arrays, e.g. [16]byte
+		array := reflect.New(reflectField.Type()).Elem()
+		reflect.Copy(array, reflect.ValueOf(fixed))
+		return array, nil
+	}
 	return reflect.ValueOf(fixed), nil
 }
 
@@ -324,6 +534,11 @@ func (reader sDatumReader) mapRecord(field Schema, reflectField reflect.Value, d
 	default:
 		t = reflectField.Type()
 	}
+	if err := enterValue(dec); err != nil {
+		return reflect.Value{}, err
+	}
+	defer leaveValue(dec)
+
 	record := reflect.New(t)
 	err := reader.fillRecord(field, record, dec)
 	return record, err
@@ -343,28 +558,64 @@ func (this sDatumReader) fillRecord(field Schema, record reflect.Value, dec Deco
 			value, err := entry.dec(structField, dec)
 
 			if err != nil {
-				return err
-			}
-			if value.IsValid() {
-				structField.Set(value)
+				return decodeError(err, entry.name, entry.schema, dec)
 			}
+			this.setValue(nil, structField, value)
 		}
+	} else if rs, ok := field.(*resolvedRecordSchema); ok {
+		return this.fillResolvedRecord(rs, record, dec)
 	} else {
-		recordSchema := field.(*RecordSchema)
-		//ri := record.Interface()
-		for i := 0; i < len(recordSchema.Fields); i++ {
-			this.findAndSet(record, recordSchema.Fields[i], dec)
+		for _, schemaField := range field.(*RecordSchema).Fields {
+			if err := this.findAndSet(record, schemaField, dec); err != nil {
+				return decodeError(err, schemaField.Name, schemaField.Type, dec)
+			}
 		}
 	}
 	return nil
 }
 
+func (this sDatumReader) fillResolvedRecord(field *resolvedRecordSchema, record reflect.Value, dec Decoder) error {
+	for _, resolved := range field.fields {
+		structField, err := findField(record, resolved.name)
+		if resolved.name == "" || err != nil {
+			// either the field was removed from reader schema or the struct does not have it
+			if err := SkipValue(resolved.writer, dec); err != nil {
+				return decodeError(err, resolved.name, resolved.writer, dec)
+			}
+			continue
+		}
+
+		value, err := this.readValue(resolved.schema, structField, dec)
+		if err != nil {
+			return decodeError(err, resolved.name, resolved.schema, dec)
+		}
+		this.setValue(nil, structField, value)
+	}
+
+	for _, readerField := range field.defaults {
+		structField, err := findField(record, readerField.Name)
+		if err != nil {
+			continue
+		}
+
+		value, err := defaultValue(readerField.Type, readerField.Default)
+		if err != nil {
+			return decodeError(err, readerField.Name, readerField.Type, dec)
+		}
+		if err := setDefault(structField, readerField.Type, value); err != nil {
+			return decodeError(err, readerField.Name, readerField.Type, dec)
+		}
+	}
+
+	return nil
+}
+
 // GenericDatumReader implements DatumReader and is used for filling GenericRecords or other Avro supported types
 // (full list is: interface{}, bool, int32, int64, float32, float64, string, slices of any type, maps with string keys
 // and any values, GenericEnums) with data.
 // Each value passed to Read is expected to be a pointer.
 type GenericDatumReader struct {
-	schema Schema
+	schemas schemaResolution
 }
 
 // NewGenericDatumReader creates a new GenericDatumReader.
@@ -373,9 +624,17 @@ func NewGenericDatumReader() *GenericDatumReader {
 }
 
 // SetSchema sets the schema for this GenericDatumReader to know the data structure.
-// Note that it must be called before calling Read.
+// This is the schema the data was written with. Note that it must be called before calling Read.
 func (reader *GenericDatumReader) SetSchema(schema Schema) {
-	reader.schema = schema
+	reader.schemas.setWriterSchema(schema)
+}
+
+// SetReaderSchema sets the schema this GenericDatumReader should read data as. If it differs from the schema set
+// with SetSchema, data is resolved according to Avro schema resolution rules: fields are matched by name, fields
+// missing in writer schema are filled with default values, unknown writer fields are skipped, numeric values are
+// promoted and enum symbols are mapped by name. Read returns an error if the schemas are incompatible.
+func (reader *GenericDatumReader) SetReaderSchema(schema Schema) {
+	reader.schemas.setReaderSchema(schema)
 }
 
 // Read reads a single entry using this GenericDatumReader.
@@ -387,14 +646,16 @@ func (reader *GenericDatumReader) Read(v interface{}, dec Decoder) error {
 		return errors.New("Not applicable for non-pointer types or nil")
 	}
 	rv = rv.Elem()
-	if reader.schema == nil {
-		return SchemaNotSet
+	schema, err := reader.schemas.schema()
+	if err != nil {
+		return err
 	}
 
+	resetLimits(dec)
 	//read the value
-	value, err := reader.readValue(reader.schema, dec)
+	value, err := reader.readValue(schema, dec)
 	if err != nil {
-		return err
+		return decodeError(err, rootElement(schema), schema, dec)
 	}
 
 	newValue := reflect.ValueOf(value)
@@ -415,24 +676,32 @@ func (reader *GenericDatumReader) findAndSet(record *GenericRecord, field *Schem
 		return err
 	}
 
+	return reader.setValue(record, field.Name, value)
+}
+
+func (reader *GenericDatumReader) setValue(record *GenericRecord, name string, value interface{}) error {
 	switch typedValue := value.(type) {
 	case *GenericEnum:
 		if typedValue.GetIndex() >= int32(len(typedValue.Symbols)) {
 			return errors.New("Enum index invalid!")
 		}
-		record.Set(field.Name, typedValue.Symbols[typedValue.GetIndex()])
+		record.Set(name, typedValue.Symbols[typedValue.GetIndex()])
 
 	default:
-		record.Set(field.Name, value)
+		record.Set(name, value)
 	}
 
 	return nil
 }
 
 func (reader *GenericDatumReader) readValue(field Schema, dec Decoder) (interface{}, error) {
+	if logicalType := GetLogicalType(field); logicalType != nil {
+		return reader.mapLogical(field, logicalType, dec)
+	}
+
 	switch field.Type() {
 	case Null:
-		return nil, nil
+		return dec.ReadNull()
 	case Boolean:
 		return dec.ReadBoolean()
 	case Int:
@@ -461,12 +730,36 @@ func (reader *GenericDatumReader) readValue(field Schema, dec Decoder) (interfac
 		return reader.mapRecord(field, dec)
 	case Recursive:
 		return reader.mapRecord(field.(*RecursiveSchema).Actual, dec)
+	case resolvedRecord:
+		return reader.mapResolvedRecord(field.(*resolvedRecordSchema), dec)
+	case resolvedEnum:
+		return reader.mapResolvedEnum(field.(*resolvedEnumSchema), dec)
+	case resolvedUnion:
+		return reader.mapResolvedUnion(field.(*resolvedUnionSchema), dec)
+	case unionBranch:
+		return reader.readValue(field.(*unionBranchSchema).Schema, dec)
+	case promoted:
+		return reader.mapPromoted(field.(*promotedSchema), dec)
 	}
 
 	return nil, fmt.Errorf("Unknown field type: %d", field.Type())
 }
 
+func (reader *GenericDatumReader) mapLogical(field Schema, logicalType *LogicalType, dec Decoder) (interface{}, error) {
+	value, err := readUnderlying(field, dec)
+	if err != nil {
+		return nil, err
+	}
+
+	return logicalType.fromRaw(value)
+}
+
 func (reader *GenericDatumReader) mapArray(field Schema, dec Decoder) ([]interface{}, error) {
+	if err := enterValue(dec); err != nil {
+		return nil, err
+	}
+	defer leaveValue(dec)
+
 	arrayLength, err := dec.ReadArrayStart()
 	if err != nil {
 		return nil, err
@@ -477,20 +770,18 @@ func (reader *GenericDatumReader) mapArray(field Schema, dec Decoder) ([]interfa
 		if arrayLength == 0 {
 			break
 		}
-		arrayPart := make([]interface{}, arrayLength, arrayLength)
+		if array == nil {
+			// further items are allocated only as they are read
+			array = make([]interface{}, 0, preallocatedItems(arrayLength))
+		}
 		var i int64
 		for ; i < arrayLength; i++ {
 			val, err := reader.readValue(field.(*ArraySchema).Items, dec)
 			if err != nil {
-				return nil, err
+				return nil, decodeError(err, indexElement(int64(len(array))), field.(*ArraySchema).Items, dec)
 			}
-			arrayPart[i] = val
+			array = append(array, val)
 		}
-		//concatenate arrays
-		concatArray := make([]interface{}, len(array)+int(arrayLength), cap(array)+int(arrayLength))
-		copy(concatArray, array)
-		copy(concatArray, arrayPart)
-		array = concatArray
 		arrayLength, err = dec.ArrayNext()
 		if err != nil {
 			return nil, err
@@ -506,25 +797,31 @@ func (reader *GenericDatumReader) mapEnum(field Schema, dec Decoder) (*GenericEn
 	}
 
 	schema := field.(*EnumSchema)
-	fullName := GetFullName(schema)
+	if enumIndex < 0 || int(enumIndex) >= len(schema.Symbols) {
+		return nil, fmt.Errorf("Invalid enum index %d for enum %s", enumIndex, schema.Name)
+	}
+	return newCachedGenericEnum(schema, enumIndex), nil
+}
 
-	var symbolsToIndex map[string]int32
-	enumSymbolsToIndexCacheLock.Lock()
-	if symbolsToIndex = enumSymbolsToIndexCache[fullName]; symbolsToIndex == nil {
-		symbolsToIndex = NewGenericEnum(schema.Symbols).symbolsToIndex
-		enumSymbolsToIndexCache[fullName] = symbolsToIndex
+func (reader *GenericDatumReader) mapResolvedEnum(field *resolvedEnumSchema, dec Decoder) (*GenericEnum, error) {
+	enumIndex, err := dec.ReadEnum()
+	if err != nil {
+		return nil, err
 	}
-	enumSymbolsToIndexCacheLock.Unlock()
 
-	enum := &GenericEnum{
-		Symbols:        schema.Symbols,
-		symbolsToIndex: symbolsToIndex,
-		index:          enumIndex,
+	enumIndex, err = field.readerIndex(enumIndex)
+	if err != nil {
+		return nil, err
 	}
-	return enum, nil
+	return newCachedGenericEnum(field.EnumSchema, enumIndex), nil
 }
 
 func (reader *GenericDatumReader) mapMap(field Schema, dec Decoder) (map[string]interface{}, error) {
+	if err := enterValue(dec); err != nil {
+		return nil, err
+	}
+	defer leaveValue(dec)
+
 	mapLength, err := dec.ReadMapStart()
 	if err != nil {
 		return nil, err
@@ -543,7 +840,7 @@ func (reader *GenericDatumReader) mapMap(field Schema, dec Decoder) (map[string]
 			}
 			val, err := reader.readValue(field.(*MapSchema).Values, dec)
 			if err != nil {
-				return nil, err
+				return nil, decodeError(err, keyElement(key.(string)), field.(*MapSchema).Values, dec)
 			}
 			resultMap[key.(string)] = val
 		}
@@ -569,6 +866,28 @@ func (reader *GenericDatumReader) mapUnion(field Schema, dec Decoder) (interface
 	return nil, UnionTypeOverflow
 }
 
+func (reader *GenericDatumReader) mapResolvedUnion(field *resolvedUnionSchema, dec Decoder) (interface{}, error) {
+	unionType, err := dec.ReadInt()
+	if err != nil {
+		return nil, err
+	}
+
+	union, err := field.branch(unionType)
+	if err != nil {
+		return nil, err
+	}
+	return reader.readValue(union, dec)
+}
+
+func (reader *GenericDatumReader) mapPromoted(field *promotedSchema, dec Decoder) (interface{}, error) {
+	value, err := reader.readValue(field.writer, dec)
+	if err != nil {
+		return nil, err
+	}
+
+	return field.promote(value), nil
+}
+
 func (reader *GenericDatumReader) mapFixed(field Schema, dec Decoder) ([]byte, error) {
 	fixed := make([]byte, field.(*FixedSchema).Size)
 	if err := dec.ReadFixed(fixed); err != nil {
@@ -578,14 +897,53 @@ func (reader *GenericDatumReader) mapFixed(field Schema, dec Decoder) ([]byte, e
 }
 
 func (reader *GenericDatumReader) mapRecord(field Schema, dec Decoder) (*GenericRecord, error) {
+	if err := enterValue(dec); err != nil {
+		return nil, err
+	}
+	defer leaveValue(dec)
+
 	record := NewGenericRecord(field)
 
-	recordSchema := assertRecordSchema(field)
-	for i := 0; i < len(recordSchema.Fields); i++ {
-		err := reader.findAndSet(record, recordSchema.Fields[i], dec)
+	for _, schemaField := range assertRecordSchema(field).Fields {
+		if err := reader.findAndSet(record, schemaField, dec); err != nil {
+			return nil, decodeError(err, schemaField.Name, schemaField.Type, dec)
+		}
+	}
+
+	return record, nil
+}
+
+func (reader *GenericDatumReader) mapResolvedRecord(field *resolvedRecordSchema, dec Decoder) (*GenericRecord, error) {
+	if err := enterValue(dec); err != nil {
+		return nil, err
+	}
+	defer leaveValue(dec)
+
+	record := NewGenericRecord(field.RecordSchema)
+
+	for _, resolved := range field.fields {
+		if resolved.name == "" {
+			if err := SkipValue(resolved.writer, dec); err != nil {
+				return nil, decodeError(err, "", resolved.writer, dec)
+			}
+			continue
+		}
+
+		value, err := reader.readValue(resolved.schema, dec)
 		if err != nil {
-			return nil, err
+			return nil, decodeError(err, resolved.name, resolved.schema, dec)
+		}
+		if err := reader.setValue(record, resolved.name, value); err != nil {
+			return nil, decodeError(err, resolved.name, resolved.schema, dec)
+		}
+	}
+
+	for _, readerField := range field.defaults {
+		value, err := defaultValue(readerField.Type, readerField.Default)
+		if err != nil {
+			return nil, decodeError(err, readerField.Name, readerField.Type, dec)
 		}
+		record.Set(readerField.Name, value)
 	}
 
 	return record, nil
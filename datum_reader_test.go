@@ -2,6 +2,7 @@ package avro
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
@@ -558,3 +559,219 @@ func testEncodeBytes(schema Schema, rec interface{}) []byte {
 	}
 	return buf.Bytes()
 }
+
+func TestDatumReaderLimits(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+		{"name": "children", "type": {"type": "array", "items": "Node"}}
+	]}`)
+
+	// a linked list of nodes 5 levels deep, each level is a record and an array
+	buf := &bytes.Buffer{}
+	enc := NewBinaryEncoder(buf)
+	for i := 0; i < 4; i++ {
+		enc.WriteArrayStart(1)
+	}
+	enc.WriteArrayStart(0)
+	for i := 0; i < 4; i++ {
+		enc.WriteArrayNext(0)
+	}
+
+	type node struct {
+		Children []*node
+	}
+	readers := map[string]func(dec Decoder) error{
+		"generic": func(dec Decoder) error {
+			reader := NewGenericDatumReader()
+			reader.SetSchema(schema)
+			var record GenericRecord
+			return reader.Read(&record, dec)
+		},
+		"specific": func(dec Decoder) error {
+			reader := NewSpecificDatumReader()
+			reader.SetSchema(schema)
+			return reader.Read(&node{}, dec)
+		},
+	}
+	for name, read := range readers {
+		assert(t, read(NewBinaryDecoderWithOptions(buf.Bytes(), DecoderOptions{MaxDepth: 10})), nil)
+		err := read(NewBinaryDecoderWithOptions(buf.Bytes(), DecoderOptions{MaxDepth: 9}))
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || limitErr.Limit != "depth" {
+			t.Fatalf("Expected depth limit error from %s reader, got %v", name, err)
+		}
+
+		// a huge block count of empty records is read without allocating all items upfront
+		huge := []byte{0xfe, 0xff, 0xff, 0xff, 0x0f}
+		err = read(NewBinaryDecoderWithOptions(huge, DecoderOptions{MaxCollectionSize: 1000}))
+		assert(t, err, &DecodeError{Path: "Node.children", Type: Array, Offset: 5,
+			Err: &LimitError{Limit: "collection size", Value: 2147483647, Max: 1000}})
+		err = read(NewBinaryDecoder(huge))
+		if err == nil {
+			t.Fatalf("Expected an error for truncated data from %s reader", name)
+		}
+	}
+}
+
+func TestDatumReaderSkippedLimits(t *testing.T) {
+	writerSchema := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "a", "type": {"type": "array", "items": "null"}}
+	]}`)
+	readerSchema := MustParseSchema(`{"type": "record", "name": "Rec", "fields": []}`)
+
+	// a removed field of 2^40 null items that take no space
+	buf := &bytes.Buffer{}
+	NewBinaryEncoder(buf).WriteArrayStart(1 << 40)
+
+	decoders := map[string]func(options DecoderOptions) Decoder{
+		"binary": func(options DecoderOptions) Decoder { return NewBinaryDecoderWithOptions(buf.Bytes(), options) },
+		"stream": func(options DecoderOptions) Decoder {
+			return NewStreamDecoderWithOptions(bytes.NewReader(buf.Bytes()), options)
+		},
+	}
+	for name, decoder := range decoders {
+		for _, options := range []DecoderOptions{{MaxBlockCount: 1000}, {MaxCollectionSize: 1000}} {
+			reader := NewGenericDatumReader()
+			reader.SetSchema(writerSchema)
+			reader.SetReaderSchema(readerSchema)
+			var record GenericRecord
+			err := reader.Read(&record, decoder(options))
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("Expected a limit error from %s decoder with %+v, got %v", name, options, err)
+			}
+		}
+	}
+
+	// nested records are skipped within the depth limit
+	writerSchema = MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "a", "type": {"type": "record", "name": "A", "fields": [
+			{"name": "b", "type": {"type": "record", "name": "B", "fields": [{"name": "c", "type": "int"}]}}
+		]}}
+	]}`)
+	reader := NewGenericDatumReader()
+	reader.SetSchema(writerSchema)
+	reader.SetReaderSchema(readerSchema)
+	var record GenericRecord
+	assert(t, reader.Read(&record, NewBinaryDecoderWithOptions([]byte{2}, DecoderOptions{MaxDepth: 3})), nil)
+	err := reader.Read(&record, NewBinaryDecoderWithOptions([]byte{2}, DecoderOptions{MaxDepth: 2}))
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "depth" {
+		t.Fatalf("Expected depth limit error, got %v", err)
+	}
+}
+
+func TestDatumReaderLimitsReset(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Hand", "fields": [
+		{"name": "cards", "type": {"type": "array", "items": {"type": "enum", "name": "Card", "symbols": ["A", "K"]}}}
+	]}`)
+
+	// a hand with an invalid card is followed by a valid hand
+	buf := &bytes.Buffer{}
+	enc := NewBinaryEncoder(buf)
+	enc.WriteArrayStart(2)
+	enc.WriteInt(0)
+	enc.WriteInt(5)
+	enc.WriteArrayStart(2)
+	enc.WriteInt(0)
+	enc.WriteInt(1)
+	enc.WriteArrayNext(0)
+
+	dec := NewStreamDecoderWithOptions(buf, DecoderOptions{MaxDepth: 2, MaxCollectionSize: 3})
+	reader := NewGenericDatumReader()
+	reader.SetSchema(schema)
+	var record GenericRecord
+	assert(t, reader.Read(&record, dec) != nil, true)
+
+	// the unfinished array of the failed read is not counted anymore
+	assert(t, reader.Read(&record, dec), nil)
+	assert(t, len(record.Get("cards").([]interface{})), 2)
+	assert(t, dec.depth, 0)
+	assert(t, len(dec.collections), 0)
+}
+
+func TestGenericDatumReaderArrayBlocks(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enc := NewBinaryEncoder(buf)
+	enc.WriteArrayStart(2)
+	enc.WriteInt(1)
+	enc.WriteInt(2)
+	enc.WriteArrayNext(1)
+	enc.WriteInt(3)
+	enc.WriteArrayNext(0)
+
+	reader := NewGenericDatumReader()
+	reader.SetSchema(MustParseSchema(`{"type": "array", "items": "int"}`))
+	var array []interface{}
+	assert(t, reader.Read(&array, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, array, []interface{}{int32(1), int32(2), int32(3)})
+}
+
+const orderSchemaRaw = `{"type": "record", "name": "Order", "fields": [
+	{"name": "id", "type": "long"},
+	{"name": "items", "type": {"type": "array", "items": {"type": "record", "name": "Item", "fields": [
+		{"name": "price", "type": "double"}
+	]}}},
+	{"name": "tags", "type": {"type": "map", "values": "string"}}
+]}`
+
+func TestDatumReaderErrors(t *testing.T) {
+	schema := MustParseSchema(orderSchemaRaw)
+
+	type item struct {
+		Price float64
+	}
+	type order struct {
+		Id    int64
+		Items []*item
+		Tags  map[string]string
+	}
+	readers := map[string]func(dec Decoder) error{
+		"generic": func(dec Decoder) error {
+			reader := NewGenericDatumReader()
+			reader.SetSchema(schema)
+			var record GenericRecord
+			return reader.Read(&record, dec)
+		},
+		"specific": func(dec Decoder) error {
+			reader := NewSpecificDatumReader()
+			reader.SetSchema(schema)
+			return reader.Read(&order{}, dec)
+		},
+		"prepared": func(dec Decoder) error {
+			reader := NewSpecificDatumReader()
+			reader.SetSchema(Prepare(schema))
+			return reader.Read(&order{}, dec)
+		},
+	}
+
+	// the price of the second item is truncated
+	truncated := &bytes.Buffer{}
+	enc := NewBinaryEncoder(truncated)
+	enc.WriteLong(1)
+	enc.WriteArrayStart(2)
+	enc.WriteDouble(1.5)
+	enc.WriteRaw([]byte{0, 0, 0, 0})
+
+	// the value of the second tag has a negative length
+	invalid := &bytes.Buffer{}
+	enc = NewBinaryEncoder(invalid)
+	enc.WriteLong(1)
+	enc.WriteArrayNext(0)
+	enc.WriteMapStart(2)
+	enc.WriteString("a")
+	enc.WriteString("b")
+	enc.WriteString("c")
+	enc.WriteLong(-1)
+
+	for name, read := range readers {
+		err := read(NewBinaryDecoder(truncated.Bytes()))
+		assert(t, err, &DecodeError{Path: "Order.items[1].price", Type: Double, Offset: 10, Err: EOF})
+		if !errors.Is(err, EOF) {
+			t.Fatalf("Expected %s reader error to wrap EOF, got %v", name, err)
+		}
+		assert(t, err.Error(), "Cannot decode Order.items[1].price (double) at offset 10: End of file reached")
+
+		err = read(NewBinaryDecoder(invalid.Bytes()))
+		assert(t, err, &DecodeError{Path: `Order.tags["c"]`, Type: String, Offset: 10, Err: InvalidStringLength})
+	}
+}
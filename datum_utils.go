@@ -80,12 +80,12 @@ func (rm *reflectInfo) fill(t reflect.Type, indexPrefix []int) {
 	var toInvestigate [][]int
 	for i := 0; i < t.NumField(); i++ {
 		f := t.Field(i)
-		tag := f.Tag.Get("avro")
+		tag, _ := parseTag(f.Tag.Get("avro"))
 		idx := append(append([]int{}, indexPrefix...), f.Index...)
 
 		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
 			toInvestigate = append(toInvestigate, idx)
-		} else if strings.ToLower(f.Name[:1]) != f.Name[:1] {
+		} else if strings.ToLower(f.Name[:1]) != f.Name[:1] && tag != "-" {
 			if tag != "" {
 				fillName(tag, idx)
 			} else {
@@ -99,3 +99,25 @@ func (rm *reflectInfo) fill(t reflect.Type, indexPrefix []int) {
 		rm.fill(t.Field(idx[len(idx)-1]).Type, idx)
 	}
 }
+
+// parseTag splits an avro struct tag into a field name and options, e.g. `avro:"name,nullable,default=0"`.
+// Options are separated by commas and may have values, the doc option takes the rest of the tag so that docs may
+// contain commas.
+func parseTag(tag string) (string, map[string]string) {
+	parts := strings.Split(tag, ",")
+	options := make(map[string]string)
+	for i := 1; i < len(parts); i++ {
+		option := strings.SplitN(parts[i], "=", 2)
+		if len(option) == 1 {
+			options[option[0]] = ""
+			continue
+		}
+		if option[0] == tagDoc {
+			options[tagDoc] = strings.Join(append([]string{option[1]}, parts[i+1:]...), ",")
+			break
+		}
+		options[option[0]] = option[1]
+	}
+
+	return parts[0], options
+}
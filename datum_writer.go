@@ -12,6 +12,44 @@ type Writer interface {
 	Write(enc Encoder) error
 }
 
+// UnionWrapper may be implemented by pointers to Go types that represent Avro unions with a field per branch, like
+// union types generated by codegen. SpecificDatumWriter and SpecificDatumReader use it to select union branches by
+// index instead of matching values against branch schemas, which is ambiguous e.g. for ["string", "bytes"].
+type UnionWrapper interface {
+	// UnionBranch returns the index of the union branch that is set along with its value.
+	// Returns -1 if no branch is set and the union has no null branch.
+	UnionBranch() (int, interface{})
+
+	// SetUnionBranch sets the union branch with a given index to a new zero value and returns a pointer to it to be
+	// filled, nil for the null branch. Returns UnionTypeOverflow if there is no such branch.
+	SetUnionBranch(index int) (interface{}, error)
+}
+
+var unionWrapperType = reflect.TypeOf((*UnionWrapper)(nil)).Elem()
+
+// asUnionWrapper returns a given value as UnionWrapper if it or a pointer to it implements the interface.
+// Nil pointers are not considered wrappers, so that they keep being written as null.
+func asUnionWrapper(v reflect.Value) (UnionWrapper, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+	if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface && reflect.PtrTo(v.Type()).Implements(unionWrapperType) {
+		if v.CanAddr() {
+			v = v.Addr()
+		} else {
+			pointer := reflect.New(v.Type())
+			pointer.Elem().Set(v)
+			v = pointer
+		}
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() || !v.CanInterface() {
+		return nil, false
+	}
+
+	wrapper, ok := v.Interface().(UnionWrapper)
+	return wrapper, ok
+}
+
 // DatumWriter is an interface that is responsible for writing structured data according to schema to an encoder.
 type DatumWriter interface {
 	// Write writes a single entry using this DatumWriter according to provided Schema.
@@ -45,10 +83,16 @@ func (writer *SpecificDatumWriter) SetSchema(schema Schema) {
 // (e.g. "some_value" in Avro schema is expected to be Some_value in struct) or you may provide Go struct tags to
 // explicitly show how to map fields (e.g. if you want to map "some_value" field of type int to SomeValue in Go struct
 // you should define your struct field as follows: SomeValue int32 `avro:"some_field"`).
-// May return an error indicating a write failure.
+// May return an error indicating a write failure, including errors of Encoders that keep them as BinaryEncoder does.
 func (writer *SpecificDatumWriter) Write(obj interface{}, enc Encoder) error {
-	if writer, ok := obj.(Writer); ok {
-		return writer.Write(enc)
+	if fastWriter, ok := obj.(Writer); ok {
+		if err := fastWriter.Write(enc); err != nil {
+			if schema := fastPathSchema(writer.schema, obj); schema != nil {
+				return encodeError(err, rootElement(schema), schema)
+			}
+			return err
+		}
+		return encoderErr(enc)
 	}
 
 	rv := reflect.ValueOf(obj)
@@ -57,12 +101,24 @@ func (writer *SpecificDatumWriter) Write(obj interface{}, enc Encoder) error {
 		return SchemaNotSet
 	}
 
-	return writer.write(rv, enc, writer.schema)
+	if err := writer.write(rv, enc, writer.schema); err != nil {
+		return encodeError(err, rootElement(writer.schema), writer.schema)
+	}
+	return encoderErr(enc)
 }
 
 func (writer *SpecificDatumWriter) write(v reflect.Value, enc Encoder, s Schema) error {
+	if logicalType := GetLogicalType(s); logicalType != nil && v.IsValid() && v.CanInterface() {
+		raw, err := logicalType.toRaw(v.Interface(), s)
+		if err != nil {
+			return err
+		}
+		v = reflect.ValueOf(raw)
+	}
+
 	switch s.Type() {
 	case Null:
+		enc.WriteNull(nil)
 	case Boolean:
 		return writer.writeBoolean(v, enc, s)
 	case Int:
@@ -173,7 +229,7 @@ func (writer *SpecificDatumWriter) writeArray(v reflect.Value, enc Encoder, s Sc
 	enc.WriteArrayStart(int64(v.Len()))
 	for i := 0; i < v.Len(); i++ {
 		if err := writer.write(v.Index(i), enc, s.(*ArraySchema).Items); err != nil {
-			return err
+			return encodeError(err, indexElement(int64(i)), s.(*ArraySchema).Items)
 		}
 	}
 	enc.WriteArrayNext(0)
@@ -198,7 +254,7 @@ func (writer *SpecificDatumWriter) writeMap(v reflect.Value, enc Encoder, s Sche
 			return err
 		}
 		if err = writer.write(v.MapIndex(key), enc, s.(*MapSchema).Values); err != nil {
-			return err
+			return encodeError(err, keyElement(key.String()), s.(*MapSchema).Values)
 		}
 	}
 	enc.WriteMapNext(0)
@@ -207,25 +263,42 @@ func (writer *SpecificDatumWriter) writeMap(v reflect.Value, enc Encoder, s Sche
 }
 
 func (writer *SpecificDatumWriter) writeEnum(v reflect.Value, enc Encoder, s Schema) error {
-	if !s.Validate(v) {
+	index, ok := s.(*EnumSchema).indexOf(v)
+	if !ok {
 		return fmt.Errorf("Invalid enum value: %v", v.Interface())
 	}
 
-	enc.WriteInt(v.Interface().(*GenericEnum).GetIndex())
+	enc.WriteInt(index)
 
 	return nil
 }
 
 func (writer *SpecificDatumWriter) writeUnion(v reflect.Value, enc Encoder, s Schema) error {
 	unionSchema := s.(*UnionSchema)
+	if wrapper, ok := asUnionWrapper(v); ok {
+		index, value := wrapper.UnionBranch()
+		if index < 0 || index >= len(unionSchema.Types) {
+			return InvalidUnionValue
+		}
+
+		enc.WriteLong(int64(index))
+		return writer.write(reflect.ValueOf(value), enc, unionSchema.Types[index])
+	}
+
 	index := unionSchema.GetType(v)
 
 	if unionSchema.Types == nil || index < 0 || index >= len(unionSchema.Types) {
 		return fmt.Errorf("Invalid union value: %v", v.Interface())
 	}
 
+	branch := unionSchema.Types[index]
+	if v.Kind() == reflect.Ptr && !v.IsNil() && GetLogicalType(branch) == nil {
+		// optional values are pointers in Go
+		v = v.Elem()
+	}
+
 	enc.WriteLong(int64(index))
-	return writer.write(v, enc, unionSchema.Types[index])
+	return writer.write(v, enc, branch)
 }
 
 func (writer *SpecificDatumWriter) writeFixed(v reflect.Value, enc Encoder, s Schema) error {
@@ -236,6 +309,13 @@ func (writer *SpecificDatumWriter) writeFixed(v reflect.Value, enc Encoder, s Sc
 	}
 
 	// Write the raw bytes. The length is known by the schema
+	v = dereference(v)
+	if v.Kind() == reflect.Array {
+		fixed := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(fixed), v)
+		enc.WriteRaw(fixed)
+		return nil
+	}
 	enc.WriteRaw(v.Interface().([]byte))
 	return nil
 }
@@ -250,10 +330,10 @@ func (writer *SpecificDatumWriter) writeRecord(v reflect.Value, enc Encoder, s S
 		schemaField := rs.Fields[i]
 		field, err := findField(v, schemaField.Name)
 		if err != nil {
-			return err
+			return encodeError(err, schemaField.Name, schemaField.Type)
 		}
 		if err := writer.write(field, enc, schemaField.Type); err != nil {
-			return err
+			return encodeError(err, schemaField.Name, schemaField.Type)
 		}
 	}
 
@@ -280,14 +360,30 @@ func (writer *GenericDatumWriter) SetSchema(schema Schema) {
 
 // Write writes a single entry using this GenericDatumWriter according to provided Schema.
 // Accepts a value to write and Encoder to write to.
-// May return an error indicating a write failure.
+// May return an error indicating a write failure, including errors of Encoders that keep them as BinaryEncoder does.
 func (writer *GenericDatumWriter) Write(obj interface{}, enc Encoder) error {
-	return writer.write(obj, enc, writer.schema)
+	if writer.schema == nil {
+		return SchemaNotSet
+	}
+
+	if err := writer.write(obj, enc, writer.schema); err != nil {
+		return encodeError(err, rootElement(writer.schema), writer.schema)
+	}
+	return encoderErr(enc)
 }
 
 func (writer *GenericDatumWriter) write(v interface{}, enc Encoder, s Schema) error {
+	if logicalType := GetLogicalType(s); logicalType != nil {
+		raw, err := logicalType.toRaw(v, s)
+		if err != nil {
+			return err
+		}
+		v = raw
+	}
+
 	switch s.Type() {
 	case Null:
+		enc.WriteNull(nil)
 	case Boolean:
 		return writer.writeBoolean(v, enc)
 	case Int:
@@ -414,7 +510,7 @@ func (writer *GenericDatumWriter) writeArray(v interface{}, enc Encoder, s Schem
 	for i := 0; i < rv.Len(); i++ {
 		err := writer.write(rv.Index(i).Interface(), enc, s.(*ArraySchema).Items)
 		if err != nil {
-			return err
+			return encodeError(err, indexElement(int64(i)), s.(*ArraySchema).Items)
 		}
 	}
 	enc.WriteArrayNext(0)
@@ -442,7 +538,7 @@ func (writer *GenericDatumWriter) writeMap(v interface{}, enc Encoder, s Schema)
 		}
 		err = writer.write(rv.MapIndex(key).Interface(), enc, s.(*MapSchema).Values)
 		if err != nil {
-			return err
+			return encodeError(err, keyElement(fmt.Sprint(key.Interface())), s.(*MapSchema).Values)
 		}
 	}
 	enc.WriteMapNext(0)
@@ -451,35 +547,27 @@ func (writer *GenericDatumWriter) writeMap(v interface{}, enc Encoder, s Schema)
 }
 
 func (writer *GenericDatumWriter) writeEnum(v interface{}, enc Encoder, s Schema) error {
-	switch v.(type) {
+	var symbol string
+	switch value := v.(type) {
 	case *GenericEnum:
-		{
-			rs := s.(*EnumSchema)
-			for i := range rs.Symbols {
-				if rs.Name == rs.Symbols[i] {
-					err := writer.writeInt(i, enc)
-					if err != nil {
-						return err
-					}
-					break
-				}
-			}
+		if value == nil || value.GetIndex() < 0 || int(value.GetIndex()) >= len(value.Symbols) {
+			return InvalidEnumValue
 		}
+		symbol = value.Get()
 	case string:
-		{
-			rs := s.(*EnumSchema)
-			for i := range rs.Symbols {
-				if v.(string) == rs.Symbols[i] {
-					enc.WriteInt(int32(i))
-					break
-				}
-			}
-		}
+		symbol = value
 	default:
 		return fmt.Errorf("%v is not a *GenericEnum", v)
 	}
 
-	return nil
+	rs := s.(*EnumSchema)
+	for i := range rs.Symbols {
+		if symbol == rs.Symbols[i] {
+			enc.WriteInt(int32(i))
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown enum symbol %s for enum %s", symbol, rs.Name)
 }
 
 func (writer *GenericDatumWriter) writeUnion(v interface{}, enc Encoder, s Schema) error {
@@ -534,7 +622,19 @@ func (writer *GenericDatumWriter) isWritableAs(v interface{}, s Schema) bool {
 }
 
 func (writer *GenericDatumWriter) writeFixed(v interface{}, enc Encoder, s Schema) error {
-	return writer.writeBytes(v, enc)
+	fs := s.(*FixedSchema)
+	switch value := v.(type) {
+	case []byte:
+		if len(value) != fs.Size {
+			return fmt.Errorf("%v is not a fixed of size %d", v, fs.Size)
+		}
+		// Write the raw bytes. The length is known by the schema
+		enc.WriteRaw(value)
+	default:
+		return fmt.Errorf("%v is not a []byte", v)
+	}
+
+	return nil
 }
 
 func (writer *GenericDatumWriter) writeRecord(v interface{}, enc Encoder, s Schema) error {
@@ -550,7 +650,7 @@ func (writer *GenericDatumWriter) writeRecord(v interface{}, enc Encoder, s Sche
 				}
 				err := writer.write(field, enc, schemaField.Type)
 				if err != nil {
-					return err
+					return encodeError(err, schemaField.Name, schemaField.Type)
 				}
 			}
 		}
@@ -2,7 +2,9 @@ package avro
 
 import (
 	"bytes"
+	"errors"
 	"math/rand"
+	"reflect"
 	"testing"
 )
 
@@ -179,6 +181,49 @@ func TestSpecificDatumTags(t *testing.T) {
 	assert(t, out.Map, in.Map)
 }
 
+func TestSpecificDatumEnums(t *testing.T) {
+	type Suit int32
+	type Color string
+	type Cards struct {
+		Suit    Suit
+		Color   Color
+		Generic *GenericEnum
+		Rank    uint8
+	}
+
+	sch, err := ParseSchema(`{"type":"record","name":"Cards","fields":[
+		{"name":"suit","type":{"type":"enum","name":"Suit","symbols":["SPADES","HEARTS","DIAMONDS","CLUBS"]}},
+		{"name":"color","type":{"type":"enum","name":"Color","symbols":["RED","BLACK"]}},
+		{"name":"generic","type":"Suit"},
+		{"name":"rank","type":{"type":"enum","name":"Rank","symbols":["ACE","KING"]}}]}`)
+	assert(t, err, nil)
+
+	generic := NewGenericEnum([]string{"SPADES", "HEARTS", "DIAMONDS", "CLUBS"})
+	generic.Set("CLUBS")
+	in := &Cards{Suit: 2, Color: "BLACK", Generic: generic, Rank: 1}
+
+	w := NewSpecificDatumWriter()
+	w.SetSchema(sch)
+	buffer := &bytes.Buffer{}
+	assert(t, w.Write(in, NewBinaryEncoder(buffer)), nil)
+	assert(t, buffer.Bytes(), []byte{4, 2, 6, 2})
+
+	r := NewSpecificDatumReader()
+	r.SetSchema(sch)
+	out := &Cards{}
+	assert(t, r.Read(out, NewBinaryDecoder(buffer.Bytes())), nil)
+	assert(t, out.Suit, in.Suit)
+	assert(t, out.Color, in.Color)
+	assert(t, out.Generic.Get(), "CLUBS")
+	assert(t, out.Rank, in.Rank)
+
+	// a nil *GenericEnum is not a valid enum value
+	for _, invalid := range []*Cards{{Suit: 4, Color: "RED", Generic: generic}, {Color: "GREEN", Generic: generic}, {Color: "RED"}} {
+		assert(t, w.Write(invalid, NewBinaryEncoder(&bytes.Buffer{})) != nil, true)
+	}
+	assert(t, sch.(*RecordSchema).Fields[2].Type.Validate(reflect.ValueOf((*GenericEnum)(nil))), false)
+}
+
 func TestGenericDatumWriterEmptyMap(t *testing.T) {
 	sch, err := ParseSchema(`{
     "type": "record",
@@ -505,3 +550,81 @@ var _Employee_schema, _Employee_schema_err = ParseSchema(`{
         }
     ]
 }`)
+
+func TestDatumWriterErrors(t *testing.T) {
+	schema := MustParseSchema(orderSchemaRaw)
+
+	item := NewGenericRecord(schema.(*RecordSchema).Fields[1].Type.(*ArraySchema).Items)
+	item.Set("price", 1.5)
+	invalidItem := NewGenericRecord(schema.(*RecordSchema).Fields[1].Type.(*ArraySchema).Items)
+	invalidItem.Set("price", "1.5")
+	record := NewGenericRecord(schema)
+	record.Set("id", int64(1))
+	record.Set("items", []interface{}{item, invalidItem})
+	record.Set("tags", map[string]interface{}{})
+
+	writer := NewGenericDatumWriter()
+	writer.SetSchema(schema)
+	err := writer.Write(record, NewBinaryEncoder(&bytes.Buffer{}))
+	assert(t, err.Error(), "Cannot encode Order.items[1].price (double): 1.5 is not a float64")
+
+	record.Set("items", []interface{}{})
+	record.Set("tags", map[string]interface{}{"a": int32(1)})
+	err = writer.Write(record, NewBinaryEncoder(&bytes.Buffer{}))
+	assert(t, err.Error(), `Cannot encode Order.tags["a"] (string): 1 is not a string`)
+
+	// the struct has no tags field
+	type order struct {
+		Id    int64
+		Items []struct{ Price float64 }
+	}
+	specificWriter := NewSpecificDatumWriter()
+	specificWriter.SetSchema(schema)
+	err = specificWriter.Write(&order{}, NewBinaryEncoder(&bytes.Buffer{}))
+	assert(t, err, &EncodeError{Path: "Order.tags", Type: Map, Err: FieldDoesNotExist})
+	if !errors.Is(err, FieldDoesNotExist) {
+		t.Fatalf("Expected error to wrap FieldDoesNotExist, got %v", err)
+	}
+}
+
+func TestGenericDatumWriterFixed(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 4}},
+		{"name": "next", "type": "int"}
+	]}`)
+	writer := NewGenericDatumWriter()
+	writer.SetSchema(schema)
+
+	record := NewGenericRecord(schema)
+	record.Set("hash", []byte{1, 2, 3, 4})
+	record.Set("next", int32(1))
+
+	// fixed values are written as raw bytes without a length prefix
+	buf := &bytes.Buffer{}
+	assert(t, writer.Write(record, NewBinaryEncoder(buf)), nil)
+	assert(t, buf.Bytes(), []byte{1, 2, 3, 4, 2})
+
+	reader := NewGenericDatumReader()
+	reader.SetSchema(schema)
+	var decoded GenericRecord
+	assert(t, reader.Read(&decoded, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, decoded.Get("hash"), []byte{1, 2, 3, 4})
+	assert(t, decoded.Get("next"), int32(1))
+
+	record.Set("hash", []byte{1, 2, 3})
+	err := writer.Write(record, NewBinaryEncoder(&bytes.Buffer{}))
+	assert(t, err.Error(), "Cannot encode Rec.hash (fixed): [1 2 3] is not a fixed of size 4")
+}
+
+func TestGenericDatumWriterNilEnum(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"]}}
+	]}`)
+	writer := NewGenericDatumWriter()
+	writer.SetSchema(schema)
+
+	record := NewGenericRecord(schema)
+	record.Set("kind", (*GenericEnum)(nil))
+	err := writer.Write(record, NewBinaryEncoder(&bytes.Buffer{}))
+	assert(t, err, &EncodeError{Path: "Rec.kind", Type: Enum, Err: InvalidEnumValue})
+}
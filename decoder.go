@@ -61,6 +61,24 @@ type Decoder interface {
 	// Returns an error if it occurs.
 	ReadFixedWithBounds([]byte, int, int) error
 
+	// Skips a string value. Returns an error if it occurs.
+	SkipString() error
+
+	// Skips a bytes value. Returns an error if it occurs.
+	SkipBytes() error
+
+	// Skips a fixed sized binary object of a given size. Returns an error if it occurs.
+	SkipFixed(int) error
+
+	// Skips array blocks which sizes in bytes are known and returns the number of items in the first block that has
+	// to be skipped item by item. The caller should skip the indicated number of items and call SkipArray() again
+	// until it returns 0. Returns an error if it occurs.
+	SkipArray() (int64, error)
+
+	// Skips map blocks which sizes in bytes are known. Usage is similar to SkipArray(). Returns the number of entries
+	// in the first block that has to be skipped entry by entry and an error if it occurs.
+	SkipMap() (int64, error)
+
 	// SetBlock is used for Avro Object Container Files where the data is split in blocks and sets a data block
 	// for this decoder and sets the position to the start of this block.
 	SetBlock(*DataBlock)
@@ -87,6 +105,155 @@ type DataBlock struct {
 	BlockRemaining int64
 }
 
+// DecoderOptions limits the sizes and nesting of decoded values to protect against corrupted or malicious input that
+// would make a decoder allocate huge buffers or a datum reader recurse too deep. Zero values mean no limit.
+// Exceeded limits are reported with LimitError.
+type DecoderOptions struct {
+	// Maximum length of a bytes value.
+	MaxBytesLength int64
+
+	// Maximum length of a string value in bytes.
+	MaxStringLength int64
+
+	// Maximum number of items in a single array or map block.
+	MaxBlockCount int64
+
+	// Maximum number of items in an array or map across all of its blocks.
+	MaxCollectionSize int64
+
+	// Maximum nesting depth of records, arrays and maps read by datum readers.
+	MaxDepth int
+
+	// Maximum size of a decompressed data block of an object container file read by DataFileReader.
+	// Unlike other limits, it defaults to math.MaxInt32 bytes, the largest block DataFileReader accepts.
+	MaxBlockSize int64
+
+	// Maximum size of an RPC message across all of its frames read by Responder.
+	MaxMessageSize int64
+}
+
+// checkLimit returns a LimitError if a given value exceeds a given maximum that is not zero.
+func checkLimit(limit string, value int64, max int64) error {
+	if max > 0 && value > max {
+		return &LimitError{Limit: limit, Value: value, Max: max}
+	}
+	return nil
+}
+
+// decoderLimits enforces DecoderOptions that span several values: the sizes of collections are tracked by decoders
+// as their blocks are read and the nesting depth is tracked by datum readers with enter and leave.
+type decoderLimits struct {
+	options DecoderOptions
+	depth   int
+
+	// numbers of items read so far by collections being read, innermost last
+	collections []int64
+}
+
+// limiter is implemented by decoders that enforce DecoderOptions.
+type limiter interface {
+	enter() error
+	leave()
+	reset()
+	startCollection(count int64) error
+	nextBlock(count int64) error
+}
+
+func (l *decoderLimits) enter() error {
+	if l.options.MaxDepth > 0 && l.depth >= l.options.MaxDepth {
+		return &LimitError{Limit: "depth", Value: int64(l.depth + 1), Max: int64(l.options.MaxDepth)}
+	}
+	l.depth++
+	return nil
+}
+
+func (l *decoderLimits) leave() {
+	l.depth--
+}
+
+// startCollection checks the item count of the first block of an array or map.
+func (l *decoderLimits) startCollection(count int64) error {
+	if l.options.MaxCollectionSize > 0 {
+		l.collections = append(l.collections, 0)
+	}
+	return l.nextBlock(count)
+}
+
+// nextBlock checks the item count of an array or map block, a zero count ends the collection.
+func (l *decoderLimits) nextBlock(count int64) error {
+	if err := checkLimit("block count", count, l.options.MaxBlockCount); err != nil {
+		return err
+	}
+	if len(l.collections) == 0 {
+		return nil
+	}
+
+	last := len(l.collections) - 1
+	if count == 0 {
+		l.collections = l.collections[:last]
+		return nil
+	}
+	l.collections[last] += count
+	return checkLimit("collection size", l.collections[last], l.options.MaxCollectionSize)
+}
+
+// reset forgets collections that were not finished, e.g. due to a read error.
+func (l *decoderLimits) reset() {
+	l.depth = 0
+	l.collections = l.collections[:0]
+}
+
+// resetLimits forgets values a given Decoder was reading if it enforces DecoderOptions. Datum readers call it before
+// reading each top-level value, so that a Decoder reused after a failed read does not keep counting the failed one.
+func resetLimits(dec Decoder) {
+	if l, ok := dec.(limiter); ok {
+		l.reset()
+	}
+}
+
+// enterValue increases the nesting depth of values read from a given Decoder if it enforces DecoderOptions.
+// Returns a LimitError if the maximum depth is exceeded, leaveValue should be called otherwise once the value is read.
+func enterValue(dec Decoder) error {
+	if l, ok := dec.(limiter); ok {
+		return l.enter()
+	}
+	return nil
+}
+
+// leaveValue decreases the nesting depth of values read from a given Decoder.
+func leaveValue(dec Decoder) {
+	if l, ok := dec.(limiter); ok {
+		l.leave()
+	}
+}
+
+// skippedBlock checks the item count of an array or map block that is skipped item by item if a given Decoder enforces
+// DecoderOptions. Decoders cannot tell the first block of a skipped collection from the following ones, so the caller
+// that skips items does.
+func skippedBlock(dec Decoder, count int64, first bool) error {
+	l, ok := dec.(limiter)
+	switch {
+	case !ok:
+		return nil
+	case first:
+		return l.startCollection(count)
+	default:
+		return l.nextBlock(count)
+	}
+}
+
+// maxPreallocatedItems limits the number of array items allocated at once, so that a corrupted block count does not
+// make datum readers allocate more than the data that is actually there.
+const maxPreallocatedItems = 1024
+
+// preallocatedItems returns the number of items to allocate for an array block with a given item count.
+func preallocatedItems(count int64) int64 {
+	if count > maxPreallocatedItems {
+		return maxPreallocatedItems
+	}
+	return count
+}
+
 var maxIntBufSize = 5
 var maxLongBufSize = 10
 
@@ -94,11 +261,18 @@ var maxLongBufSize = 10
 type BinaryDecoder struct {
 	buf []byte
 	pos int64
+	decoderLimits
 }
 
 // NewBinaryDecoder creates a new BinaryDecoder to read from a given buffer.
 func NewBinaryDecoder(buf []byte) *BinaryDecoder {
-	return &BinaryDecoder{buf, 0}
+	return &BinaryDecoder{buf: buf}
+}
+
+// NewBinaryDecoderWithOptions creates a new BinaryDecoder to read from a given buffer that limits the sizes of read
+// values with given options.
+func NewBinaryDecoderWithOptions(buf []byte, options DecoderOptions) *BinaryDecoder {
+	return &BinaryDecoder{buf: buf, decoderLimits: decoderLimits{options: options}}
 }
 
 // ReadNull reads a null value. Returns a decoded value and an error if it occurs.
@@ -173,6 +347,9 @@ func (bd *BinaryDecoder) ReadString() (string, error) {
 	if err != nil || length < 0 {
 		return "", InvalidStringLength
 	}
+	if err := checkLimit("string length", length, bd.options.MaxStringLength); err != nil {
+		return "", err
+	}
 	if err := checkEOF(bd.buf, bd.pos, int(length)); err != nil {
 		return "", err
 	}
@@ -183,6 +360,9 @@ func (bd *BinaryDecoder) ReadString() (string, error) {
 
 // ReadBoolean reads a boolean value. Returns a decoded value and an error if it occurs.
 func (bd *BinaryDecoder) ReadBoolean() (bool, error) {
+	if err := checkEOF(bd.buf, bd.pos, 1); err != nil {
+		return false, err
+	}
 	b := bd.buf[bd.pos] & 0xFF
 	bd.pos++
 	var err error
@@ -205,6 +385,9 @@ func (bd *BinaryDecoder) ReadBytes() ([]byte, error) {
 	if length < 0 {
 		return nil, NegativeBytesLength
 	}
+	if err := checkLimit("bytes length", length, bd.options.MaxBytesLength); err != nil {
+		return nil, err
+	}
 	if err = checkEOF(bd.buf, bd.pos, int(length)); err != nil {
 		return nil, EOF
 	}
@@ -248,26 +431,42 @@ func (bd *BinaryDecoder) ReadEnum() (int32, error) {
 // should read the indicated number of items and then call ArrayNext() to find out the number of items in the
 // next block. Returns a decoded value and an error if it occurs.
 func (bd *BinaryDecoder) ReadArrayStart() (int64, error) {
-	return bd.readItemCount()
+	count, err := bd.readItemCount()
+	if err != nil {
+		return 0, err
+	}
+	return count, bd.startCollection(count)
 }
 
 // ArrayNext processes the next block of an array and returns the number of items in the block.
 // Returns a decoded value and an error if it occurs.
 func (bd *BinaryDecoder) ArrayNext() (int64, error) {
-	return bd.readItemCount()
+	count, err := bd.readItemCount()
+	if err != nil {
+		return 0, err
+	}
+	return count, bd.nextBlock(count)
 }
 
 // ReadMapStart reads and returns the size of the first block of map entries. If call to this return non-zero, then the caller
 // should read the indicated number of items and then call MapNext() to find out the number of items in the
 // next block. Usage is similar to ReadArrayStart(). Returns a decoded value and an error if it occurs.
 func (bd *BinaryDecoder) ReadMapStart() (int64, error) {
-	return bd.readItemCount()
+	count, err := bd.readItemCount()
+	if err != nil {
+		return 0, err
+	}
+	return count, bd.startCollection(count)
 }
 
 // MapNext processes the next block of map entries and returns the number of items in the block.
 // Returns a decoded value and an error if it occurs.
 func (bd *BinaryDecoder) MapNext() (int64, error) {
-	return bd.readItemCount()
+	count, err := bd.readItemCount()
+	if err != nil {
+		return 0, err
+	}
+	return count, bd.nextBlock(count)
 }
 
 // ReadFixed reads fixed sized binary object into the provided buffer.
@@ -283,11 +482,57 @@ func (bd *BinaryDecoder) ReadFixedWithBounds(bytes []byte, start int, length int
 	return bd.readBytes(bytes, start, length)
 }
 
+// SkipString skips a string value. Returns an error if it occurs.
+func (bd *BinaryDecoder) SkipString() error {
+	length, err := bd.ReadLong()
+	if err != nil {
+		return err
+	}
+	if length < 0 {
+		return InvalidStringLength
+	}
+	return bd.skip(length)
+}
+
+// SkipBytes skips a bytes value. Returns an error if it occurs.
+func (bd *BinaryDecoder) SkipBytes() error {
+	length, err := bd.ReadLong()
+	if err != nil {
+		return err
+	}
+	if length < 0 {
+		return NegativeBytesLength
+	}
+	return bd.skip(length)
+}
+
+// SkipFixed skips a fixed sized binary object of a given size. Returns an error if it occurs.
+func (bd *BinaryDecoder) SkipFixed(length int) error {
+	if length < 0 {
+		return NegativeBytesLength
+	}
+	return bd.skip(int64(length))
+}
+
+// SkipArray skips array blocks which sizes in bytes are known and returns the number of items in the first block that
+// has to be skipped item by item. The caller should skip the indicated number of items and call SkipArray() again
+// until it returns 0. Returns an error if it occurs.
+func (bd *BinaryDecoder) SkipArray() (int64, error) {
+	return bd.skipBlocks()
+}
+
+// SkipMap skips map blocks which sizes in bytes are known. Usage is similar to SkipArray(). Returns the number of
+// entries in the first block that has to be skipped entry by entry and an error if it occurs.
+func (bd *BinaryDecoder) SkipMap() (int64, error) {
+	return bd.skipBlocks()
+}
+
 // SetBlock is used for Avro Object Container Files where the data is split in blocks and sets a data block
 // for this decoder and sets the position to the start of this block.
 func (bd *BinaryDecoder) SetBlock(block *DataBlock) {
 	bd.buf = block.Data
 	bd.Seek(0)
+	bd.reset()
 }
 
 // Seek sets the reading position of this Decoder to a given value allowing to skip items etc.
@@ -301,7 +546,7 @@ func (bd *BinaryDecoder) Tell() int64 {
 }
 
 func checkEOF(buf []byte, pos int64, length int) error {
-	if int64(len(buf)) < pos+int64(length) {
+	if length < 0 || int64(len(buf))-pos < int64(length) {
 		return EOF
 	}
 	return nil
@@ -335,3 +580,37 @@ func (bd *BinaryDecoder) readBytes(bytes []byte, start int, length int) error {
 
 	return nil
 }
+
+func (bd *BinaryDecoder) skip(length int64) error {
+	if err := checkEOF(bd.buf, bd.pos, int(length)); err != nil {
+		return EOF
+	}
+	bd.pos += length
+	return nil
+}
+
+// skipBlocks skips array or map blocks that are prefixed with their sizes in bytes, i.e. have negative item counts.
+// Returns the item count of the first block without the size, that the caller checks against DecoderOptions as it
+// knows whether the block starts a collection.
+func (bd *BinaryDecoder) skipBlocks() (int64, error) {
+	for {
+		count, err := bd.ReadLong()
+		if err != nil || count >= 0 {
+			return count, err
+		}
+		if err := checkLimit("block count", -count, bd.options.MaxBlockCount); err != nil {
+			return 0, err
+		}
+
+		size, err := bd.ReadLong()
+		if err != nil {
+			return 0, err
+		}
+		if size < 0 {
+			return 0, NegativeBytesLength
+		}
+		if err := bd.skip(size); err != nil {
+			return 0, err
+		}
+	}
+}
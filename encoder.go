@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"io"
 	"math"
+	"sync"
 )
 
 // Encoder is an interface that provides low-level support for serializing Avro values.
@@ -54,8 +55,16 @@ type Encoder interface {
 }
 
 // BinaryEncoder implements Encoder and provides low-level support for serializing Avro values.
+// BinaryEncoder does not buffer output: every value is written to the underlying io.Writer as soon as it is encoded,
+// so small writes should be batched by the io.Writer itself, e.g. a bytes.Buffer or a bufio.Writer.
+// Errors are sticky: once a write to the underlying io.Writer fails, all following writes are ignored and the error
+// is available via Err and Flush.
 type BinaryEncoder struct {
 	buffer io.Writer
+	err    error
+
+	// scratch space for varints and floats so that encoding them does not allocate
+	scratch [binary.MaxVarintLen64]byte
 }
 
 // NewBinaryEncoder creates a new BinaryEncoder that will write to a given io.Writer.
@@ -63,6 +72,53 @@ func NewBinaryEncoder(buffer io.Writer) *BinaryEncoder {
 	return &BinaryEncoder{buffer: buffer}
 }
 
+var binaryEncoderPool = sync.Pool{
+	New: func() interface{} {
+		return new(BinaryEncoder)
+	},
+}
+
+// AcquireBinaryEncoder returns a BinaryEncoder that will write to a given io.Writer from a pool of encoders.
+// It is meant for hot paths like per-message serialization, the encoder should be returned to the pool with
+// ReleaseBinaryEncoder once it is not used anymore.
+func AcquireBinaryEncoder(buffer io.Writer) *BinaryEncoder {
+	be := binaryEncoderPool.Get().(*BinaryEncoder)
+	be.Reset(buffer)
+	return be
+}
+
+// ReleaseBinaryEncoder returns a BinaryEncoder acquired with AcquireBinaryEncoder to the pool. The encoder must not be
+// used after that.
+func ReleaseBinaryEncoder(be *BinaryEncoder) {
+	be.Reset(nil)
+	binaryEncoderPool.Put(be)
+}
+
+// Reset makes this BinaryEncoder write to a given io.Writer and clears its error.
+func (be *BinaryEncoder) Reset(buffer io.Writer) {
+	be.buffer = buffer
+	be.err = nil
+}
+
+// Err returns the first error that occurred while writing or nil if there was none.
+func (be *BinaryEncoder) Err() error {
+	return be.err
+}
+
+// Flush flushes the underlying io.Writer if it has a Flush method, e.g. a bufio.Writer, and returns the first error
+// that occurred while writing or flushing. BinaryEncoder keeps no data of its own to flush.
+func (be *BinaryEncoder) Flush() error {
+	if be.err != nil {
+		return be.err
+	}
+	if flusher, ok := be.buffer.(interface {
+		Flush() error
+	}); ok {
+		be.err = flusher.Flush()
+	}
+	return be.err
+}
+
 // WriteNull writes a null value. Doesn't actually do anything in this implementation.
 func (be *BinaryEncoder) WriteNull(_ interface{}) {
 	//do nothing
@@ -71,51 +127,55 @@ func (be *BinaryEncoder) WriteNull(_ interface{}) {
 // WriteBoolean writes a boolean value.
 func (be *BinaryEncoder) WriteBoolean(x bool) {
 	if x {
-		_, _ = be.buffer.Write([]byte{0x01})
+		be.scratch[0] = 0x01
 	} else {
-		_, _ = be.buffer.Write([]byte{0x00})
+		be.scratch[0] = 0x00
 	}
+	be.write(be.scratch[:1])
 }
 
 // WriteInt writes an int value.
 func (be *BinaryEncoder) WriteInt(x int32) {
-	_, _ = be.buffer.Write(be.encodeVarint32(x))
+	be.write(be.encodeVarint32(x))
 }
 
 // WriteLong writes a long value.
 func (be *BinaryEncoder) WriteLong(x int64) {
-	_, _ = be.buffer.Write(be.encodeVarint64(x))
+	be.write(be.encodeVarint64(x))
 }
 
 // WriteFloat writes a float value.
 func (be *BinaryEncoder) WriteFloat(x float32) {
-	bytes := make([]byte, 4)
-	binary.LittleEndian.PutUint32(bytes, math.Float32bits(x))
-	_, _ = be.buffer.Write(bytes)
+	binary.LittleEndian.PutUint32(be.scratch[:4], math.Float32bits(x))
+	be.write(be.scratch[:4])
 }
 
 // WriteDouble writes a double value.
 func (be *BinaryEncoder) WriteDouble(x float64) {
-	bytes := make([]byte, 8)
-	binary.LittleEndian.PutUint64(bytes, math.Float64bits(x))
-	_, _ = be.buffer.Write(bytes)
+	binary.LittleEndian.PutUint64(be.scratch[:8], math.Float64bits(x))
+	be.write(be.scratch[:8])
 }
 
 // WriteRaw writes raw bytes to this Encoder.
 func (be *BinaryEncoder) WriteRaw(x []byte) {
-	_, _ = be.buffer.Write(x)
+	be.write(x)
 }
 
 // WriteBytes writes a bytes value.
 func (be *BinaryEncoder) WriteBytes(x []byte) {
 	be.WriteLong(int64(len(x)))
-	_, _ = be.buffer.Write(x)
+	be.write(x)
 }
 
 // WriteString writes a string value.
 func (be *BinaryEncoder) WriteString(x string) {
 	be.WriteLong(int64(len(x)))
-	_, _ = be.buffer.Write([]byte(x))
+	if be.err != nil {
+		return
+	}
+	if _, err := io.WriteString(be.buffer, x); err != nil {
+		be.err = err
+	}
 }
 
 // WriteArrayStart should be called when starting to serialize an array providing it with a number of items in
@@ -146,36 +206,53 @@ func (be *BinaryEncoder) writeItemCount(count int64) {
 	be.WriteLong(count)
 }
 
+func (be *BinaryEncoder) write(x []byte) {
+	if be.err != nil {
+		return
+	}
+	if _, err := be.buffer.Write(x); err != nil {
+		be.err = err
+	}
+}
+
 func (be *BinaryEncoder) encodeVarint32(n int32) []byte {
-	var buf [5]byte
 	ux := uint32(n) << 1
 	if n < 0 {
 		ux = ^ux
 	}
 	i := 0
 	for ux >= 0x80 {
-		buf[i] = byte(ux) | 0x80
+		be.scratch[i] = byte(ux) | 0x80
 		ux >>= 7
 		i++
 	}
-	buf[i] = byte(ux)
+	be.scratch[i] = byte(ux)
 
-	return buf[0 : i+1]
+	return be.scratch[0 : i+1]
 }
 
 func (be *BinaryEncoder) encodeVarint64(x int64) []byte {
-	var buf [10]byte
 	ux := uint64(x) << 1
 	if x < 0 {
 		ux = ^ux
 	}
 	i := 0
 	for ux >= 0x80 {
-		buf[i] = byte(ux) | 0x80
+		be.scratch[i] = byte(ux) | 0x80
 		ux >>= 7
 		i++
 	}
-	buf[i] = byte(ux)
+	be.scratch[i] = byte(ux)
 
-	return buf[0 : i+1]
+	return be.scratch[0 : i+1]
+}
+
+// encoderErr returns the sticky error of a given Encoder if it keeps one as BinaryEncoder and JSONEncoder do.
+func encoderErr(enc Encoder) error {
+	if errEncoder, ok := enc.(interface {
+		Err() error
+	}); ok {
+		return errEncoder.Err()
+	}
+	return nil
 }
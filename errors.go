@@ -1,6 +1,10 @@
 package avro
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
 
 // Signals that an end of file or stream has been reached unexpectedly.
 var EOF = errors.New("End of file reached")
@@ -55,3 +59,152 @@ var SchemaNotSet = errors.New("Schema not set")
 
 // FieldDoesNotExist happens when a struct does not have a necessary field.
 var FieldDoesNotExist = errors.New("Field does not exist")
+
+// NotSingleObject happens when a value to decode does not start with the Avro single-object encoding marker.
+var NotSingleObject = errors.New("Not a single-object encoded value")
+
+// NotConfluentMessage happens when a value to decode does not start with the Confluent wire format magic byte.
+var NotConfluentMessage = errors.New("Not a Confluent wire format message")
+
+// InvalidUnionValue happens when a value to encode does not match any union branch.
+var InvalidUnionValue = errors.New("Invalid union value")
+
+// InvalidEnumValue happens when a value of a generated enum type is not within the range of its symbols.
+var InvalidEnumValue = errors.New("Invalid enum value")
+
+// LimitError happens when a decoded length or count exceeds a maximum set with DecoderOptions.
+type LimitError struct {
+	// Name of the exceeded limit, e.g. "bytes length".
+	Limit string
+
+	// Decoded value and the maximum it exceeds.
+	Value int64
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("Max %s exceeded: %d > %d", e.Limit, e.Value, e.Max)
+}
+
+// DecodeError happens when a datum reader cannot read a value. It tells which value could not be read and wraps the
+// underlying error, so that errors.Is and errors.As may be used with the errors above.
+type DecodeError struct {
+	// Path of the value within the datum, e.g. "order.items[3].price". Empty for top-level values other than records.
+	Path string
+
+	// Type of the schema of the value, e.g. Double.
+	Type int
+
+	// Position of the Decoder when the error occurred.
+	Offset int64
+
+	// Underlying error.
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("Cannot decode %s at offset %d: %s", describeValue(e.Path, e.Type), e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// EncodeError happens when a datum writer cannot write a value. It tells which value could not be written and wraps
+// the underlying error, so that errors.Is and errors.As may be used with the errors above.
+type EncodeError struct {
+	// Path of the value within the datum, e.g. "order.items[3].price". Empty for top-level values other than records.
+	Path string
+
+	// Type of the schema of the value, e.g. Double.
+	Type int
+
+	// Underlying error.
+	Err error
+}
+
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("Cannot encode %s: %s", describeValue(e.Path, e.Type), e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeError annotates an error of reading a value of a given schema with an element of the value path, e.g. a field
+// name or an array index. The first call creates a DecodeError at the current position of a given Decoder, the
+// following ones prepend their elements to its path.
+func decodeError(err error, element string, schema Schema, dec Decoder) error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(*DecodeError); ok {
+		e.Path = joinPath(element, e.Path)
+		return e
+	}
+	return &DecodeError{Path: element, Type: publicType(schema), Offset: dec.Tell(), Err: err}
+}
+
+// encodeError annotates an error of writing a value of a given schema with an element of the value path the same way
+// decodeError does.
+func encodeError(err error, element string, schema Schema) error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(*EncodeError); ok {
+		e.Path = joinPath(element, e.Path)
+		return e
+	}
+	return &EncodeError{Path: element, Type: publicType(schema), Err: err}
+}
+
+// fastPathSchema returns the schema of a value read or written by its Reader or Writer implementation, i.e. a given
+// datum schema or the schema of the value itself if the datum schema is not set.
+func fastPathSchema(schema Schema, v interface{}) Schema {
+	if schema == nil {
+		if record, ok := v.(AvroRecord); ok {
+			return record.Schema()
+		}
+	}
+	return schema
+}
+
+// rootElement returns the first element of value paths of a given top-level schema, i.e. the name of a record schema.
+func rootElement(schema Schema) string {
+	if publicType(schema) == Record {
+		return schema.GetName()
+	}
+	return ""
+}
+
+// joinPath prepends a path element, i.e. a field name or an index in square brackets, to a given path.
+func joinPath(element string, path string) string {
+	switch {
+	case element == "":
+		return path
+	case path == "" || path[0] == '[':
+		return element + path
+	default:
+		return element + "." + path
+	}
+}
+
+// indexElement returns a path element of an array item with a given index.
+func indexElement(index int64) string {
+	return "[" + strconv.FormatInt(index, 10) + "]"
+}
+
+// keyElement returns a path element of a map value with a given key.
+func keyElement(key string) string {
+	return "[" + strconv.Quote(key) + "]"
+}
+
+// describeValue describes a value for error messages by its path and schema type, e.g. "order.items[3].price (double)".
+func describeValue(path string, schemaType int) string {
+	if path == "" {
+		return typeName(schemaType)
+	}
+	return path + " (" + typeName(schemaType) + ")"
+}
@@ -46,6 +46,7 @@ func main() {
 		// Should not actually happen
 		panic(err)
 	}
+	defer specificReader.Close()
 
 	for {
 		// Note: should ALWAYS pass in a pointer, e.g. specificReader.Next(SomeComplexType{}) will NOT work
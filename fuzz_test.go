@@ -0,0 +1,108 @@
+//go:build go1.18
+// +build go1.18
+
+package avro
+
+import (
+	"bytes"
+	"testing"
+)
+
+var fuzzSchema = MustParseSchema(`{"type": "record", "name": "Fuzz", "fields": [
+    {"name": "flag", "type": "boolean"},
+    {"name": "number", "type": ["null", "int", "long", "double"]},
+    {"name": "name", "type": "string"},
+    {"name": "data", "type": "bytes"},
+    {"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"]}},
+    {"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 4}},
+    {"name": "tags", "type": {"type": "map", "values": "string"}},
+    {"name": "children", "type": {"type": "array", "items": "Fuzz"}}
+]}`)
+
+// fuzzOptions keep decoders from allocating more than fuzzing can afford.
+var fuzzOptions = DecoderOptions{
+	MaxBytesLength:    1 << 16,
+	MaxStringLength:   1 << 16,
+	MaxBlockCount:     1 << 10,
+	MaxCollectionSize: 1 << 12,
+	MaxDepth:          64,
+}
+
+type fuzzRecord struct {
+	Flag     bool
+	Number   interface{}
+	Name     string
+	Data     []byte
+	Kind     string
+	Hash     [4]byte
+	Tags     map[string]string
+	Children []*fuzzRecord
+}
+
+func fuzzSeeds(f *testing.F) {
+	child := NewGenericRecord(fuzzSchema)
+	child.Set("flag", true)
+	child.Set("number", nil)
+	child.Set("name", "child")
+	child.Set("data", []byte{})
+	child.Set("kind", "A")
+	child.Set("hash", []byte{1, 2, 3, 4})
+	child.Set("tags", map[string]interface{}{})
+	child.Set("children", []interface{}{})
+
+	record := NewGenericRecord(fuzzSchema)
+	record.Set("flag", false)
+	record.Set("number", int64(42))
+	record.Set("name", "parent")
+	record.Set("data", []byte{0xff, 0x00})
+	record.Set("kind", "B")
+	record.Set("hash", []byte{5, 6, 7, 8})
+	record.Set("tags", map[string]interface{}{"key": "value"})
+	record.Set("children", []interface{}{child, child})
+
+	writer := NewGenericDatumWriter()
+	writer.SetSchema(fuzzSchema)
+	for _, value := range []*GenericRecord{child, record} {
+		buf := &bytes.Buffer{}
+		if err := writer.Write(value, NewBinaryEncoder(buf)); err != nil {
+			f.Fatal(err)
+		}
+		f.Add(buf.Bytes())
+	}
+
+	// huge lengths and block counts
+	f.Add([]byte{0x00, 0x00, 0xfe, 0xff, 0xff, 0xff, 0x0f})
+	f.Add([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xfd, 0xff, 0xff, 0xff, 0x0f, 0x02})
+	f.Add([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02})
+}
+
+func FuzzGenericDatumReader(f *testing.F) {
+	fuzzSeeds(f)
+	reader := NewGenericDatumReader()
+	reader.SetSchema(fuzzSchema)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var record GenericRecord
+		reader.Read(&record, NewBinaryDecoderWithOptions(data, fuzzOptions))
+		reader.Read(&record, NewStreamDecoderWithOptions(bytes.NewReader(data), fuzzOptions))
+	})
+}
+
+func FuzzSpecificDatumReader(f *testing.F) {
+	fuzzSeeds(f)
+	reader := NewSpecificDatumReader()
+	reader.SetSchema(fuzzSchema)
+	prepared := NewSpecificDatumReader()
+	prepared.SetSchema(Prepare(fuzzSchema))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		reader.Read(&fuzzRecord{}, NewBinaryDecoderWithOptions(data, fuzzOptions))
+		prepared.Read(&fuzzRecord{}, NewStreamDecoderWithOptions(bytes.NewReader(data), fuzzOptions))
+	})
+}
+
+func FuzzSkipValue(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		SkipValue(fuzzSchema, NewBinaryDecoderWithOptions(data, fuzzOptions))
+		SkipValue(fuzzSchema, NewStreamDecoderWithOptions(bytes.NewReader(data), fuzzOptions))
+	})
+}
@@ -0,0 +1,691 @@
+package avro
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+)
+
+const ipcContentType = "avro/binary"
+
+// ipcMaxFrameSize is the maximum size of a single frame written by this implementation.
+const ipcMaxFrameSize = 8192
+
+// maxClientProtocols is the maximum number of client protocols a Responder keeps.
+const maxClientProtocols = 128
+
+// defaultMaxMessageSize is the maximum size of a request a Responder reads unless set otherwise.
+const defaultMaxMessageSize = 64 << 20
+
+const (
+	handshakeMatchBoth   = "BOTH"
+	handshakeMatchClient = "CLIENT"
+	handshakeMatchNone   = "NONE"
+)
+
+var handshakeSchemas = make(map[string]Schema)
+
+var handshakeRequestSchema = mustParseSchemaWithRegistry(`{
+    "type": "record",
+    "name": "HandshakeRequest",
+    "namespace": "org.apache.avro.ipc",
+    "fields": [
+        {"name": "clientHash", "type": {"type": "fixed", "name": "MD5", "size": 16}},
+        {"name": "clientProtocol", "type": ["null", "string"]},
+        {"name": "serverHash", "type": "MD5"},
+        {"name": "meta", "type": ["null", {"type": "map", "values": "bytes"}]}
+    ]
+}`, handshakeSchemas)
+
+var handshakeResponseSchema = mustParseSchemaWithRegistry(`{
+    "type": "record",
+    "name": "HandshakeResponse",
+    "namespace": "org.apache.avro.ipc",
+    "fields": [
+        {"name": "match", "type": {"type": "enum", "name": "HandshakeMatch", "symbols": ["BOTH", "CLIENT", "NONE"]}},
+        {"name": "serverProtocol", "type": ["null", "string"]},
+        {"name": "serverHash", "type": ["null", "MD5"]},
+        {"name": "meta", "type": ["null", {"type": "map", "values": "bytes"}]}
+    ]
+}`, handshakeSchemas)
+
+var callMetaSchema = MustParseSchema(`{"type": "map", "values": "bytes"}`)
+
+// systemErrorsSchema is used to respond to messages that are unknown to the server.
+var systemErrorsSchema = &UnionSchema{Types: []Schema{new(StringSchema)}}
+
+func mustParseSchemaWithRegistry(rawSchema string, schemas map[string]Schema) Schema {
+	s, err := ParseSchemaWithRegistry(rawSchema, schemas)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// RemoteError is an error returned by a remote RPC peer. Value is either a string for system errors or a value of one
+// of the error types declared by the message.
+type RemoteError struct {
+	Value interface{}
+}
+
+// Error returns a human readable representation of this RemoteError.
+func (e *RemoteError) Error() string {
+	if message, ok := e.Value.(string); ok {
+		return message
+	}
+	return fmt.Sprintf("Remote error: %v", e.Value)
+}
+
+// Transceiver transfers framed RPC messages to a remote peer.
+type Transceiver interface {
+	// Transceive sends a request and returns the response.
+	Transceive(request []byte) ([]byte, error)
+
+	// Send sends a request without waiting for a response. Used for one-way messages.
+	Send(request []byte) error
+
+	// Stateful returns true if the handshake is needed only once per Transceiver as opposed to once per request.
+	Stateful() bool
+
+	// Close releases the underlying resources.
+	Close() error
+}
+
+// SocketTransceiver implements Transceiver over a stream connection, e.g. TCP.
+type SocketTransceiver struct {
+	// Maximum size of a response, responses are not limited if zero.
+	MaxMessageSize int64
+
+	conn net.Conn
+	lock sync.Mutex
+}
+
+// NewSocketTransceiver creates a new SocketTransceiver that talks over a given connection.
+func NewSocketTransceiver(conn net.Conn) *SocketTransceiver {
+	return &SocketTransceiver{conn: conn}
+}
+
+// Transceive sends a request and returns the response.
+func (t *SocketTransceiver) Transceive(request []byte) ([]byte, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if err := writeFrames(t.conn, request); err != nil {
+		return nil, err
+	}
+	return readFrames(t.conn, t.MaxMessageSize)
+}
+
+// Send sends a request without waiting for a response.
+func (t *SocketTransceiver) Send(request []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return writeFrames(t.conn, request)
+}
+
+// Stateful returns true as the handshake is done once per connection.
+func (t *SocketTransceiver) Stateful() bool {
+	return true
+}
+
+// Close closes the underlying connection.
+func (t *SocketTransceiver) Close() error {
+	return t.conn.Close()
+}
+
+// HTTPTransceiver implements Transceiver over HTTP sending each request as a POST request.
+type HTTPTransceiver struct {
+	// HTTP client used to send requests, http.DefaultClient by default.
+	HTTPClient *http.Client
+
+	// Maximum size of a response, responses are not limited if zero.
+	MaxMessageSize int64
+
+	url string
+}
+
+// NewHTTPTransceiver creates a new HTTPTransceiver that sends requests to a given URL.
+func NewHTTPTransceiver(url string) *HTTPTransceiver {
+	return &HTTPTransceiver{HTTPClient: http.DefaultClient, url: url}
+}
+
+// Transceive sends a request and returns the response.
+func (t *HTTPTransceiver) Transceive(request []byte) ([]byte, error) {
+	body := &bytes.Buffer{}
+	if err := writeFrames(body, request); err != nil {
+		return nil, err
+	}
+
+	response, err := t.HTTPClient.Post(t.url, ipcContentType, body)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected HTTP status: %s", response.Status)
+	}
+	return readFrames(response.Body, t.MaxMessageSize)
+}
+
+// Send sends a request ignoring the response as HTTP always responds.
+func (t *HTTPTransceiver) Send(request []byte) error {
+	_, err := t.Transceive(request)
+	return err
+}
+
+// Stateful returns false as every HTTP request carries a handshake.
+func (t *HTTPTransceiver) Stateful() bool {
+	return false
+}
+
+// Close does nothing for HTTPTransceiver.
+func (t *HTTPTransceiver) Close() error {
+	return nil
+}
+
+// writeFrames writes a given message as a sequence of length-prefixed frames terminated by an empty frame.
+func writeFrames(output io.Writer, message []byte) error {
+	header := make([]byte, 4)
+	for len(message) > 0 {
+		size := len(message)
+		if size > ipcMaxFrameSize {
+			size = ipcMaxFrameSize
+		}
+		binary.BigEndian.PutUint32(header, uint32(size))
+		if _, err := output.Write(header); err != nil {
+			return err
+		}
+		if _, err := output.Write(message[:size]); err != nil {
+			return err
+		}
+		message = message[size:]
+	}
+
+	binary.BigEndian.PutUint32(header, 0)
+	_, err := output.Write(header)
+	return err
+}
+
+// readFrames reads a message written with writeFrames. Returns io.EOF if the input ends before the message starts or
+// a LimitError if the message is longer than a given maximum size that is not zero.
+func readFrames(input io.Reader, maxSize int64) ([]byte, error) {
+	message := &bytes.Buffer{}
+	header := make([]byte, 4)
+	for first := true; ; first = false {
+		if _, err := io.ReadFull(input, header); err != nil {
+			if first {
+				return nil, err
+			}
+			return nil, unexpectedEOF(err)
+		}
+
+		size := int64(binary.BigEndian.Uint32(header))
+		if size == 0 {
+			return message.Bytes(), nil
+		}
+		if err := checkLimit("message size", int64(message.Len())+size, maxSize); err != nil {
+			return nil, err
+		}
+		if _, err := io.CopyN(message, input, size); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+}
+
+// readGeneric reads a value written with a given writer schema as a given reader schema with GenericDatumReader.
+func readGeneric(writer Schema, reader Schema, dec Decoder) (interface{}, error) {
+	datumReader := NewGenericDatumReader()
+	datumReader.SetSchema(writer)
+	if writer != reader {
+		datumReader.SetReaderSchema(reader)
+	}
+
+	schema, err := datumReader.schemas.schema()
+	if err != nil {
+		return nil, err
+	}
+	return datumReader.readValue(schema, dec)
+}
+
+func writeGeneric(schema Schema, value interface{}, enc Encoder) error {
+	datumWriter := NewGenericDatumWriter()
+	datumWriter.SetSchema(schema)
+	return datumWriter.Write(value, enc)
+}
+
+// Requestor is an Avro RPC client that sends messages of a local Protocol through a Transceiver. Responses written
+// with a different server protocol are resolved against the local one. It is safe for concurrent use, requests are
+// sent one at a time.
+type Requestor struct {
+	local       *Protocol
+	transceiver Transceiver
+
+	// server protocol, the local one until the server reports otherwise
+	remote     *Protocol
+	remoteHash []byte
+
+	// whether the handshake is complete on a stateful transceiver
+	established bool
+
+	// whether the server asked for the full client protocol
+	sendProtocol bool
+	lock         sync.Mutex
+}
+
+// NewRequestor creates a new Requestor that sends messages of a given Protocol through a given Transceiver.
+func NewRequestor(protocol *Protocol, transceiver Transceiver) *Requestor {
+	return &Requestor{local: protocol, transceiver: transceiver, remote: protocol, remoteHash: protocol.MD5()}
+}
+
+// Request sends a given message with given parameters and returns the response value read with GenericDatumReader.
+// Parameters may be nil for messages without parameters. Returns a *RemoteError if the server responds with an error.
+// One-way messages always return a nil response.
+func (r *Requestor) Request(messageName string, params *GenericRecord) (interface{}, error) {
+	message, ok := r.local.Messages[messageName]
+	if !ok {
+		return nil, fmt.Errorf("Unknown message: %s", messageName)
+	}
+	if params == nil {
+		params = NewGenericRecord(message.Request)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for {
+		handshake := !(r.transceiver.Stateful() && r.established)
+		request, err := r.writeRequest(message, params, handshake)
+		if err != nil {
+			return nil, err
+		}
+
+		if message.OneWay && !handshake {
+			return nil, r.transceiver.Send(request)
+		}
+
+		response, err := r.transceiver.Transceive(request)
+		if err != nil {
+			return nil, err
+		}
+
+		dec := NewBinaryDecoder(response)
+		if handshake {
+			accepted, err := r.readHandshake(dec)
+			if err != nil {
+				return nil, err
+			}
+			if !accepted {
+				continue
+			}
+		}
+
+		return r.readResponse(message, dec)
+	}
+}
+
+func (r *Requestor) writeRequest(message *Message, params *GenericRecord, handshake bool) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	enc := NewBinaryEncoder(buffer)
+	if handshake {
+		request := NewGenericRecord(handshakeRequestSchema)
+		request.Set("clientHash", r.local.MD5())
+		if r.sendProtocol {
+			request.Set("clientProtocol", r.local.String())
+		}
+		request.Set("serverHash", r.remoteHash)
+		if err := writeGeneric(handshakeRequestSchema, request, enc); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeGeneric(callMetaSchema, map[string]interface{}{}, enc); err != nil {
+		return nil, err
+	}
+	enc.WriteString(message.Name)
+	if err := writeGeneric(message.Request, params, enc); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// readHandshake reads the handshake response and returns false if the request should be sent again with
+// the full client protocol.
+func (r *Requestor) readHandshake(dec Decoder) (bool, error) {
+	value, err := readGeneric(handshakeResponseSchema, handshakeResponseSchema, dec)
+	if err != nil {
+		return false, err
+	}
+	response := value.(*GenericRecord)
+
+	if serverProtocol, ok := response.Get("serverProtocol").(string); ok {
+		if r.remote, err = ParseProtocol(serverProtocol); err != nil {
+			return false, err
+		}
+	}
+	if serverHash, ok := response.Get("serverHash").([]byte); ok {
+		r.remoteHash = serverHash
+	}
+
+	switch response.Get("match") {
+	case handshakeMatchBoth, handshakeMatchClient:
+		r.established = true
+		return true, nil
+	case handshakeMatchNone:
+		if r.sendProtocol {
+			return false, errors.New("Server rejected client protocol")
+		}
+		r.sendProtocol = true
+		return false, nil
+	}
+
+	return false, fmt.Errorf("Invalid handshake match: %v", response.Get("match"))
+}
+
+func (r *Requestor) readResponse(message *Message, dec Decoder) (interface{}, error) {
+	remoteMessage, ok := r.remote.Messages[message.Name]
+	if !ok {
+		return nil, fmt.Errorf("Message %s is not supported by server protocol", message.Name)
+	}
+
+	if _, err := readGeneric(callMetaSchema, callMetaSchema, dec); err != nil {
+		return nil, err
+	}
+	isError, err := dec.ReadBoolean()
+	if err != nil {
+		return nil, err
+	}
+
+	if !isError {
+		return readGeneric(remoteMessage.Response, message.Response, dec)
+	}
+
+	value, err := readGeneric(remoteMessage.Errors, message.Errors, dec)
+	if err != nil {
+		return nil, err
+	}
+	return nil, &RemoteError{Value: value}
+}
+
+// MessageHandler handles a single RPC message with given parameters. Returns a response value that GenericDatumWriter
+// is able to write with the message response schema or an error. Return a *RemoteError to respond with one of
+// the declared message errors, other errors are sent as system errors.
+type MessageHandler func(params *GenericRecord) (interface{}, error)
+
+// Responder is an Avro RPC server that handles messages of a local Protocol with registered MessageHandlers.
+// Requests written with a different client protocol are resolved against the local one.
+type Responder struct {
+	// Options limit the size of requests and the values read from them. Requests are limited to 64 MiB by default.
+	Options DecoderOptions
+
+	// ErrorHandler is called with errors there is no caller to return to, e.g. when Serve fails to serve
+	// a connection or ServeHTTP fails to write a response. Such errors are ignored if it is nil.
+	ErrorHandler func(error)
+
+	local    *Protocol
+	handlers map[string]MessageHandler
+
+	// known client protocols by MD5 hash, at most maxClientProtocols besides the local one
+	protocols map[string]*Protocol
+	lock      sync.RWMutex
+}
+
+// rpcSession is the handshake state of a single connection.
+type rpcSession struct {
+	remote      *Protocol
+	established bool
+}
+
+// NewResponder creates a new Responder for a given Protocol.
+func NewResponder(protocol *Protocol) *Responder {
+	return &Responder{
+		Options:   DecoderOptions{MaxMessageSize: defaultMaxMessageSize},
+		local:     protocol,
+		handlers:  make(map[string]MessageHandler),
+		protocols: map[string]*Protocol{string(protocol.MD5()): protocol},
+	}
+}
+
+// Handle registers a MessageHandler for a given message. Returns an error if the protocol has no such message.
+func (r *Responder) Handle(messageName string, handler MessageHandler) error {
+	if _, ok := r.local.Messages[messageName]; !ok {
+		return fmt.Errorf("Unknown message: %s", messageName)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.handlers[messageName] = handler
+	return nil
+}
+
+// Serve accepts connections on a given listener and serves each of them in a separate goroutine, errors of which
+// are passed to ErrorHandler. Returns when accepting fails, e.g. the listener is closed.
+func (r *Responder) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := r.ServeConn(conn); err != nil {
+				r.handleError(err)
+			}
+		}()
+	}
+}
+
+// ServeConn serves requests from a given connection until it is closed by the client.
+// Returns an error if reading or writing fails or a request is malformed. Closes the connection when done.
+func (r *Responder) ServeConn(conn net.Conn) error {
+	defer conn.Close()
+
+	session := &rpcSession{}
+	for {
+		request, err := readFrames(conn, r.Options.MaxMessageSize)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		response, err := r.respond(request, session)
+		if err != nil {
+			return err
+		}
+		if response != nil {
+			if err := writeFrames(conn, response); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ServeHTTP serves a single request sent by HTTPTransceiver.
+func (r *Responder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	request, err := readFrames(req.Body, r.Options.MaxMessageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := r.respond(request, &rpcSession{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", ipcContentType)
+	if err := writeFrames(w, response); err != nil {
+		// the response has already started, so the client can only be left with a malformed one
+		r.handleError(err)
+	}
+}
+
+// handleError passes a given error to ErrorHandler if it is set.
+func (r *Responder) handleError(err error) {
+	if r.ErrorHandler != nil {
+		r.ErrorHandler(err)
+	}
+}
+
+// respond handles a single request and returns the response or nil if there is nothing to respond.
+func (r *Responder) respond(request []byte, session *rpcSession) ([]byte, error) {
+	dec := NewBinaryDecoderWithOptions(request, r.Options)
+	buffer := &bytes.Buffer{}
+	enc := NewBinaryEncoder(buffer)
+
+	established := session.established
+	if !established {
+		remote, err := r.handshake(dec, enc)
+		if err != nil {
+			return nil, err
+		}
+		if remote == nil {
+			return buffer.Bytes(), nil
+		}
+		session.remote = remote
+		session.established = true
+	}
+
+	if _, err := readGeneric(callMetaSchema, callMetaSchema, dec); err != nil {
+		return nil, err
+	}
+	name, err := dec.ReadString()
+	if err != nil {
+		return nil, err
+	}
+
+	message, ok := r.local.Messages[name]
+	remoteMessage, remoteOk := session.remote.Messages[name]
+	if !ok || !remoteOk {
+		return r.writeResponse(buffer, message, nil, fmt.Errorf("Unknown message: %s", name))
+	}
+
+	value, err := readGeneric(remoteMessage.Request, message.Request, dec)
+	if err != nil {
+		return nil, err
+	}
+
+	r.lock.RLock()
+	handler, ok := r.handlers[name]
+	r.lock.RUnlock()
+
+	var response interface{}
+	if ok {
+		response, err = handler(value.(*GenericRecord))
+	} else {
+		err = fmt.Errorf("No handler for message: %s", name)
+	}
+
+	if message.OneWay && established {
+		return nil, nil
+	}
+	return r.writeResponse(buffer, message, response, err)
+}
+
+// handshake reads the handshake request and writes the handshake response. Returns the client protocol or nil if
+// it is unknown. Returns an error if the client protocol does not match its hash.
+func (r *Responder) handshake(dec Decoder, enc Encoder) (*Protocol, error) {
+	value, err := readGeneric(handshakeRequestSchema, handshakeRequestSchema, dec)
+	if err != nil {
+		return nil, err
+	}
+	request := value.(*GenericRecord)
+
+	clientHash, _ := request.Get("clientHash").([]byte)
+	r.lock.RLock()
+	remote := r.protocols[string(clientHash)]
+	r.lock.RUnlock()
+
+	if clientProtocol, ok := request.Get("clientProtocol").(string); remote == nil && ok {
+		if hash := md5.Sum([]byte(clientProtocol)); !bytes.Equal(hash[:], clientHash) {
+			return nil, fmt.Errorf("Client protocol does not match client hash %x", clientHash)
+		}
+		if remote, err = ParseProtocol(clientProtocol); err != nil {
+			return nil, err
+		}
+		r.addProtocol(clientHash, remote)
+	}
+
+	response := NewGenericRecord(handshakeResponseSchema)
+	serverHash, _ := request.Get("serverHash").([]byte)
+	switch {
+	case remote == nil:
+		response.Set("match", handshakeMatchNone)
+	case bytes.Equal(serverHash, r.local.MD5()):
+		response.Set("match", handshakeMatchBoth)
+	default:
+		response.Set("match", handshakeMatchClient)
+	}
+	if response.Get("match") != handshakeMatchBoth {
+		response.Set("serverProtocol", r.local.String())
+		response.Set("serverHash", r.local.MD5())
+	}
+
+	return remote, writeGeneric(handshakeResponseSchema, response, enc)
+}
+
+// addProtocol remembers a client protocol by a given hash. Evicts an arbitrary client protocol if there are too many.
+func (r *Responder) addProtocol(hash []byte, protocol *Protocol) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.protocols) > maxClientProtocols {
+		local := string(r.local.MD5())
+		for key := range r.protocols {
+			if key != local {
+				delete(r.protocols, key)
+				break
+			}
+		}
+	}
+	r.protocols[string(hash)] = protocol
+}
+
+// writeResponse writes the call response or error after the already written handshake in a given buffer.
+// Returns an error if neither the response nor the error can be written.
+func (r *Responder) writeResponse(buffer *bytes.Buffer, message *Message, response interface{}, err error) ([]byte, error) {
+	payload := &bytes.Buffer{}
+	enc := NewBinaryEncoder(payload)
+	if err == nil {
+		enc.WriteBoolean(false)
+		err = writeGeneric(message.Response, response, enc)
+	}
+	if err != nil {
+		payload.Reset()
+		enc.WriteBoolean(true)
+		var errorsSchema Schema = systemErrorsSchema
+		if message != nil {
+			errorsSchema = message.Errors
+		}
+
+		var value interface{} = err.Error()
+		if remoteError, ok := err.(*RemoteError); ok {
+			value = remoteError.Value
+		}
+		if writeGeneric(errorsSchema, value, enc) != nil {
+			payload.Reset()
+			enc.WriteBoolean(true)
+			if err := writeGeneric(errorsSchema, err.Error(), enc); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := writeGeneric(callMetaSchema, map[string]interface{}{}, NewBinaryEncoder(buffer)); err != nil {
+		return nil, err
+	}
+	buffer.Write(payload.Bytes())
+	return buffer.Bytes(), nil
+}
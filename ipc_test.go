@@ -0,0 +1,310 @@
+package avro
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const helloProtocol = `{
+    "protocol": "Hello",
+    "namespace": "com.example",
+    "doc": "Greetings",
+    "types": [
+        {"type": "record", "name": "Greeting", "fields": [{"name": "message", "type": "string"}]},
+        {"type": "error", "name": "Curse", "fields": [{"name": "message", "type": "string"}]}
+    ],
+    "messages": {
+        "hello": {
+            "doc": "Say hello",
+            "request": [{"name": "greeting", "type": "Greeting"}],
+            "response": "Greeting",
+            "errors": ["Curse"]
+        },
+        "add": {
+            "request": [{"name": "a", "type": "int"}, {"name": "b", "type": "int"}],
+            "response": "int"
+        },
+        "ping": {
+            "request": [],
+            "response": "null",
+            "one-way": true
+        }
+    }
+}`
+
+// helloProtocolV2 adds a field with a default value to the response and the request
+const helloProtocolV2 = `{
+    "protocol": "Hello",
+    "namespace": "com.example",
+    "types": [
+        {"type": "record", "name": "Greeting", "fields": [
+            {"name": "message", "type": "string"},
+            {"name": "language", "type": "string", "default": "en"}
+        ]},
+        {"type": "error", "name": "Curse", "fields": [{"name": "message", "type": "string"}]}
+    ],
+    "messages": {
+        "hello": {
+            "request": [{"name": "greeting", "type": "Greeting"}],
+            "response": "Greeting",
+            "errors": ["Curse"]
+        }
+    }
+}`
+
+func TestParseProtocol(t *testing.T) {
+	protocol, err := ParseProtocol(helloProtocol)
+	assert(t, err, nil)
+	assert(t, protocol.Name, "Hello")
+	assert(t, protocol.Namespace, "com.example")
+	assert(t, protocol.Doc, "Greetings")
+	assert(t, len(protocol.Types), 2)
+	assert(t, protocol.MessageNames(), []string{"add", "hello", "ping"})
+
+	hello := protocol.Messages["hello"]
+	assert(t, hello.Doc, "Say hello")
+	assert(t, len(hello.Request.Fields), 1)
+	assert(t, hello.Response.GetName(), "Greeting")
+	assert(t, len(hello.Errors.Types), 2)
+	assert(t, hello.Errors.Types[0].Type(), String)
+	assert(t, hello.OneWay, false)
+	assert(t, protocol.Messages["ping"].OneWay, true)
+	assert(t, len(protocol.MD5()), 16)
+
+	_, err = ParseProtocol(`{"protocol": "Bad", "messages": {"m": {"request": [], "response": "int", "one-way": true}}}`)
+	if err == nil {
+		t.Fatal("Expected an error for one-way message with a response")
+	}
+}
+
+func TestProtocolString(t *testing.T) {
+	// Java hashes the protocol as it is written by Protocol.toString, so the same layout is expected here
+	protocol := MustParseProtocol(helloProtocol)
+	assert(t, protocol.String(), `{"protocol":"Hello","namespace":"com.example","doc":"Greetings",`+
+		`"types":[{"type":"record","name":"Greeting","fields":[{"name":"message","type":"string"}]},`+
+		`{"type":"error","name":"Curse","fields":[{"name":"message","type":"string"}]}],`+
+		`"messages":{"hello":{"doc":"Say hello","request":[{"name":"greeting","type":"Greeting"}],`+
+		`"response":"Greeting","errors":["Curse"]},"add":{"request":[{"name":"a","type":"int"},`+
+		`{"name":"b","type":"int"}],"response":"int"},"ping":{"request":[],"response":"null","one-way":true}}}`)
+	assert(t, fmt.Sprintf("%x", protocol.MD5()), "0d40744d5874d5eb5c6b277a1e3e068b")
+	assert(t, MustParseProtocol(protocol.String()).String(), protocol.String())
+
+	// inline types are defined by the first message that uses them, other names are relative to namespaces
+	protocol = MustParseProtocol(`{"protocol": "P", "namespace": "a", "my-prop": {"b": 1, "a": "<>"}, "messages": {
+        "m": {"request": [{"name": "p", "type": {"type": "enum", "name": "b.E", "symbols": ["X"], "default": "X",
+            "aliases": ["b.F", "c.G"]}, "default": "X", "order": "descending", "aliases": ["q"]}],
+            "response": {"type": "array", "items": {"type": "fixed", "name": "F", "size": 2}}},
+        "n": {"request": [{"name": "p", "type": "b.E", "order": "ascending"}], "response": ["null", "F"]}}}`)
+	assert(t, protocol.String(), `{"protocol":"P","namespace":"a","my-prop":{"a":"<>","b":1},"types":[`+
+		`{"type":"enum","name":"E","namespace":"b","symbols":["X"],"aliases":["F","c.G"],"default":"X"},`+
+		`{"type":"fixed","name":"F","size":2}],"messages":{`+
+		`"m":{"request":[{"name":"p","type":"b.E","default":"X","order":"descending","aliases":["q"]}],`+
+		`"response":{"type":"array","items":"F"}},`+
+		`"n":{"request":[{"name":"p","type":"b.E"}],"response":["null","F"]}}}`)
+}
+
+func newHelloResponder(t *testing.T, rawProtocol string) *Responder {
+	protocol := MustParseProtocol(rawProtocol)
+	responder := NewResponder(protocol)
+	greeting := actualSchema(protocol.Messages["hello"].Response)
+	curse := actualSchema(protocol.Messages["hello"].Errors.Types[1])
+
+	assert(t, responder.Handle("hello", func(params *GenericRecord) (interface{}, error) {
+		message := params.Get("greeting").(*GenericRecord).Get("message").(string)
+		if message == "curse" {
+			err := NewGenericRecord(curse)
+			err.Set("message", "cursed")
+			return nil, &RemoteError{Value: err}
+		}
+		response := NewGenericRecord(greeting)
+		response.Set("message", "hello, "+message)
+		if len(assertRecordSchema(greeting).Fields) > 1 {
+			response.Set("language", params.Get("greeting").(*GenericRecord).Get("language"))
+		}
+		return response, nil
+	}), nil)
+	if _, ok := protocol.Messages["add"]; ok {
+		assert(t, responder.Handle("add", func(params *GenericRecord) (interface{}, error) {
+			return params.Get("a").(int32) + params.Get("b").(int32), nil
+		}), nil)
+		assert(t, responder.Handle("ping", func(params *GenericRecord) (interface{}, error) {
+			return nil, nil
+		}), nil)
+	}
+	return responder
+}
+
+func helloParams(protocol *Protocol, message string) *GenericRecord {
+	greeting := NewGenericRecord(actualSchema(protocol.Messages["hello"].Response))
+	greeting.Set("message", message)
+	params := NewGenericRecord(protocol.Messages["hello"].Request)
+	params.Set("greeting", greeting)
+	return params
+}
+
+func testRequestor(t *testing.T, requestor *Requestor, protocol *Protocol) {
+	response, err := requestor.Request("hello", helloParams(protocol, "world"))
+	assert(t, err, nil)
+	assert(t, response.(*GenericRecord).Get("message"), "hello, world")
+
+	_, err = requestor.Request("hello", helloParams(protocol, "curse"))
+	remoteError, ok := err.(*RemoteError)
+	assert(t, ok, true)
+	assert(t, remoteError.Value.(*GenericRecord).Get("message"), "cursed")
+
+	params := NewGenericRecord(protocol.Messages["add"].Request)
+	params.Set("a", int32(2))
+	params.Set("b", int32(3))
+	response, err = requestor.Request("add", params)
+	assert(t, err, nil)
+	assert(t, response, int32(5))
+
+	response, err = requestor.Request("ping", nil)
+	assert(t, err, nil)
+	assert(t, response, nil)
+
+	response, err = requestor.Request("add", params)
+	assert(t, err, nil)
+	assert(t, response, int32(5))
+}
+
+func TestSocketRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert(t, err, nil)
+	defer listener.Close()
+	go newHelloResponder(t, helloProtocol).Serve(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	assert(t, err, nil)
+	transceiver := NewSocketTransceiver(conn)
+	defer transceiver.Close()
+
+	protocol := MustParseProtocol(helloProtocol)
+	testRequestor(t, NewRequestor(protocol, transceiver), protocol)
+}
+
+func TestHTTPRPC(t *testing.T) {
+	server := httptest.NewServer(newHelloResponder(t, helloProtocol))
+	defer server.Close()
+
+	protocol := MustParseProtocol(helloProtocol)
+	testRequestor(t, NewRequestor(protocol, NewHTTPTransceiver(server.URL)), protocol)
+}
+
+func TestRPCProtocolResolution(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert(t, err, nil)
+	defer listener.Close()
+	go newHelloResponder(t, helloProtocolV2).Serve(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	assert(t, err, nil)
+	transceiver := NewSocketTransceiver(conn)
+	defer transceiver.Close()
+
+	// the server does not know the client protocol, so the client has to send it and the server resolves the request
+	// against its own protocol, then the client resolves the response
+	protocol := MustParseProtocol(helloProtocol)
+	requestor := NewRequestor(protocol, transceiver)
+	response, err := requestor.Request("hello", helloParams(protocol, "world"))
+	assert(t, err, nil)
+	assert(t, response.(*GenericRecord).Get("message"), "hello, world")
+	assert(t, requestor.remote.String(), MustParseProtocol(helloProtocolV2).String())
+
+	params := NewGenericRecord(protocol.Messages["add"].Request)
+	params.Set("a", int32(2))
+	params.Set("b", int32(3))
+	_, err = requestor.Request("add", params)
+	assert(t, err.Error(), "Message add is not supported by server protocol")
+}
+
+func handshakeRequest(t *testing.T, clientHash []byte, clientProtocol string) Decoder {
+	request := NewGenericRecord(handshakeRequestSchema)
+	request.Set("clientHash", clientHash)
+	request.Set("clientProtocol", clientProtocol)
+	request.Set("serverHash", make([]byte, 16))
+
+	buffer := &bytes.Buffer{}
+	assert(t, writeGeneric(handshakeRequestSchema, request, NewBinaryEncoder(buffer)), nil)
+	return NewBinaryDecoder(buffer.Bytes())
+}
+
+func TestResponderHandshake(t *testing.T) {
+	responder := newHelloResponder(t, helloProtocolV2)
+	local := responder.local.MD5()
+
+	// a client protocol is accepted only along with its hash
+	clientHash := MustParseProtocol(helloProtocol).MD5()
+	clientProtocol := MustParseProtocol(helloProtocolV2).String()
+	_, err := responder.handshake(handshakeRequest(t, clientHash, clientProtocol), NewBinaryEncoder(&bytes.Buffer{}))
+	assert(t, err.Error(), fmt.Sprintf("Client protocol does not match client hash %x", clientHash))
+	assert(t, len(responder.protocols), 1)
+
+	// the number of remembered client protocols is limited
+	for i := 0; i < maxClientProtocols*2; i++ {
+		clientProtocol := fmt.Sprintf(`{"protocol": "Client%d"}`, i)
+		hash := md5.Sum([]byte(clientProtocol))
+		remote, err := responder.handshake(handshakeRequest(t, hash[:], clientProtocol), NewBinaryEncoder(&bytes.Buffer{}))
+		assert(t, err, nil)
+		assert(t, remote.Name, fmt.Sprintf("Client%d", i))
+	}
+	assert(t, len(responder.protocols), maxClientProtocols+1)
+	assert(t, responder.protocols[string(local)], responder.local)
+}
+
+func TestReadFramesMaxSize(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	assert(t, writeFrames(buffer, make([]byte, 20000)), nil)
+
+	message, err := readFrames(bytes.NewReader(buffer.Bytes()), 20000)
+	assert(t, err, nil)
+	assert(t, len(message), 20000)
+
+	_, err = readFrames(bytes.NewReader(buffer.Bytes()), 10000)
+	assert(t, err, &LimitError{Limit: "message size", Value: 2 * ipcMaxFrameSize, Max: 10000})
+
+	// a frame is not read if its size alone exceeds the limit
+	_, err = readFrames(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff}), 10000)
+	assert(t, err, &LimitError{Limit: "message size", Value: 0xffffffff, Max: 10000})
+}
+
+type failingResponseWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (failingResponseWriter) Write([]byte) (int, error) {
+	return 0, errors.New("Connection reset")
+}
+
+func TestResponderHTTPErrors(t *testing.T) {
+	protocol := MustParseProtocol(helloProtocol)
+	params := NewGenericRecord(protocol.Messages["add"].Request)
+	params.Set("a", int32(2))
+	params.Set("b", int32(3))
+	request, err := NewRequestor(protocol, nil).writeRequest(protocol.Messages["add"], params, true)
+	assert(t, err, nil)
+	body := &bytes.Buffer{}
+	assert(t, writeFrames(body, request), nil)
+
+	// errors of writing responses go to the error handler
+	responder := newHelloResponder(t, helloProtocol)
+	var handled []error
+	responder.ErrorHandler = func(err error) {
+		handled = append(handled, err)
+	}
+	responder.ServeHTTP(failingResponseWriter{httptest.NewRecorder()}, httptest.NewRequest("POST", "/", bytes.NewReader(body.Bytes())))
+	assert(t, handled, []error{errors.New("Connection reset")})
+
+	// requests larger than the maximum message size are rejected
+	responder.Options.MaxMessageSize = int64(len(request)) - 1
+	recorder := httptest.NewRecorder()
+	responder.ServeHTTP(recorder, httptest.NewRequest("POST", "/", bytes.NewReader(body.Bytes())))
+	assert(t, recorder.Code, http.StatusBadRequest)
+	assert(t, recorder.Body.String(), fmt.Sprintf("Max message size exceeded: %d > %d\n", len(request), len(request)-1))
+}
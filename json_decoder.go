@@ -0,0 +1,535 @@
+package avro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// mapKeySchema marks map keys in JSONDecoder frames.
+var mapKeySchema = &StringSchema{}
+
+// JSONDecoder implements Decoder and deserializes Avro values from Avro JSON encoding
+// (https://avro.apache.org/docs/current/spec.html#json_encoding). As opposed to BinaryDecoder, JSONDecoder needs to
+// know the writer schema of the read data to interpret union type names, enum symbols etc., so calls to it must follow
+// the given schema exactly as DatumReaders do. Record fields may appear in any order and missing fields are filled
+// with their default values. Arrays and maps are always read as a single block.
+type JSONDecoder struct {
+	input  *json.Decoder
+	schema Schema
+	stack  []*jsonDecoderFrame
+}
+
+// jsonDecoderFrame holds values of a record, array, map or union that are not read yet.
+type jsonDecoderFrame struct {
+	schema    Schema
+	namespace string
+	items     []jsonDecoderItem
+	index     int
+}
+
+type jsonDecoderItem struct {
+	schema Schema
+	value  interface{}
+
+	// whether the value comes from a default value of a schema field, union defaults are not wrapped in a type name
+	isDefault bool
+}
+
+// NewJSONDecoder creates a new JSONDecoder that will read values of a given schema from a given io.Reader.
+func NewJSONDecoder(schema Schema, input io.Reader) *JSONDecoder {
+	decoder := json.NewDecoder(input)
+	decoder.UseNumber()
+	return &JSONDecoder{input: decoder, schema: schema}
+}
+
+// ReadNull reads a null value. Returns a decoded value and an error if it occurs.
+func (jd *JSONDecoder) ReadNull() (interface{}, error) {
+	item, err := jd.expect(Null)
+	if err != nil {
+		return nil, err
+	}
+	if item.value != nil {
+		return nil, jd.invalid(item)
+	}
+	return nil, nil
+}
+
+// ReadBoolean reads a boolean value. Returns a decoded value and an error if it occurs.
+func (jd *JSONDecoder) ReadBoolean() (bool, error) {
+	item, err := jd.expect(Boolean)
+	if err != nil {
+		return false, err
+	}
+	value, ok := item.value.(bool)
+	if !ok {
+		return false, jd.invalid(item)
+	}
+	return value, nil
+}
+
+// ReadInt reads an int value. Also reads union branch indexes. Returns a decoded value and an error if it occurs.
+func (jd *JSONDecoder) ReadInt() (int32, error) {
+	value, err := jd.readInteger(Int)
+	if err == nil && (value < math.MinInt32 || value > math.MaxInt32) {
+		return 0, IntOverflow
+	}
+	return int32(value), err
+}
+
+// ReadLong reads a long value. Also reads union branch indexes. Returns a decoded value and an error if it occurs.
+func (jd *JSONDecoder) ReadLong() (int64, error) {
+	return jd.readInteger(Long)
+}
+
+func (jd *JSONDecoder) readInteger(typ int) (int64, error) {
+	item, err := jd.expect(typ, Union)
+	if err != nil {
+		return 0, err
+	}
+	if item.schema.Type() == Union {
+		return jd.readUnion(item)
+	}
+
+	switch value := item.value.(type) {
+	case json.Number:
+		integer, err := value.Int64()
+		if err != nil {
+			return 0, jd.invalid(item)
+		}
+		return integer, nil
+	case float64:
+		if value != math.Trunc(value) {
+			return 0, jd.invalid(item)
+		}
+		return int64(value), nil
+	case int32:
+		// default values of int and long fields are converted when parsing schema
+		return int64(value), nil
+	case int64:
+		return value, nil
+	}
+	return 0, jd.invalid(item)
+}
+
+// readUnion finds the union branch of a given value and prepares its value to be read next.
+func (jd *JSONDecoder) readUnion(item jsonDecoderItem) (int64, error) {
+	types := item.schema.(*UnionSchema).Types
+	if len(types) == 0 {
+		return 0, jd.invalid(item)
+	}
+	if item.isDefault {
+		jd.push(item.schema, jsonDecoderItem{schema: actualSchema(types[0]), value: item.value, isDefault: true})
+		return 0, nil
+	}
+
+	if item.value == nil {
+		for i, branch := range types {
+			if branch.Type() == Null {
+				jd.push(item.schema, jsonDecoderItem{schema: actualSchema(branch)})
+				return int64(i), nil
+			}
+		}
+		return 0, jd.invalid(item)
+	}
+
+	wrapped, ok := item.value.(map[string]interface{})
+	if !ok || len(wrapped) != 1 {
+		return 0, jd.invalid(item)
+	}
+	for name, value := range wrapped {
+		for i, branch := range types {
+			branch = actualSchema(branch)
+			if name == branch.GetName() || name == jd.fullName(branch) {
+				jd.push(item.schema, jsonDecoderItem{schema: branch, value: value})
+				return int64(i), nil
+			}
+		}
+		return 0, fmt.Errorf("Unknown union type %s", name)
+	}
+	return 0, jd.invalid(item)
+}
+
+// ReadFloat reads a float value. Returns a decoded value and an error if it occurs.
+func (jd *JSONDecoder) ReadFloat() (float32, error) {
+	item, err := jd.expect(Float)
+	if err != nil {
+		return 0, err
+	}
+	value, err := jd.readFloat(item, 32)
+	return float32(value), err
+}
+
+// ReadDouble reads a double value. Returns a decoded value and an error if it occurs.
+func (jd *JSONDecoder) ReadDouble() (float64, error) {
+	item, err := jd.expect(Double)
+	if err != nil {
+		return 0, err
+	}
+	return jd.readFloat(item, 64)
+}
+
+func (jd *JSONDecoder) readFloat(item jsonDecoderItem, bitSize int) (float64, error) {
+	switch value := item.value.(type) {
+	case json.Number:
+		number, err := strconv.ParseFloat(string(value), bitSize)
+		if err != nil {
+			return 0, jd.invalid(item)
+		}
+		return number, nil
+	case float64:
+		return value, nil
+	case float32:
+		// default values of float fields are converted when parsing schema
+		return float64(value), nil
+	case string:
+		switch value {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+	}
+	return 0, jd.invalid(item)
+}
+
+// ReadBytes reads a bytes value encoded as a string where each Unicode code point 0-255 represents a byte.
+// Returns a decoded value and an error if it occurs.
+func (jd *JSONDecoder) ReadBytes() ([]byte, error) {
+	item, err := jd.expect(Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return jd.readBytes(item)
+}
+
+func (jd *JSONDecoder) readBytes(item jsonDecoderItem) ([]byte, error) {
+	value, ok := item.value.(string)
+	if !ok {
+		return nil, jd.invalid(item)
+	}
+
+	bytes := make([]byte, 0, len(value))
+	for _, r := range value {
+		if r > 0xFF {
+			return nil, jd.invalid(item)
+		}
+		bytes = append(bytes, byte(r))
+	}
+	return bytes, nil
+}
+
+// ReadString reads a string value. Also reads map keys. Returns a decoded value and an error if it occurs.
+func (jd *JSONDecoder) ReadString() (string, error) {
+	item, err := jd.expect(String)
+	if err != nil {
+		return "", err
+	}
+	value, ok := item.value.(string)
+	if !ok {
+		return "", jd.invalid(item)
+	}
+	return value, nil
+}
+
+// ReadEnum reads an enum symbol and returns its index. Returns a decoded value and an error if it occurs.
+func (jd *JSONDecoder) ReadEnum() (int32, error) {
+	item, err := jd.expect(Enum)
+	if err != nil {
+		return 0, err
+	}
+	value, ok := item.value.(string)
+	if !ok {
+		return 0, jd.invalid(item)
+	}
+
+	for i, symbol := range item.schema.(*EnumSchema).Symbols {
+		if symbol == value {
+			return int32(i), nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown symbol %s for enum %s", value, item.schema.GetName())
+}
+
+// ReadArrayStart reads the number of items of an array. All array items are read in a single block.
+// Returns a decoded value and an error if it occurs.
+func (jd *JSONDecoder) ReadArrayStart() (int64, error) {
+	item, err := jd.expect(Array)
+	if err != nil {
+		return 0, err
+	}
+	values, ok := item.value.([]interface{})
+	if !ok {
+		return 0, jd.invalid(item)
+	}
+
+	if len(values) > 0 {
+		itemSchema := actualSchema(item.schema.(*ArraySchema).Items)
+		items := make([]jsonDecoderItem, len(values))
+		for i, value := range values {
+			items[i] = jsonDecoderItem{schema: itemSchema, value: value, isDefault: item.isDefault}
+		}
+		jd.push(item.schema, items...)
+	}
+	return int64(len(values)), nil
+}
+
+// ArrayNext finishes reading an array. Always returns 0 as all items are read in a single block.
+// Returns an error if not all items were read.
+func (jd *JSONDecoder) ArrayNext() (int64, error) {
+	return 0, jd.finish(Array)
+}
+
+// ReadMapStart reads the number of entries of a map. All map entries are read in a single block ordered by keys.
+// Returns a decoded value and an error if it occurs.
+func (jd *JSONDecoder) ReadMapStart() (int64, error) {
+	item, err := jd.expect(Map)
+	if err != nil {
+		return 0, err
+	}
+	values, ok := item.value.(map[string]interface{})
+	if !ok {
+		return 0, jd.invalid(item)
+	}
+
+	if len(values) > 0 {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		valueSchema := actualSchema(item.schema.(*MapSchema).Values)
+		items := make([]jsonDecoderItem, 0, 2*len(keys))
+		for _, key := range keys {
+			items = append(items, jsonDecoderItem{schema: mapKeySchema, value: key},
+				jsonDecoderItem{schema: valueSchema, value: values[key], isDefault: item.isDefault})
+		}
+		jd.push(item.schema, items...)
+	}
+	return int64(len(values)), nil
+}
+
+// MapNext finishes reading a map. Always returns 0 as all entries are read in a single block.
+// Returns an error if not all entries were read.
+func (jd *JSONDecoder) MapNext() (int64, error) {
+	return 0, jd.finish(Map)
+}
+
+// ReadFixed reads a fixed value encoded as a string where each Unicode code point 0-255 represents a byte into
+// the provided buffer. Returns an error if it occurs.
+func (jd *JSONDecoder) ReadFixed(bytes []byte) error {
+	return jd.ReadFixedWithBounds(bytes, 0, len(bytes))
+}
+
+// ReadFixedWithBounds reads a fixed value into the provided buffer at a given position.
+// Returns an error if it occurs.
+func (jd *JSONDecoder) ReadFixedWithBounds(bytes []byte, start int, length int) error {
+	item, err := jd.expect(Fixed)
+	if err != nil {
+		return err
+	}
+	value, err := jd.readBytes(item)
+	if err != nil {
+		return err
+	}
+	if len(value) != length || len(value) != item.schema.(*FixedSchema).Size {
+		return jd.invalid(item)
+	}
+
+	copy(bytes[start:start+length], value)
+	return nil
+}
+
+// SkipString skips a string value. Returns an error if it occurs.
+func (jd *JSONDecoder) SkipString() error {
+	_, err := jd.expect(String)
+	return err
+}
+
+// SkipBytes skips a bytes value. Returns an error if it occurs.
+func (jd *JSONDecoder) SkipBytes() error {
+	_, err := jd.expect(Bytes)
+	return err
+}
+
+// SkipFixed skips a fixed value. Returns an error if it occurs.
+func (jd *JSONDecoder) SkipFixed(length int) error {
+	_, err := jd.expect(Fixed)
+	return err
+}
+
+// SkipArray skips a whole array and always returns 0. Returns an error if it occurs.
+func (jd *JSONDecoder) SkipArray() (int64, error) {
+	_, err := jd.expect(Array)
+	return 0, err
+}
+
+// SkipMap skips a whole map and always returns 0. Returns an error if it occurs.
+func (jd *JSONDecoder) SkipMap() (int64, error) {
+	_, err := jd.expect(Map)
+	return 0, err
+}
+
+// SetBlock does nothing as JSON encoded data is not split in blocks.
+func (jd *JSONDecoder) SetBlock(block *DataBlock) {}
+
+// Seek does nothing as JSONDecoder reads values sequentially.
+func (jd *JSONDecoder) Seek(pos int64) {}
+
+// Tell always returns 0 as JSONDecoder reads values sequentially.
+func (jd *JSONDecoder) Tell() int64 {
+	return 0
+}
+
+// expect returns the next value according to schema and checks that it is of one of the given types.
+// Records are entered implicitly.
+func (jd *JSONDecoder) expect(types ...int) (jsonDecoderItem, error) {
+	for {
+		item, err := jd.next()
+		if err != nil {
+			return item, err
+		}
+
+		if item.schema == mapKeySchema {
+			if types[0] != String {
+				return item, errors.New("Expected map key")
+			}
+			return item, nil
+		}
+
+		if item.schema.Type() == Record {
+			if err := jd.enterRecord(item); err != nil {
+				return item, err
+			}
+			continue
+		}
+
+		for _, typ := range types {
+			if item.schema.Type() == typ {
+				return item, nil
+			}
+		}
+		return item, fmt.Errorf("Value of type %s does not match schema %s", typeName(types[0]), item.schema.GetName())
+	}
+}
+
+// next returns the next value to read, reading a new JSON value from input if nothing is left.
+func (jd *JSONDecoder) next() (jsonDecoderItem, error) {
+	for top := jd.top(); top != nil && jd.skipEmpty(top) == len(top.items); top = jd.top() {
+		if top.schema.Type() == Array || top.schema.Type() == Map {
+			return jsonDecoderItem{}, fmt.Errorf("Reading past the end of %s", top.schema.GetName())
+		}
+		jd.pop()
+	}
+
+	top := jd.top()
+	if top == nil {
+		var value interface{}
+		if err := jd.input.Decode(&value); err != nil {
+			if err == io.EOF {
+				return jsonDecoderItem{}, EOF
+			}
+			return jsonDecoderItem{}, err
+		}
+		return jsonDecoderItem{schema: actualSchema(jd.schema), value: value}, nil
+	}
+
+	item := top.items[top.index]
+	top.index++
+	return item, nil
+}
+
+// enterRecord prepares fields of a given record value to be read next in schema order.
+func (jd *JSONDecoder) enterRecord(item jsonDecoderItem) error {
+	values, ok := item.value.(map[string]interface{})
+	if !ok {
+		return jd.invalid(item)
+	}
+
+	fields := assertRecordSchema(item.schema).Fields
+	items := make([]jsonDecoderItem, len(fields))
+	for i, field := range fields {
+		if value, exists := values[field.Name]; exists {
+			items[i] = jsonDecoderItem{schema: actualSchema(field.Type), value: value, isDefault: item.isDefault}
+		} else if hasDefault(field) {
+			items[i] = jsonDecoderItem{schema: actualSchema(field.Type), value: field.Default, isDefault: true}
+		} else {
+			return fmt.Errorf("Missing field %s of record %s", field.Name, item.schema.GetName())
+		}
+	}
+
+	namespace := namespaceOfFullName(jd.fullName(item.schema))
+	jd.stack = append(jd.stack, &jsonDecoderFrame{schema: item.schema, namespace: namespace, items: items})
+	return nil
+}
+
+// finish checks that all items of the current array or map were read and leaves it.
+func (jd *JSONDecoder) finish(typ int) error {
+	for top := jd.top(); top != nil && jd.skipEmpty(top) == len(top.items); top = jd.top() {
+		if top.schema.Type() == typ {
+			jd.pop()
+			return nil
+		}
+		if top.schema.Type() == Array || top.schema.Type() == Map {
+			break
+		}
+		jd.pop()
+	}
+	return fmt.Errorf("Unexpected end of %s", typeName(typ))
+}
+
+// skipEmpty skips records without fields in a given frame as DatumReaders do not call the Decoder for them.
+// Returns the index of the next item to read.
+func (jd *JSONDecoder) skipEmpty(frame *jsonDecoderFrame) int {
+	for frame.index < len(frame.items) && isEmptyRecord(frame.items[frame.index].schema) {
+		frame.index++
+	}
+	return frame.index
+}
+
+func (jd *JSONDecoder) top() *jsonDecoderFrame {
+	if len(jd.stack) == 0 {
+		return nil
+	}
+	return jd.stack[len(jd.stack)-1]
+}
+
+func (jd *JSONDecoder) push(schema Schema, items ...jsonDecoderItem) {
+	jd.stack = append(jd.stack, &jsonDecoderFrame{schema: schema, namespace: jd.namespace(), items: items})
+}
+
+func (jd *JSONDecoder) pop() {
+	jd.stack = jd.stack[:len(jd.stack)-1]
+}
+
+func (jd *JSONDecoder) namespace() string {
+	if top := jd.top(); top != nil {
+		return top.namespace
+	}
+	return ""
+}
+
+// fullName returns a full name of a given type using the enclosing namespace if the type does not define one.
+func (jd *JSONDecoder) fullName(schema Schema) string {
+	switch schema.Type() {
+	case Record, Enum, Fixed:
+		fullName := GetFullName(schema)
+		if !strings.ContainsRune(fullName, '.') {
+			fullName = getFullName(fullName, jd.namespace())
+		}
+		return fullName
+	}
+	return schema.GetName()
+}
+
+func (jd *JSONDecoder) invalid(item jsonDecoderItem) error {
+	return fmt.Errorf("Invalid value %v for type %s", item.value, item.schema.GetName())
+}
@@ -0,0 +1,522 @@
+package avro
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSONEncoder implements Encoder and serializes Avro values using Avro JSON encoding
+// (https://avro.apache.org/docs/current/spec.html#json_encoding). As opposed to BinaryEncoder, JSONEncoder needs to
+// know the schema of the written data to produce field names and union type names, so calls to it must follow the
+// given schema exactly as DatumWriters do. Each top-level value is written on its own line.
+// Errors are sticky: once a write fails or does not match the schema, all following writes are ignored and the error
+// is available via Err.
+type JSONEncoder struct {
+	output io.Writer
+	schema Schema
+	stack  []*jsonEncoderFrame
+	err    error
+}
+
+type jsonEncoderFrame struct {
+	// record, array, map or union schema of the value being written
+	schema Schema
+
+	// namespace used to resolve full names of named types nested in this value
+	namespace string
+
+	// record: index of the next field; array and map: number of items started; union: chosen branch
+	index int
+
+	// array and map: number of items left in the current block
+	remaining int64
+
+	// map: whether a key is expected next
+	key bool
+
+	// union: whether the branch value has been started
+	started bool
+}
+
+// NewJSONEncoder creates a new JSONEncoder that will write values of a given schema to a given io.Writer.
+func NewJSONEncoder(schema Schema, output io.Writer) *JSONEncoder {
+	return &JSONEncoder{output: output, schema: schema}
+}
+
+// Err returns the first error that occurred while writing or nil if there was none.
+func (je *JSONEncoder) Err() error {
+	return je.err
+}
+
+// WriteNull writes a null value.
+func (je *JSONEncoder) WriteNull(_ interface{}) {
+	if je.expect(Null) != nil {
+		je.write("null")
+		je.end()
+	}
+}
+
+// WriteBoolean writes a boolean value.
+func (je *JSONEncoder) WriteBoolean(x bool) {
+	if je.expect(Boolean) != nil {
+		je.write(strconv.FormatBool(x))
+		je.end()
+	}
+}
+
+// WriteInt writes an int value. Also used to write enum indexes and union branch indexes.
+func (je *JSONEncoder) WriteInt(x int32) {
+	je.writeInteger(int64(x))
+}
+
+// WriteLong writes a long value. Also used to write union branch indexes.
+func (je *JSONEncoder) WriteLong(x int64) {
+	je.writeInteger(x)
+}
+
+func (je *JSONEncoder) writeInteger(x int64) {
+	schema := je.expect(Int, Long, Enum, Union)
+	if schema == nil {
+		return
+	}
+
+	switch schema.Type() {
+	case Enum:
+		symbols := schema.(*EnumSchema).Symbols
+		if x < 0 || x >= int64(len(symbols)) {
+			je.fail(fmt.Errorf("Invalid enum index %d for enum %s", x, schema.GetName()))
+			return
+		}
+		je.writeString(symbols[x])
+		je.end()
+	case Union:
+		types := schema.(*UnionSchema).Types
+		if x < 0 || x >= int64(len(types)) {
+			je.fail(UnionTypeOverflow)
+			return
+		}
+		branch := actualSchema(types[x])
+		if branch.Type() != Null {
+			je.write("{")
+			je.writeString(je.fullName(branch))
+			je.write(":")
+		}
+		je.push(&jsonEncoderFrame{schema: schema, namespace: je.namespace(), index: int(x)})
+		je.fillEmpty()
+	default:
+		je.write(strconv.FormatInt(x, 10))
+		je.end()
+	}
+}
+
+// WriteFloat writes a float value.
+func (je *JSONEncoder) WriteFloat(x float32) {
+	if je.expect(Float) != nil {
+		je.writeFloat(float64(x), 32)
+		je.end()
+	}
+}
+
+// WriteDouble writes a double value.
+func (je *JSONEncoder) WriteDouble(x float64) {
+	if je.expect(Double) != nil {
+		je.writeFloat(x, 64)
+		je.end()
+	}
+}
+
+func (je *JSONEncoder) writeFloat(x float64, bitSize int) {
+	switch {
+	case math.IsNaN(x):
+		je.write(`"NaN"`)
+	case math.IsInf(x, 1):
+		je.write(`"Infinity"`)
+	case math.IsInf(x, -1):
+		je.write(`"-Infinity"`)
+	default:
+		je.write(strconv.FormatFloat(x, 'g', -1, bitSize))
+	}
+}
+
+// WriteBytes writes a bytes value as a string where each byte is mapped to a Unicode code point 0-255.
+func (je *JSONEncoder) WriteBytes(x []byte) {
+	if je.expect(Bytes) != nil {
+		je.writeString(bytesToJSONString(x))
+		je.end()
+	}
+}
+
+// WriteString writes a string value. Also used to write map keys.
+func (je *JSONEncoder) WriteString(x string) {
+	if top := je.top(); top != nil && top.schema.Type() == Map && top.key {
+		if top.remaining <= 0 {
+			je.fail(errors.New("Map key written after the end of map block"))
+			return
+		}
+		if top.index > 0 {
+			je.write(",")
+		}
+		je.writeString(x)
+		je.write(":")
+		top.key = false
+		top.remaining--
+		top.index++
+		je.fillEmpty()
+		return
+	}
+
+	if je.expect(String) != nil {
+		je.writeString(x)
+		je.end()
+	}
+}
+
+// WriteArrayStart should be called when starting to serialize an array providing it with a number of items in
+// array block.
+func (je *JSONEncoder) WriteArrayStart(count int64) {
+	schema := je.expect(Array)
+	if schema == nil {
+		return
+	}
+
+	je.write("[")
+	je.push(&jsonEncoderFrame{schema: schema, namespace: je.namespace(), remaining: count})
+	je.fillEmpty()
+}
+
+// WriteArrayNext should be called after finishing writing an array block either passing it the number of items in
+// next block or 0 indicating the end of array.
+func (je *JSONEncoder) WriteArrayNext(count int64) {
+	je.writeNext(Array, "[", "]", count)
+}
+
+// WriteMapStart should be called when starting to serialize a map providing it with a number of items in
+// map block.
+func (je *JSONEncoder) WriteMapStart(count int64) {
+	schema := je.expect(Map)
+	if schema == nil {
+		return
+	}
+
+	je.write("{")
+	je.push(&jsonEncoderFrame{schema: schema, namespace: je.namespace(), remaining: count, key: true})
+}
+
+// WriteMapNext should be called after finishing writing a map block either passing it the number of items in
+// next block or 0 indicating the end of map.
+func (je *JSONEncoder) WriteMapNext(count int64) {
+	je.writeNext(Map, "{", "}", count)
+}
+
+// writeNext either finishes the current array or map block, or writes an empty array or map if no block is open.
+func (je *JSONEncoder) writeNext(typ int, open string, close string, count int64) {
+	if top := je.top(); top != nil && top.schema.Type() == typ && top.remaining == 0 && (typ != Map || top.key) {
+		if count > 0 {
+			top.remaining = count
+			je.fillEmpty()
+			return
+		}
+		je.pop()
+		je.write(close)
+		je.end()
+		return
+	}
+
+	if je.expect(typ) == nil {
+		return
+	}
+	if count > 0 {
+		je.fail(fmt.Errorf("Expected %s start", strings.ToLower(open)))
+		return
+	}
+	je.write(open + close)
+	je.end()
+}
+
+// WriteRaw writes raw bytes of a fixed value as a string where each byte is mapped to a Unicode code point 0-255.
+func (je *JSONEncoder) WriteRaw(x []byte) {
+	schema := je.expect(Fixed)
+	if schema == nil {
+		return
+	}
+	if len(x) != schema.(*FixedSchema).Size {
+		je.fail(fmt.Errorf("Invalid fixed %s size: %d", schema.GetName(), len(x)))
+		return
+	}
+
+	je.writeString(bytesToJSONString(x))
+	je.end()
+}
+
+// expect moves to the next value according to schema writing field names and separators, and checks that it is of
+// one of the given types. Records are opened implicitly. Returns nil if an error occurred.
+func (je *JSONEncoder) expect(types ...int) Schema {
+	for je.err == nil {
+		schema := je.next()
+		if schema == nil {
+			return nil
+		}
+
+		if schema.Type() == Record {
+			je.write("{")
+			je.push(&jsonEncoderFrame{schema: schema, namespace: je.namespaceOf(schema)})
+			je.end()
+			continue
+		}
+
+		for _, typ := range types {
+			if schema.Type() == typ {
+				return schema
+			}
+		}
+		je.fail(fmt.Errorf("Value of type %s does not match schema %s", typeName(types[0]), schema.GetName()))
+	}
+
+	return nil
+}
+
+// next returns the schema of the next value writing a field name or a separator if needed.
+func (je *JSONEncoder) next() Schema {
+	top := je.top()
+	if top == nil {
+		return actualSchema(je.schema)
+	}
+
+	switch top.schema.Type() {
+	case Record:
+		fields := assertRecordSchema(top.schema).Fields
+		field := fields[top.index]
+		if top.index > 0 {
+			je.write(",")
+		}
+		je.writeString(field.Name)
+		je.write(":")
+		top.index++
+		return actualSchema(field.Type)
+	case Array:
+		if top.remaining <= 0 {
+			je.fail(errors.New("Array item written after the end of array block"))
+			return nil
+		}
+		if top.index > 0 {
+			je.write(",")
+		}
+		top.remaining--
+		top.index++
+		return actualSchema(top.schema.(*ArraySchema).Items)
+	case Map:
+		if top.key {
+			je.fail(errors.New("Map value written before map key"))
+			return nil
+		}
+		top.key = true
+		return actualSchema(top.schema.(*MapSchema).Values)
+	case Union:
+		if top.started {
+			je.fail(errors.New("Union value is already written"))
+			return nil
+		}
+		top.started = true
+		return actualSchema(top.schema.(*UnionSchema).Types[top.index])
+	}
+
+	return nil
+}
+
+// end closes records and unions that are complete after a value has been written.
+func (je *JSONEncoder) end() {
+	for top := je.top(); top != nil; top = je.top() {
+		switch top.schema.Type() {
+		case Record:
+			if top.index < len(assertRecordSchema(top.schema).Fields) {
+				je.fillEmpty()
+				return
+			}
+			je.write("}")
+		case Union:
+			if actualSchema(top.schema.(*UnionSchema).Types[top.index]).Type() != Null {
+				je.write("}")
+			}
+		default:
+			// arrays and maps are closed explicitly with WriteArrayNext and WriteMapNext
+			je.fillEmpty()
+			return
+		}
+		je.pop()
+	}
+
+	je.write("\n")
+}
+
+// fillEmpty writes records without fields that come next as DatumWriters do not call the Encoder for them.
+func (je *JSONEncoder) fillEmpty() {
+	schema := je.peek()
+	if je.err != nil || !isEmptyRecord(schema) {
+		return
+	}
+
+	je.next()
+	je.write("{")
+	je.push(&jsonEncoderFrame{schema: schema, namespace: je.namespaceOf(schema)})
+	je.end()
+}
+
+// peek returns the schema of the next value if it is known without writing anything.
+func (je *JSONEncoder) peek() Schema {
+	top := je.top()
+	if top == nil {
+		return nil
+	}
+
+	switch top.schema.Type() {
+	case Record:
+		if fields := assertRecordSchema(top.schema).Fields; top.index < len(fields) {
+			return actualSchema(fields[top.index].Type)
+		}
+	case Array:
+		if top.remaining > 0 {
+			return actualSchema(top.schema.(*ArraySchema).Items)
+		}
+	case Map:
+		if !top.key {
+			return actualSchema(top.schema.(*MapSchema).Values)
+		}
+	case Union:
+		if !top.started {
+			return actualSchema(top.schema.(*UnionSchema).Types[top.index])
+		}
+	}
+	return nil
+}
+
+func isEmptyRecord(schema Schema) bool {
+	return schema != nil && schema.Type() == Record && len(assertRecordSchema(schema).Fields) == 0
+}
+
+func (je *JSONEncoder) top() *jsonEncoderFrame {
+	if len(je.stack) == 0 {
+		return nil
+	}
+	return je.stack[len(je.stack)-1]
+}
+
+func (je *JSONEncoder) push(frame *jsonEncoderFrame) {
+	je.stack = append(je.stack, frame)
+}
+
+func (je *JSONEncoder) pop() {
+	je.stack = je.stack[:len(je.stack)-1]
+}
+
+func (je *JSONEncoder) namespace() string {
+	if top := je.top(); top != nil {
+		return top.namespace
+	}
+	return ""
+}
+
+// namespaceOf returns the namespace for types nested in a given record.
+func (je *JSONEncoder) namespaceOf(schema Schema) string {
+	return namespaceOfFullName(je.fullName(schema))
+}
+
+// fullName returns a full name of a given type using the enclosing namespace if the type does not define one.
+func (je *JSONEncoder) fullName(schema Schema) string {
+	switch schema.Type() {
+	case Record, Enum, Fixed:
+		fullName := GetFullName(schema)
+		if !strings.ContainsRune(fullName, '.') {
+			fullName = getFullName(fullName, je.namespace())
+		}
+		return fullName
+	}
+	return schema.GetName()
+}
+
+func (je *JSONEncoder) fail(err error) {
+	if je.err == nil {
+		je.err = err
+	}
+}
+
+func (je *JSONEncoder) write(s string) {
+	if je.err != nil {
+		return
+	}
+	if _, err := io.WriteString(je.output, s); err != nil {
+		je.err = err
+	}
+}
+
+func (je *JSONEncoder) writeString(s string) {
+	je.write(quoteJSONString(s))
+}
+
+// quoteJSONString quotes a given string according to JSON rules.
+func quoteJSONString(s string) string {
+	quoted := make([]byte, 0, len(s)+2)
+	quoted = append(quoted, '"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			quoted = append(quoted, '\\', byte(r))
+		case r == '\n':
+			quoted = append(quoted, '\\', 'n')
+		case r == '\r':
+			quoted = append(quoted, '\\', 'r')
+		case r == '\t':
+			quoted = append(quoted, '\\', 't')
+		case r < 0x20 || r == utf8.RuneError:
+			quoted = append(quoted, fmt.Sprintf("\\u%04x", r)...)
+		default:
+			quoted = append(quoted, string(r)...)
+		}
+	}
+	return string(append(quoted, '"'))
+}
+
+// bytesToJSONString maps each byte to a Unicode code point 0-255 as required by Avro JSON encoding.
+func bytesToJSONString(bytes []byte) string {
+	runes := make([]rune, len(bytes))
+	for i, b := range bytes {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+func typeName(typ int) string {
+	switch typ {
+	case Null:
+		return typeNull
+	case Boolean:
+		return typeBoolean
+	case Int:
+		return typeInt
+	case Long:
+		return typeLong
+	case Float:
+		return typeFloat
+	case Double:
+		return typeDouble
+	case Bytes:
+		return typeBytes
+	case String:
+		return typeString
+	case Array:
+		return typeArray
+	case Map:
+		return typeMap
+	case Enum:
+		return typeEnum
+	case Fixed:
+		return typeFixed
+	case Union:
+		return typeUnion
+	}
+	return typeRecord
+}
@@ -0,0 +1,202 @@
+package avro
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+var jsonEncodingSchema = MustParseSchema(`{
+    "type": "record",
+    "name": "Event",
+    "namespace": "com.example",
+    "fields": [
+        {"name": "id", "type": "long"},
+        {"name": "name", "type": "string"},
+        {"name": "flag", "type": "boolean"},
+        {"name": "score", "type": "float"},
+        {"name": "ratio", "type": "double"},
+        {"name": "payload", "type": "bytes"},
+        {"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 2}},
+        {"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"]}},
+        {"name": "tags", "type": {"type": "array", "items": "string"}},
+        {"name": "matrix", "type": {"type": "array", "items": {"type": "array", "items": "int"}}},
+        {"name": "props", "type": {"type": "map", "values": "int"}},
+        {"name": "empty", "type": {"type": "record", "name": "Empty", "fields": []}},
+        {"name": "optional", "type": ["null", "string"]},
+        {"name": "choice", "type": ["null", {"type": "record", "name": "Inner", "fields": [
+            {"name": "value", "type": "int"}
+        ]}, "Kind"]},
+        {"name": "nothing", "type": "null"}
+    ]
+}`)
+
+func jsonEncodingRecord() *GenericRecord {
+	inner := NewGenericRecord(jsonEncodingSchema)
+	inner.Set("value", int32(5))
+
+	record := NewGenericRecord(jsonEncodingSchema)
+	record.Set("id", int64(1))
+	record.Set("name", "quote \" and\nnewline")
+	record.Set("flag", true)
+	record.Set("score", float32(1.5))
+	record.Set("ratio", math.Inf(-1))
+	record.Set("payload", []byte{0x00, 0x7f, 0xff})
+	record.Set("hash", []byte{0x01, 0xe9})
+	record.Set("kind", "B")
+	record.Set("tags", []interface{}{"x", "y"})
+	record.Set("matrix", []interface{}{[]interface{}{}, []interface{}{int32(1), int32(2)}, []interface{}{}})
+	record.Set("props", map[string]interface{}{"a": int32(1)})
+	record.Set("empty", NewGenericRecord(jsonEncodingSchema))
+	record.Set("optional", "set")
+	record.Set("choice", inner)
+	return record
+}
+
+const jsonEncodingExpected = `{"id":1,"name":"quote \" and\nnewline","flag":true,"score":1.5,"ratio":"-Infinity",` +
+	`"payload":"\u0000` + "\x7f" + `ÿ","hash":"\u0001é","kind":"B","tags":["x","y"],"matrix":[[],[1,2],[]],` +
+	`"props":{"a":1},"empty":{},"optional":{"string":"set"},"choice":{"com.example.Inner":{"value":5}},"nothing":null}` + "\n"
+
+func TestJSONEncoder(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	encoder := NewJSONEncoder(jsonEncodingSchema, buffer)
+	writer := NewGenericDatumWriter()
+	writer.SetSchema(jsonEncodingSchema)
+
+	err := writer.Write(jsonEncodingRecord(), encoder)
+	assert(t, err, nil)
+	assert(t, encoder.Err(), nil)
+	assert(t, buffer.String(), jsonEncodingExpected)
+}
+
+func TestJSONDecoder(t *testing.T) {
+	decoder := NewJSONDecoder(jsonEncodingSchema, strings.NewReader(jsonEncodingExpected))
+	reader := NewGenericDatumReader()
+	reader.SetSchema(jsonEncodingSchema)
+
+	record := NewGenericRecord(jsonEncodingSchema)
+	err := reader.Read(record, decoder)
+	assert(t, err, nil)
+	assert(t, record.Get("id"), int64(1))
+	assert(t, record.Get("name"), "quote \" and\nnewline")
+	assert(t, record.Get("flag"), true)
+	assert(t, record.Get("score"), float32(1.5))
+	assert(t, record.Get("ratio"), math.Inf(-1))
+	assert(t, record.Get("payload"), []byte{0x00, 0x7f, 0xff})
+	assert(t, record.Get("hash"), []byte{0x01, 0xe9})
+	assert(t, record.Get("kind"), "B")
+	assert(t, record.Get("tags"), []interface{}{"x", "y"})
+	matrix := record.Get("matrix").([]interface{})
+	assert(t, len(matrix), 3)
+	assert(t, len(matrix[0].([]interface{})), 0)
+	assert(t, matrix[1], []interface{}{int32(1), int32(2)})
+	assert(t, len(matrix[2].([]interface{})), 0)
+	assert(t, record.Get("props"), map[string]interface{}{"a": int32(1)})
+	assert(t, record.Get("optional"), "set")
+	assert(t, record.Get("choice").(*GenericRecord).Get("value"), int32(5))
+	assert(t, record.Get("nothing"), nil)
+
+	_, err = decoder.ReadLong()
+	assert(t, err, EOF)
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	encoder := NewJSONEncoder(jsonEncodingSchema, buffer)
+	writer := NewGenericDatumWriter()
+	writer.SetSchema(jsonEncodingSchema)
+
+	first := jsonEncodingRecord()
+	second := jsonEncodingRecord()
+	second.Set("optional", nil)
+	second.Set("choice", "A")
+	second.Set("ratio", math.NaN())
+	assert(t, writer.Write(first, encoder), nil)
+	assert(t, writer.Write(second, encoder), nil)
+	assert(t, encoder.Err(), nil)
+
+	decoder := NewJSONDecoder(jsonEncodingSchema, buffer)
+	reader := NewGenericDatumReader()
+	reader.SetSchema(jsonEncodingSchema)
+
+	record := NewGenericRecord(jsonEncodingSchema)
+	assert(t, reader.Read(record, decoder), nil)
+	assert(t, record.Get("optional"), "set")
+
+	record = NewGenericRecord(jsonEncodingSchema)
+	assert(t, reader.Read(record, decoder), nil)
+	assert(t, record.Get("optional"), nil)
+	assert(t, record.Get("choice"), "A")
+	assert(t, math.IsNaN(record.Get("ratio").(float64)), true)
+}
+
+func TestJSONDecoderFieldOrderAndDefaults(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Point", "fields": [
+        {"name": "x", "type": "int"},
+        {"name": "y", "type": "int", "default": 7},
+        {"name": "label", "type": ["string", "null"], "default": "none"},
+        {"name": "note", "type": ["null", "string"]}
+    ]}`)
+
+	decoder := NewJSONDecoder(schema, strings.NewReader(`{"note": {"string": "hi"}, "x": 3}`))
+	reader := NewGenericDatumReader()
+	reader.SetSchema(schema)
+
+	record := NewGenericRecord(schema)
+	err := reader.Read(record, decoder)
+	assert(t, err, nil)
+	assert(t, record.Get("x"), int32(3))
+	assert(t, record.Get("y"), int32(7))
+	assert(t, record.Get("label"), "none")
+	assert(t, record.Get("note"), "hi")
+
+	decoder = NewJSONDecoder(schema, strings.NewReader(`{"y": 1}`))
+	err = reader.Read(NewGenericRecord(schema), decoder)
+	if err == nil {
+		t.Fatal("Expected an error for missing field without default value")
+	}
+}
+
+type jsonSpecificEvent struct {
+	Id       int64
+	Name     string
+	Tags     []string
+	Optional *string
+}
+
+func TestJSONSpecificRoundTrip(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+        {"name": "id", "type": "long"},
+        {"name": "name", "type": "string"},
+        {"name": "tags", "type": {"type": "array", "items": "string"}},
+        {"name": "optional", "type": ["null", "string"]}
+    ]}`)
+
+	buffer := &bytes.Buffer{}
+	encoder := NewJSONEncoder(schema, buffer)
+	writer := NewSpecificDatumWriter()
+	writer.SetSchema(schema)
+	assert(t, writer.Write(&jsonSpecificEvent{Id: 10, Name: "ten", Tags: []string{"a"}}, encoder), nil)
+	assert(t, encoder.Err(), nil)
+	assert(t, buffer.String(), `{"id":10,"name":"ten","tags":["a"],"optional":null}`+"\n")
+
+	reader := NewSpecificDatumReader()
+	reader.SetSchema(schema)
+	event := &jsonSpecificEvent{}
+	assert(t, reader.Read(event, NewJSONDecoder(schema, buffer)), nil)
+	assert(t, event.Id, int64(10))
+	assert(t, event.Name, "ten")
+	assert(t, event.Tags, []string{"a"})
+}
+
+func TestJSONEncoderSchemaMismatch(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	encoder := NewJSONEncoder(MustParseSchema(`"int"`), buffer)
+	encoder.WriteString("not an int")
+	if encoder.Err() == nil {
+		t.Fatal("Expected an error for a value not matching schema")
+	}
+	encoder.WriteInt(1)
+	assert(t, buffer.String(), "")
+}
@@ -0,0 +1,456 @@
+package avro
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Logical type names defined by the Avro specification.
+const (
+	// LogicalDecimal annotates bytes or fixed and maps to *big.Rat.
+	LogicalDecimal = "decimal"
+
+	// LogicalUUID annotates string and maps to string or [16]byte.
+	LogicalUUID = "uuid"
+
+	// LogicalDate annotates int (days since Unix epoch) and maps to time.Time.
+	LogicalDate = "date"
+
+	// LogicalTimeMillis annotates int (milliseconds after midnight) and maps to time.Duration.
+	LogicalTimeMillis = "time-millis"
+
+	// LogicalTimeMicros annotates long (microseconds after midnight) and maps to time.Duration.
+	LogicalTimeMicros = "time-micros"
+
+	// LogicalTimestampMillis annotates long (milliseconds since Unix epoch) and maps to time.Time.
+	LogicalTimestampMillis = "timestamp-millis"
+
+	// LogicalTimestampMicros annotates long (microseconds since Unix epoch) and maps to time.Time.
+	LogicalTimestampMicros = "timestamp-micros"
+
+	// LogicalLocalTimestampMillis annotates long (milliseconds since Unix epoch in local time) and maps to time.Time
+	// in UTC that holds the local wall clock time.
+	LogicalLocalTimestampMillis = "local-timestamp-millis"
+
+	// LogicalLocalTimestampMicros annotates long (microseconds since Unix epoch in local time) and maps to time.Time
+	// in UTC that holds the local wall clock time.
+	LogicalLocalTimestampMicros = "local-timestamp-micros"
+
+	// LogicalDuration annotates fixed of size 12 and maps to Duration.
+	LogicalDuration = "duration"
+)
+
+const (
+	schemaLogicalTypeField = "logicalType"
+	schemaPrecisionField   = "precision"
+	schemaScaleField       = "scale"
+)
+
+const (
+	durationSize  = 12
+	secondsPerDay = int64(24 * time.Hour / time.Second)
+	millisPerDay  = int64(24 * time.Hour / time.Millisecond)
+)
+
+var (
+	timeType         = reflect.TypeOf(time.Time{})
+	durationType     = reflect.TypeOf(time.Duration(0))
+	ratType          = reflect.TypeOf(big.Rat{})
+	uuidType         = reflect.TypeOf([16]byte{})
+	avroDurationType = reflect.TypeOf(Duration{})
+	logicalGoTypes   = []reflect.Type{timeType, durationType, ratType, uuidType, avroDurationType}
+)
+
+// LogicalType is an Avro logical type annotation of an int, long, bytes, string or fixed schema.
+type LogicalType struct {
+	// Logical type name, e.g. "timestamp-millis".
+	Name string
+
+	// Maximum number of digits and number of digits to the right of the decimal point. Used only by decimals.
+	Precision int
+	Scale     int
+}
+
+// Duration is a Go representation of Avro duration logical type.
+type Duration struct {
+	Months       uint32
+	Days         uint32
+	Milliseconds uint32
+}
+
+// GetLogicalType returns a logical type of a given schema or nil if the schema has none.
+func GetLogicalType(schema Schema) *LogicalType {
+	switch s := schema.(type) {
+	case *IntSchema:
+		return s.LogicalType
+	case *LongSchema:
+		return s.LogicalType
+	case *BytesSchema:
+		return s.LogicalType
+	case *StringSchema:
+		return s.LogicalType
+	case *FixedSchema:
+		return s.LogicalType
+	}
+
+	return nil
+}
+
+// parseLogicalType parses a logical type annotation of a schema with a given underlying type. As required by
+// Avro specification unknown and invalid logical types (e.g. decimal with scale greater than precision) are ignored
+// and nil is returned so that the underlying type is used.
+func parseLogicalType(v map[string]interface{}, typ int, size int) *LogicalType {
+	name, ok := v[schemaLogicalTypeField].(string)
+	if !ok {
+		return nil
+	}
+
+	valid := false
+	logicalType := &LogicalType{Name: name}
+	switch name {
+	case LogicalDate, LogicalTimeMillis:
+		valid = typ == Int
+	case LogicalTimeMicros, LogicalTimestampMillis, LogicalTimestampMicros, LogicalLocalTimestampMillis, LogicalLocalTimestampMicros:
+		valid = typ == Long
+	case LogicalUUID:
+		valid = typ == String
+	case LogicalDuration:
+		valid = typ == Fixed && size == durationSize
+	case LogicalDecimal:
+		precision, precisionOk := integerProperty(v, schemaPrecisionField, -1)
+		scale, scaleOk := integerProperty(v, schemaScaleField, 0)
+		logicalType.Precision = precision
+		logicalType.Scale = scale
+		valid = precisionOk && scaleOk && precision > 0 && scale >= 0 && scale <= precision
+		if typ == Fixed {
+			valid = valid && precision <= maxDecimalPrecision(size)
+		} else {
+			valid = valid && typ == Bytes
+		}
+	}
+
+	if !valid {
+		return nil
+	}
+	return logicalType
+}
+
+func integerProperty(v map[string]interface{}, name string, fallback int) (int, bool) {
+	value, exists := v[name]
+	if !exists {
+		return fallback, true
+	}
+
+	number, ok := value.(float64)
+	if !ok || number != math.Trunc(number) || math.Abs(number) > math.MaxInt32 {
+		return 0, false
+	}
+	return int(number), true
+}
+
+// maxDecimalPrecision returns the number of base-10 digits that can be stored in a fixed of a given size.
+func maxDecimalPrecision(size int) int {
+	if size <= 0 {
+		return 0
+	}
+
+	max := new(big.Int).Lsh(big.NewInt(1), uint(8*size-1))
+	max.Sub(max, big.NewInt(1))
+	return len(max.String()) - 1
+}
+
+// marshalJSON serializes a schema of a given underlying type annotated with this logical type as JSON.
+func (t *LogicalType) marshalJSON(typ string) ([]byte, error) {
+	if t.Name != LogicalDecimal {
+		return []byte(fmt.Sprintf(`{"type":"%s","logicalType":"%s"}`, typ, t.Name)), nil
+	}
+
+	return []byte(fmt.Sprintf(`{"type":"%s","logicalType":"%s","precision":%d,"scale":%d}`, typ, t.Name, t.Precision, t.Scale)), nil
+}
+
+// accepts checks whether a given value is a Go representation of this logical type.
+func (t *LogicalType) accepts(v reflect.Value) bool {
+	v = dereference(v)
+	if !v.IsValid() {
+		return false
+	}
+
+	switch t.Name {
+	case LogicalDate, LogicalTimestampMillis, LogicalTimestampMicros, LogicalLocalTimestampMillis, LogicalLocalTimestampMicros:
+		return v.Type() == timeType
+	case LogicalTimeMillis, LogicalTimeMicros:
+		return v.Type() == durationType
+	case LogicalDecimal:
+		return v.Type() == ratType
+	case LogicalUUID:
+		return v.Type() == uuidType
+	case LogicalDuration:
+		return v.Type() == avroDurationType
+	}
+
+	return false
+}
+
+// toRaw converts a Go representation of this logical type to a value of the underlying Avro type. Values that are
+// not Go representations of this logical type are returned as is so that raw values can still be written.
+func (t *LogicalType) toRaw(value interface{}, schema Schema) (interface{}, error) {
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Type().Elem() != ratType {
+		value = rv.Elem().Interface()
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		switch t.Name {
+		case LogicalDate:
+			days := floorDiv(v.Unix(), secondsPerDay)
+			if days < math.MinInt32 || days > math.MaxInt32 {
+				return nil, fmt.Errorf("Date %v overflows an int", v)
+			}
+			return int32(days), nil
+		case LogicalTimestampMillis, LogicalLocalTimestampMillis:
+			return v.Unix()*1e3 + int64(v.Nanosecond())/1e6, nil
+		case LogicalTimestampMicros, LogicalLocalTimestampMicros:
+			return v.Unix()*1e6 + int64(v.Nanosecond())/1e3, nil
+		}
+	case time.Duration:
+		switch t.Name {
+		case LogicalTimeMillis:
+			millis := int64(v / time.Millisecond)
+			if millis < 0 || millis >= millisPerDay {
+				return nil, fmt.Errorf("Time of day %v is out of range", v)
+			}
+			return int32(millis), nil
+		case LogicalTimeMicros:
+			return int64(v / time.Microsecond), nil
+		}
+	case *big.Rat:
+		if t.Name == LogicalDecimal {
+			return t.encodeDecimal(v, schema)
+		}
+	case big.Rat:
+		if t.Name == LogicalDecimal {
+			return t.encodeDecimal(&v, schema)
+		}
+	case [16]byte:
+		if t.Name == LogicalUUID {
+			return formatUUID(v), nil
+		}
+	case Duration:
+		if t.Name == LogicalDuration {
+			bytes := make([]byte, durationSize)
+			binary.LittleEndian.PutUint32(bytes[0:], v.Months)
+			binary.LittleEndian.PutUint32(bytes[4:], v.Days)
+			binary.LittleEndian.PutUint32(bytes[8:], v.Milliseconds)
+			return bytes, nil
+		}
+	}
+
+	return value, nil
+}
+
+// fromRaw converts a value of the underlying Avro type to a Go representation of this logical type.
+func (t *LogicalType) fromRaw(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int32:
+		switch t.Name {
+		case LogicalDate:
+			return time.Unix(int64(v)*secondsPerDay, 0).UTC(), nil
+		case LogicalTimeMillis:
+			return time.Duration(v) * time.Millisecond, nil
+		}
+	case int64:
+		switch t.Name {
+		case LogicalTimeMicros:
+			return time.Duration(v) * time.Microsecond, nil
+		case LogicalTimestampMillis, LogicalLocalTimestampMillis:
+			return time.Unix(floorDiv(v, 1e3), floorMod(v, 1e3)*1e6).UTC(), nil
+		case LogicalTimestampMicros, LogicalLocalTimestampMicros:
+			return time.Unix(floorDiv(v, 1e6), floorMod(v, 1e6)*1e3).UTC(), nil
+		}
+	case []byte:
+		switch t.Name {
+		case LogicalDecimal:
+			return t.decodeDecimal(v), nil
+		case LogicalDuration:
+			if len(v) != durationSize {
+				return nil, fmt.Errorf("Invalid duration length: %d", len(v))
+			}
+			return Duration{
+				Months:       binary.LittleEndian.Uint32(v[0:]),
+				Days:         binary.LittleEndian.Uint32(v[4:]),
+				Milliseconds: binary.LittleEndian.Uint32(v[8:]),
+			}, nil
+		}
+	case string:
+		return v, nil
+	}
+
+	return nil, fmt.Errorf("Invalid %s value: %v", t.Name, value)
+}
+
+// specificValue converts a value of the underlying Avro type to a value assignable to a given Go type.
+// Raw value is returned if the given type is not a Go representation of any logical type.
+func (t *LogicalType) specificValue(value interface{}, target reflect.Type) (reflect.Value, error) {
+	base := target
+	if base.Kind() == reflect.Ptr {
+		base = base.Elem()
+	}
+	if !isLogicalGoType(base) && target.Kind() != reflect.Interface {
+		return reflect.ValueOf(value), nil
+	}
+
+	logical, err := t.fromRaw(value)
+	if err != nil {
+		return reflect.ValueOf(nil), err
+	}
+	if base == uuidType {
+		if logical, err = parseUUID(logical.(string)); err != nil {
+			return reflect.ValueOf(nil), err
+		}
+	}
+
+	rv := reflect.ValueOf(logical)
+	switch {
+	case target.Kind() == reflect.Interface:
+		return rv, nil
+	case rv.Type() == target:
+		return rv, nil
+	case reflect.PtrTo(rv.Type()) == target:
+		pointer := reflect.New(rv.Type())
+		pointer.Elem().Set(rv)
+		return pointer, nil
+	case rv.Type() == reflect.PtrTo(target):
+		return rv.Elem(), nil
+	}
+
+	return reflect.ValueOf(nil), fmt.Errorf("Cannot read %s value into %s", t.Name, target)
+}
+
+// readUnderlying reads a value of the underlying Avro type of a given logical type annotated schema.
+func readUnderlying(schema Schema, dec Decoder) (interface{}, error) {
+	switch schema.Type() {
+	case Int:
+		return dec.ReadInt()
+	case Long:
+		return dec.ReadLong()
+	case Bytes:
+		return dec.ReadBytes()
+	case String:
+		return dec.ReadString()
+	case Fixed:
+		fixed := make([]byte, schema.(*FixedSchema).Size)
+		if err := dec.ReadFixed(fixed); err != nil {
+			return nil, err
+		}
+		return fixed, nil
+	}
+
+	return nil, fmt.Errorf("Unknown logical type underlying type: %d", schema.Type())
+}
+
+func isLogicalGoType(t reflect.Type) bool {
+	for _, logicalType := range logicalGoTypes {
+		if t == logicalType {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *LogicalType) encodeDecimal(value *big.Rat, schema Schema) ([]byte, error) {
+	unscaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(pow10(t.Scale)))
+	if !unscaled.IsInt() {
+		return nil, fmt.Errorf("Decimal %s does not fit scale %d", value.RatString(), t.Scale)
+	}
+
+	number := unscaled.Num()
+	if digits := len(new(big.Int).Abs(number).String()); number.Sign() != 0 && digits > t.Precision {
+		return nil, fmt.Errorf("Decimal %s does not fit precision %d", value.RatString(), t.Precision)
+	}
+
+	bytes := twosComplement(number)
+	if fixed, ok := schema.(*FixedSchema); ok {
+		if len(bytes) > fixed.Size {
+			return nil, fmt.Errorf("Decimal %s does not fit fixed %s", value.RatString(), fixed.Name)
+		}
+		padded := make([]byte, fixed.Size)
+		if number.Sign() < 0 {
+			for i := range padded {
+				padded[i] = 0xff
+			}
+		}
+		copy(padded[fixed.Size-len(bytes):], bytes)
+		bytes = padded
+	}
+
+	return bytes, nil
+}
+
+func (t *LogicalType) decodeDecimal(bytes []byte) *big.Rat {
+	number := new(big.Int).SetBytes(bytes)
+	if len(bytes) > 0 && bytes[0]&0x80 != 0 {
+		number.Sub(number, new(big.Int).Lsh(big.NewInt(1), uint(8*len(bytes))))
+	}
+
+	return new(big.Rat).SetFrac(number, pow10(t.Scale))
+}
+
+// twosComplement returns the shortest big-endian two's-complement representation of a given number.
+func twosComplement(number *big.Int) []byte {
+	if number.Sign() >= 0 {
+		bytes := number.Bytes()
+		if len(bytes) == 0 || bytes[0]&0x80 != 0 {
+			bytes = append([]byte{0}, bytes...)
+		}
+		return bytes
+	}
+
+	// -x in n bytes is 2^(8n) - x, with n large enough to keep the sign bit set
+	length := len(new(big.Int).Sub(new(big.Int).Abs(number), big.NewInt(1)).Bytes()) + 1
+	bytes := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), uint(8*length)), number).Bytes()
+	for len(bytes) > 1 && bytes[0] == 0xff && bytes[1]&0x80 != 0 {
+		bytes = bytes[1:]
+	}
+	return bytes
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func floorDiv(x int64, y int64) int64 {
+	q := x / y
+	if x%y != 0 && (x < 0) != (y < 0) {
+		q--
+	}
+	return q
+}
+
+func floorMod(x int64, y int64) int64 {
+	return x - floorDiv(x, y)*y
+}
+
+func formatUUID(uuid [16]byte) string {
+	s := hex.EncodeToString(uuid[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+func parseUUID(s string) ([16]byte, error) {
+	var uuid [16]byte
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return uuid, fmt.Errorf("Invalid UUID: %s", s)
+	}
+
+	bytes, err := hex.DecodeString(strings.Replace(s, "-", "", -1))
+	if err != nil {
+		return uuid, fmt.Errorf("Invalid UUID: %s", s)
+	}
+	copy(uuid[:], bytes)
+	return uuid, nil
+}
@@ -0,0 +1,218 @@
+package avro
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+)
+
+var logicalTypesSchema = MustParseSchema(`{
+    "type": "record",
+    "name": "Logical",
+    "fields": [
+        {"name": "date", "type": {"type": "int", "logicalType": "date"}},
+        {"name": "timeMillis", "type": {"type": "int", "logicalType": "time-millis"}},
+        {"name": "timeMicros", "type": {"type": "long", "logicalType": "time-micros"}},
+        {"name": "timestampMillis", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+        {"name": "timestampMicros", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+        {"name": "decimal", "type": {"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}},
+        {"name": "fixedDecimal", "type": {"type": "fixed", "name": "dec", "size": 4, "logicalType": "decimal", "precision": 9, "scale": 2}},
+        {"name": "uuid", "type": {"type": "string", "logicalType": "uuid"}},
+        {"name": "duration", "type": {"type": "fixed", "name": "dur", "size": 12, "logicalType": "duration"}},
+        {"name": "optionalTimestamp", "type": ["null", {"type": "long", "logicalType": "timestamp-millis"}]}
+    ]
+}`)
+
+func TestParseLogicalTypes(t *testing.T) {
+	fields := logicalTypesSchema.(*RecordSchema).Fields
+	assert(t, GetLogicalType(fields[0].Type), &LogicalType{Name: LogicalDate})
+	assert(t, GetLogicalType(fields[3].Type), &LogicalType{Name: LogicalTimestampMillis})
+	assert(t, GetLogicalType(fields[5].Type), &LogicalType{Name: LogicalDecimal, Precision: 9, Scale: 2})
+	assert(t, GetLogicalType(fields[6].Type), &LogicalType{Name: LogicalDecimal, Precision: 9, Scale: 2})
+	assert(t, GetLogicalType(fields[8].Type), &LogicalType{Name: LogicalDuration})
+
+	// invalid logical types are ignored
+	invalid := []string{
+		`{"type": "long", "logicalType": "date"}`,
+		`{"type": "string", "logicalType": "unknown"}`,
+		`{"type": "bytes", "logicalType": "decimal"}`,
+		`{"type": "bytes", "logicalType": "decimal", "precision": 2, "scale": 3}`,
+		`{"type": "bytes", "logicalType": "decimal", "precision": 2.5}`,
+		`{"type": "fixed", "name": "small", "size": 2, "logicalType": "decimal", "precision": 5}`,
+		`{"type": "fixed", "name": "dur", "size": 8, "logicalType": "duration"}`,
+	}
+	for _, schema := range invalid {
+		if logicalType := GetLogicalType(MustParseSchema(schema)); logicalType != nil {
+			t.Errorf("Expected logical type of %s to be ignored, got %v", schema, logicalType)
+		}
+	}
+
+	assert(t, MustParseSchema(`{"type": "fixed", "name": "max", "size": 2, "logicalType": "decimal", "precision": 4}`).(*FixedSchema).LogicalType.Precision, 4)
+}
+
+func TestLogicalTypesMarshalJSON(t *testing.T) {
+	assert(t, MustParseSchema(`{"type": "long", "logicalType": "timestamp-micros"}`).String(), `{"type":"long","logicalType":"timestamp-micros"}`)
+	assert(t, MustParseSchema(`{"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 2}`).String(), `{"type":"bytes","logicalType":"decimal","precision":4,"scale":2}`)
+
+	fixed, err := MustParseSchema(`{"type": "fixed", "name": "dur", "size": 12, "logicalType": "duration"}`).(*FixedSchema).MarshalJSON()
+	assert(t, err, nil)
+	assert(t, string(fixed), `{"type":"fixed","size":12,"name":"dur","logicalType":"duration"}`)
+}
+
+func TestGenericDatumLogicalTypes(t *testing.T) {
+	timestamp := time.Date(2016, 3, 4, 5, 6, 7, 891234000, time.UTC)
+	before1970 := time.Date(1969, 12, 31, 23, 59, 59, 999000000, time.UTC)
+	uuid := [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+
+	record := NewGenericRecord(logicalTypesSchema)
+	record.Set("date", time.Date(2016, 3, 4, 0, 0, 0, 0, time.UTC))
+	record.Set("timeMillis", 5*time.Hour+time.Millisecond)
+	record.Set("timeMicros", 5*time.Hour+time.Microsecond)
+	record.Set("timestampMillis", before1970)
+	record.Set("timestampMicros", timestamp)
+	record.Set("decimal", big.NewRat(-12345, 100))
+	record.Set("fixedDecimal", big.NewRat(-1, 4))
+	record.Set("uuid", uuid)
+	record.Set("duration", Duration{Months: 1, Days: 2, Milliseconds: 3})
+	record.Set("optionalTimestamp", timestamp)
+
+	buf := encodeGeneric(t, logicalTypesSchema, record)
+
+	reader := NewGenericDatumReader()
+	reader.SetSchema(logicalTypesSchema)
+	decoded := NewGenericRecord(logicalTypesSchema)
+	assert(t, reader.Read(decoded, NewBinaryDecoder(buf)), nil)
+
+	assert(t, decoded.Get("date"), time.Date(2016, 3, 4, 0, 0, 0, 0, time.UTC))
+	assert(t, decoded.Get("timeMillis"), 5*time.Hour+time.Millisecond)
+	assert(t, decoded.Get("timeMicros"), 5*time.Hour+time.Microsecond)
+	assert(t, decoded.Get("timestampMillis"), before1970)
+	assert(t, decoded.Get("timestampMicros"), time.Date(2016, 3, 4, 5, 6, 7, 891234000, time.UTC))
+	assert(t, decoded.Get("decimal").(*big.Rat).Cmp(big.NewRat(-12345, 100)), 0)
+	assert(t, decoded.Get("fixedDecimal").(*big.Rat).Cmp(big.NewRat(-1, 4)), 0)
+	assert(t, decoded.Get("uuid"), "123e4567-e89b-12d3-a456-426614174000")
+	assert(t, decoded.Get("duration"), Duration{Months: 1, Days: 2, Milliseconds: 3})
+	assert(t, decoded.Get("optionalTimestamp"), time.Date(2016, 3, 4, 5, 6, 7, 891000000, time.UTC))
+}
+
+func TestLogicalTypesRawValues(t *testing.T) {
+	// values of underlying types can still be written and are encoded the same way
+	schema := MustParseSchema(`{"type": "long", "logicalType": "timestamp-millis"}`)
+	assert(t, encodeGeneric(t, schema, int64(1457067967891)), encodeGeneric(t, schema, time.Unix(1457067967, 891000000)))
+
+	assert(t, encodeGeneric(t, MustParseSchema(`{"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 2}`), big.NewRat(1, 1)), []byte{0x02, 0x64})
+	assert(t, encodeGeneric(t, MustParseSchema(`{"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 2}`), big.NewRat(128, 100)), []byte{0x04, 0x00, 0x80})
+	assert(t, encodeGeneric(t, MustParseSchema(`{"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 2}`), big.NewRat(-128, 100)), []byte{0x02, 0x80})
+}
+
+func TestLogicalTypesDecimalValidation(t *testing.T) {
+	schema := MustParseSchema(`{"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 2}`)
+	writer := NewGenericDatumWriter()
+	writer.SetSchema(schema)
+
+	if err := writer.Write(big.NewRat(1, 3), NewBinaryEncoder(&bytes.Buffer{})); err == nil {
+		t.Fatal("Expected an error for decimal that does not fit scale")
+	}
+	if err := writer.Write(big.NewRat(100, 1), NewBinaryEncoder(&bytes.Buffer{})); err == nil {
+		t.Fatal("Expected an error for decimal that does not fit precision")
+	}
+	assert(t, writer.Write(big.NewRat(-9999, 100), NewBinaryEncoder(&bytes.Buffer{})), nil)
+}
+
+type logicalTypesRaw struct {
+	Date              int32
+	TimeMillis        int32
+	TimeMicros        int64
+	TimestampMillis   int64
+	TimestampMicros   int64
+	Decimal           []byte
+	FixedDecimal      []byte
+	Uuid              string
+	Duration          []byte
+	OptionalTimestamp int64
+}
+
+type logicalTypesSpecific struct {
+	Date              time.Time
+	TimeMillis        time.Duration
+	TimeMicros        time.Duration
+	TimestampMillis   time.Time
+	TimestampMicros   *time.Time
+	Decimal           *big.Rat
+	FixedDecimal      big.Rat
+	Uuid              [16]byte
+	Duration          Duration
+	OptionalTimestamp time.Time
+}
+
+func TestSpecificDatumLogicalTypes(t *testing.T) {
+	timestamp := time.Date(2016, 3, 4, 5, 6, 7, 891000000, time.UTC)
+	value := &logicalTypesSpecific{
+		Date:              time.Date(2016, 3, 4, 0, 0, 0, 0, time.UTC),
+		TimeMillis:        time.Minute,
+		TimeMicros:        time.Second,
+		TimestampMillis:   timestamp,
+		TimestampMicros:   &timestamp,
+		Decimal:           big.NewRat(314, 100),
+		FixedDecimal:      *big.NewRat(-5, 2),
+		Uuid:              [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00},
+		Duration:          Duration{Months: 4, Days: 5, Milliseconds: 6},
+		OptionalTimestamp: timestamp,
+	}
+
+	buffer := &bytes.Buffer{}
+	writer := NewSpecificDatumWriter()
+	writer.SetSchema(logicalTypesSchema)
+	assert(t, writer.Write(value, NewBinaryEncoder(buffer)), nil)
+
+	reader := NewSpecificDatumReader()
+	reader.SetSchema(logicalTypesSchema)
+	decoded := &logicalTypesSpecific{}
+	assert(t, reader.Read(decoded, NewBinaryDecoder(buffer.Bytes())), nil)
+
+	assert(t, decoded.Date, value.Date)
+	assert(t, decoded.TimeMillis, value.TimeMillis)
+	assert(t, decoded.TimeMicros, value.TimeMicros)
+	assert(t, decoded.TimestampMillis, timestamp)
+	assert(t, *decoded.TimestampMicros, timestamp)
+	assert(t, decoded.Decimal.Cmp(value.Decimal), 0)
+	assert(t, decoded.FixedDecimal.Cmp(&value.FixedDecimal), 0)
+	assert(t, decoded.Uuid, value.Uuid)
+	assert(t, decoded.Duration, value.Duration)
+	assert(t, decoded.OptionalTimestamp, timestamp)
+
+	// fields of underlying types keep working
+	raw := &logicalTypesRaw{}
+	assert(t, reader.Read(raw, NewBinaryDecoder(buffer.Bytes())), nil)
+	assert(t, raw.Date, int32(16864))
+	assert(t, raw.TimestampMillis, int64(1457067967891))
+	assert(t, raw.TimestampMicros, int64(1457067967891000))
+	assert(t, raw.Decimal, []byte{0x01, 0x3a})
+	assert(t, raw.Uuid, "123e4567-e89b-12d3-a456-426614174000")
+}
+
+func TestLogicalTypesDefaults(t *testing.T) {
+	writerSchema := MustParseSchema(`{"type": "record", "name": "Event", "fields": []}`)
+	readerSchema := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+        {"name": "timestamp", "type": {"type": "long", "logicalType": "timestamp-millis"}, "default": 1000}
+    ]}`)
+
+	reader := NewGenericDatumReader()
+	reader.SetSchema(writerSchema)
+	reader.SetReaderSchema(readerSchema)
+	record := NewGenericRecord(readerSchema)
+	assert(t, reader.Read(record, NewBinaryDecoder([]byte{})), nil)
+	assert(t, record.Get("timestamp"), time.Unix(1, 0).UTC())
+
+	specificReader := NewSpecificDatumReader()
+	specificReader.SetSchema(writerSchema)
+	specificReader.SetReaderSchema(readerSchema)
+	event := &struct{ Timestamp time.Time }{}
+	assert(t, specificReader.Read(event, NewBinaryDecoder([]byte{})), nil)
+	assert(t, event.Timestamp, time.Unix(1, 0).UTC())
+
+	rawEvent := &struct{ Timestamp int64 }{}
+	assert(t, specificReader.Read(rawEvent, NewBinaryDecoder([]byte{})), nil)
+	assert(t, rawEvent.Timestamp, int64(1000))
+}
@@ -0,0 +1,189 @@
+package avro
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProjectingDatumReader implements DatumReader and reads only selected fields of records into GenericRecords.
+// Fields are selected with dot separated paths like "user.address.city" or with a pruned reader schema, all other
+// fields are skipped without being decoded. Paths may go through unions, arrays and maps of records.
+// Each value passed to Read is expected to be a pointer.
+type ProjectingDatumReader struct {
+	paths        []string
+	writer       Schema
+	readerSchema Schema
+	generic      GenericDatumReader
+	err          error
+}
+
+// NewProjectingDatumReader creates a new ProjectingDatumReader that reads only fields with given paths. A path that
+// ends with a record field selects the whole record.
+func NewProjectingDatumReader(paths ...string) *ProjectingDatumReader {
+	return &ProjectingDatumReader{paths: paths}
+}
+
+// SetSchema sets the schema for this ProjectingDatumReader to know the data structure.
+// This is the schema the data was written with. Note that it must be called before calling Read.
+func (reader *ProjectingDatumReader) SetSchema(schema Schema) {
+	reader.writer = schema
+	reader.project()
+}
+
+// SetReaderSchema sets a pruned reader schema that selects fields to read instead of field paths. Data is resolved
+// according to Avro schema resolution rules, so the reader schema may also promote values.
+func (reader *ProjectingDatumReader) SetReaderSchema(schema Schema) {
+	reader.readerSchema = schema
+	reader.project()
+}
+
+// Read reads a single entry using this ProjectingDatumReader.
+// Accepts a value to fill with data and a Decoder to read from. Given value MUST be of pointer type.
+// May return an error indicating a read failure or an invalid projection.
+func (reader *ProjectingDatumReader) Read(v interface{}, dec Decoder) error {
+	if reader.err != nil {
+		return reader.err
+	}
+
+	return reader.generic.Read(v, dec)
+}
+
+func (reader *ProjectingDatumReader) project() {
+	reader.err = nil
+	if reader.writer == nil {
+		return
+	}
+
+	projected := reader.readerSchema
+	if projected == nil {
+		projected, reader.err = ProjectSchema(reader.writer, reader.paths...)
+		if reader.err != nil {
+			return
+		}
+	}
+
+	reader.generic.SetSchema(reader.writer)
+	reader.generic.SetReaderSchema(projected)
+}
+
+// projection is a tree of selected field names. A nil subtree selects the whole field.
+type projection map[string]projection
+
+// ProjectSchema returns a copy of a given record schema that contains only fields with given dot separated paths.
+// Records on the paths are copied with their names, so the result can be used as a reader schema for data written
+// with the given schema. Returns an error if any path does not exist.
+func ProjectSchema(schema Schema, paths ...string) (Schema, error) {
+	if actualSchema(schema).Type() != Record {
+		return nil, fmt.Errorf("Projection requires a record schema, got %s", schema.GetName())
+	}
+
+	root := make(projection)
+	for _, path := range paths {
+		names := strings.Split(path, ".")
+		node := root
+		for i, name := range names {
+			if name == "" {
+				return nil, fmt.Errorf("Invalid projection path %q", path)
+			}
+			if i == len(names)-1 {
+				node[name] = nil
+				break
+			}
+
+			child, exists := node[name]
+			if exists && child == nil {
+				break
+			}
+			if !exists {
+				child = make(projection)
+				node[name] = child
+			}
+			node = child
+		}
+	}
+
+	return pruneSchema(schema, root, "")
+}
+
+func pruneSchema(schema Schema, selected projection, path string) (Schema, error) {
+	schema = actualSchema(schema)
+	switch schema.Type() {
+	case Record:
+		return pruneRecord(schema.(*RecordSchema), selected, path)
+	case Array:
+		items, err := pruneSchema(schema.(*ArraySchema).Items, selected, path)
+		if err != nil {
+			return nil, err
+		}
+		return &ArraySchema{Items: items, Properties: schema.(*ArraySchema).Properties}, nil
+	case Map:
+		values, err := pruneSchema(schema.(*MapSchema).Values, selected, path)
+		if err != nil {
+			return nil, err
+		}
+		return &MapSchema{Values: values, Properties: schema.(*MapSchema).Properties}, nil
+	case Union:
+		// branches the projection does not apply to are kept as is
+		var firstErr error
+		pruned := false
+		union := &UnionSchema{Types: make([]Schema, len(schema.(*UnionSchema).Types))}
+		for i, branch := range schema.(*UnionSchema).Types {
+			prunedBranch, err := pruneSchema(branch, selected, path)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				union.Types[i] = branch
+				continue
+			}
+			union.Types[i] = prunedBranch
+			pruned = true
+		}
+		if !pruned {
+			return nil, firstErr
+		}
+		return union, nil
+	}
+
+	return nil, fmt.Errorf("Projection path %s does not point to a record", strings.TrimSuffix(path, "."))
+}
+
+func pruneRecord(record *RecordSchema, selected projection, path string) (Schema, error) {
+	fields := make(map[string]*SchemaField)
+	for _, field := range record.Fields {
+		fields[field.Name] = field
+	}
+	for name := range selected {
+		if _, exists := fields[name]; !exists {
+			return nil, fmt.Errorf("Unknown field %s%s in record %s", path, name, record.Name)
+		}
+	}
+
+	pruned := &RecordSchema{
+		Name:       record.Name,
+		Namespace:  record.Namespace,
+		Doc:        record.Doc,
+		Aliases:    record.Aliases,
+		Properties: record.Properties,
+	}
+	for _, field := range record.Fields {
+		subtree, exists := selected[field.Name]
+		if !exists {
+			continue
+		}
+		if subtree == nil {
+			pruned.Fields = append(pruned.Fields, field)
+			continue
+		}
+
+		prunedType, err := pruneSchema(field.Type, subtree, path+field.Name+".")
+		if err != nil {
+			return nil, err
+		}
+		prunedField := *field
+		prunedField.Type = prunedType
+		pruned.Fields = append(pruned.Fields, &prunedField)
+	}
+
+	return pruned, nil
+}
@@ -0,0 +1,130 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+)
+
+const projectionTestSchema = `{"type": "record", "name": "Event", "fields": [
+	{"name": "id", "type": "long"},
+	{"name": "payload", "type": "bytes"},
+	{"name": "user", "type": {"type": "record", "name": "User", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "address", "type": ["null", {"type": "record", "name": "Address", "fields": [
+			{"name": "street", "type": "string"},
+			{"name": "city", "type": "string"}
+		]}]},
+		{"name": "tags", "type": {"type": "array", "items": "string"}}
+	]}},
+	{"name": "visits", "type": {"type": "array", "items": {"type": "record", "name": "Visit", "fields": [
+		{"name": "page", "type": "string"},
+		{"name": "duration", "type": "int"}
+	]}}}
+]}`
+
+func projectionTestData(t *testing.T) (Schema, []byte) {
+	schema := MustParseSchema(projectionTestSchema)
+	userSchema := schema.(*RecordSchema).Fields[2].Type
+	addressSchema := userSchema.(*RecordSchema).Fields[1].Type.(*UnionSchema).Types[1]
+	visitSchema := schema.(*RecordSchema).Fields[3].Type.(*ArraySchema).Items
+
+	address := NewGenericRecord(addressSchema)
+	address.Set("street", "Main St")
+	address.Set("city", "Springfield")
+	user := NewGenericRecord(userSchema)
+	user.Set("name", "homer")
+	user.Set("address", address)
+	user.Set("tags", []interface{}{"a", "b"})
+	visit := NewGenericRecord(visitSchema)
+	visit.Set("page", "/index")
+	visit.Set("duration", int32(12))
+	event := NewGenericRecord(schema)
+	event.Set("id", int64(1))
+	event.Set("payload", []byte("lots of data"))
+	event.Set("user", user)
+	event.Set("visits", []interface{}{visit})
+
+	buffer := &bytes.Buffer{}
+	writer := NewGenericDatumWriter()
+	writer.SetSchema(schema)
+	assert(t, writer.Write(event, NewBinaryEncoder(buffer)), nil)
+	return schema, buffer.Bytes()
+}
+
+func TestProjectingDatumReader(t *testing.T) {
+	schema, data := projectionTestData(t)
+
+	reader := NewProjectingDatumReader("user.address.city", "visits.page", "id")
+	reader.SetSchema(schema)
+	dec := NewBinaryDecoder(data)
+	event := NewGenericRecord(schema)
+	assert(t, reader.Read(event, dec), nil)
+	assert(t, dec.Tell(), int64(len(data)))
+
+	assert(t, len(event.Schema().(*RecordSchema).Fields), 3)
+	assert(t, event.Get("id"), int64(1))
+	assert(t, event.Get("payload"), nil)
+
+	user := event.Get("user").(*GenericRecord)
+	assert(t, user.Get("name"), nil)
+	assert(t, user.Get("tags"), nil)
+	address := user.Get("address").(*GenericRecord)
+	assert(t, address.Get("city"), "Springfield")
+	assert(t, address.Get("street"), nil)
+
+	visits := event.Get("visits").([]interface{})
+	assert(t, len(visits), 1)
+	assert(t, visits[0].(*GenericRecord).Get("page"), "/index")
+	assert(t, visits[0].(*GenericRecord).Get("duration"), nil)
+}
+
+func TestProjectingDatumReaderWholeRecord(t *testing.T) {
+	schema, data := projectionTestData(t)
+
+	reader := NewProjectingDatumReader("user.name", "user")
+	reader.SetSchema(schema)
+	event := NewGenericRecord(schema)
+	assert(t, reader.Read(event, NewBinaryDecoder(data)), nil)
+
+	user := event.Get("user").(*GenericRecord)
+	assert(t, user.Get("name"), "homer")
+	assert(t, len(user.Get("tags").([]interface{})), 2)
+	assert(t, user.Get("address").(*GenericRecord).Get("street"), "Main St")
+}
+
+func TestProjectingDatumReaderReaderSchema(t *testing.T) {
+	schema, data := projectionTestData(t)
+
+	reader := NewProjectingDatumReader()
+	reader.SetReaderSchema(MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "id", "type": "double"},
+		{"name": "user", "type": {"type": "record", "name": "User", "fields": [{"name": "name", "type": "string"}]}}
+	]}`))
+	reader.SetSchema(schema)
+	event := NewGenericRecord(schema)
+	assert(t, reader.Read(event, NewBinaryDecoder(data)), nil)
+
+	assert(t, event.Get("id"), float64(1))
+	assert(t, event.Get("user").(*GenericRecord).Get("name"), "homer")
+	assert(t, event.Get("visits"), nil)
+}
+
+func TestProjectSchemaErrors(t *testing.T) {
+	schema := MustParseSchema(projectionTestSchema)
+
+	_, err := ProjectSchema(schema, "user.phone")
+	assert(t, err.Error(), "Unknown field user.phone in record User")
+
+	_, err = ProjectSchema(schema, "id.value")
+	assert(t, err.Error(), "Projection path id does not point to a record")
+
+	_, err = ProjectSchema(schema, "user..name")
+	assert(t, err.Error(), `Invalid projection path "user..name"`)
+
+	_, err = ProjectSchema(MustParseSchema(`"string"`), "name")
+	assert(t, err != nil, true)
+
+	reader := NewProjectingDatumReader("unknown")
+	reader.SetSchema(schema)
+	assert(t, reader.Read(NewGenericRecord(schema), NewBinaryDecoder(nil)).Error(), "Unknown field unknown in record Event")
+}
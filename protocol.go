@@ -0,0 +1,571 @@
+package avro
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+const (
+	protocolProtocolField = "protocol"
+	protocolTypesField    = "types"
+	protocolMessagesField = "messages"
+	messageRequestField   = "request"
+	messageResponseField  = "response"
+	messageErrorsField    = "errors"
+	messageOneWayField    = "one-way"
+	fieldOrderField       = "order"
+	fieldOrderAscending   = "ascending"
+)
+
+// Protocol is an Avro protocol (https://avro.apache.org/docs/current/spec.html#Protocol+Declaration) that describes
+// RPC messages along with the types they use.
+type Protocol struct {
+	Name      string
+	Namespace string
+	Doc       string
+
+	// Named types declared by this protocol in declaration order.
+	Types []Schema
+
+	// Messages by names.
+	Messages map[string]*Message
+
+	// message names in declaration order
+	messageNames []string
+	// custom non-reserved properties
+	properties map[string]interface{}
+	// JSON rendered by renderProtocol that identifies this protocol
+	json string
+}
+
+// Message is a single RPC message of a Protocol.
+type Message struct {
+	Name string
+	Doc  string
+
+	// Request parameters as fields of a record named after this message.
+	Request *RecordSchema
+
+	// Response schema, NullSchema for messages without a response.
+	Response Schema
+
+	// Errors union that always starts with "string" for system errors followed by errors declared by this message.
+	Errors *UnionSchema
+
+	// OneWay messages have no response and no errors.
+	OneWay bool
+
+	// custom non-reserved properties
+	properties map[string]interface{}
+}
+
+// ParseProtocolFile parses a given .avpr file.
+// May return an error if protocol is not parsable or file does not exist.
+func ParseProtocolFile(file string) (*Protocol, error) {
+	fileContents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseProtocol(string(fileContents))
+}
+
+// ParseProtocol parses a given protocol JSON.
+// May return an error if protocol is not parsable or has insufficient information about any type.
+func ParseProtocol(rawProtocol string) (*Protocol, error) {
+	return parseProtocolWithRegistry(rawProtocol, make(map[string]Schema))
+}
+
+// parseProtocolWithRegistry parses a given protocol JSON using the provided registry for type lookup.
+func parseProtocolWithRegistry(rawProtocol string, registry map[string]Schema) (*Protocol, error) {
+	var v map[string]interface{}
+	if err := json.Unmarshal([]byte(rawProtocol), &v); err != nil {
+		return nil, err
+	}
+
+	name, ok := v[protocolProtocolField].(string)
+	if !ok {
+		return nil, fmt.Errorf("Protocol name missing")
+	}
+	protocol := &Protocol{Name: name, Messages: make(map[string]*Message)}
+	setOptionalField(&protocol.Namespace, v, schemaNamespaceField)
+	setOptionalField(&protocol.Doc, v, schemaDocField)
+	protocol.properties = propertiesExcept(v, protocolProtocolField, schemaNamespaceField, schemaDocField,
+		protocolTypesField, protocolMessagesField)
+
+	if types, ok := v[protocolTypesField].([]interface{}); ok {
+		for _, rawType := range types {
+			schema, err := schemaByType(rawType, registry, protocol.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			protocol.Types = append(protocol.Types, schema)
+		}
+	}
+
+	if messages, ok := v[protocolMessagesField].(map[string]interface{}); ok {
+		// messages are parsed in declaration order as types declared inline are registered by the first of them
+		var raw struct {
+			Messages json.RawMessage `json:"messages"`
+		}
+		if err := json.Unmarshal([]byte(rawProtocol), &raw); err != nil {
+			return nil, err
+		}
+		names, err := objectKeys(raw.Messages)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range names {
+			message, err := parseMessage(name, messages[name], registry, protocol.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			protocol.Messages[name] = message
+			protocol.messageNames = append(protocol.messageNames, name)
+		}
+	}
+
+	protocol.json = renderProtocol(protocol)
+	return protocol, nil
+}
+
+// objectKeys returns keys of a given JSON object in declaration order.
+func objectKeys(object json.RawMessage) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(object))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key.(string))
+	}
+	return keys, nil
+}
+
+// MustParseProtocol is like ParseProtocol, but panics if the given protocol cannot be parsed.
+func MustParseProtocol(rawProtocol string) *Protocol {
+	p, err := ParseProtocol(rawProtocol)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func parseMessage(name string, i interface{}, registry map[string]Schema, namespace string) (*Message, error) {
+	v, ok := i.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid message %s", name)
+	}
+
+	message := &Message{Name: name, Request: &RecordSchema{Name: name}}
+	setOptionalField(&message.Doc, v, schemaDocField)
+	message.properties = propertiesExcept(v, schemaDocField, messageRequestField, messageResponseField,
+		messageErrorsField, messageOneWayField)
+
+	params, ok := v[messageRequestField].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Message %s request missing", name)
+	}
+	for _, param := range params {
+		field, err := parseSchemaField(param, registry, namespace)
+		if err != nil {
+			return nil, err
+		}
+		message.Request.Fields = append(message.Request.Fields, field)
+	}
+
+	response, err := schemaByType(v[messageResponseField], registry, namespace)
+	if err != nil {
+		return nil, err
+	}
+	message.Response = response
+
+	message.Errors = &UnionSchema{Types: []Schema{new(StringSchema)}}
+	if errors, ok := v[messageErrorsField].([]interface{}); ok {
+		for _, rawError := range errors {
+			schema, err := schemaByType(rawError, registry, namespace)
+			if err != nil {
+				return nil, err
+			}
+			message.Errors.Types = append(message.Errors.Types, schema)
+		}
+	}
+
+	if oneWay, ok := v[messageOneWayField].(bool); ok && oneWay {
+		if response.Type() != Null || len(message.Errors.Types) > 1 {
+			return nil, fmt.Errorf("One-way message %s can't have a response or errors", name)
+		}
+		message.OneWay = true
+	}
+
+	return message, nil
+}
+
+// MessageNames returns names of all messages of this Protocol in alphabetical order.
+func (p *Protocol) MessageNames() []string {
+	names := make([]string, 0, len(p.Messages))
+	for name := range p.Messages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MD5 returns the MD5 hash of this Protocol JSON that identifies it during RPC handshake. The hash is the same
+// the Java implementation computes for the same protocol.
+func (p *Protocol) MD5() []byte {
+	hash := md5.Sum([]byte(p.json))
+	return hash[:]
+}
+
+// String returns a compact JSON representation of this Protocol laid out like the Java implementation does in
+// Protocol.toString.
+func (p *Protocol) String() string {
+	return p.json
+}
+
+// propertiesExcept gets custom properties of a given JSON object skipping the reserved ones.
+func propertiesExcept(v map[string]interface{}, reserved ...string) map[string]interface{} {
+	props := make(map[string]interface{})
+	for name, value := range v {
+		props[name] = value
+	}
+	for _, name := range reserved {
+		delete(props, name)
+	}
+	return props
+}
+
+// renderProtocol renders a given Protocol the way the Java implementation does in Protocol.toString, so that
+// protocol hashes match in handshakes with Java peers. Named types are defined on first use and referenced by
+// names afterwards. Custom properties are written in alphabetical order as their declaration order is not kept.
+func renderProtocol(p *Protocol) string {
+	w := &protocolWriter{space: p.Namespace, named: make(map[Schema]string)}
+	w.buf.WriteByte('{')
+	w.key(protocolProtocolField)
+	w.value(p.Name)
+	if p.Namespace != "" {
+		w.key(schemaNamespaceField)
+		w.value(p.Namespace)
+	}
+	if p.Doc != "" {
+		w.key(schemaDocField)
+		w.value(p.Doc)
+	}
+	w.properties(p.properties)
+
+	// declared types go first followed by the types declared inline in messages
+	w.key(protocolTypesField)
+	w.buf.WriteByte('[')
+	for _, schema := range p.Types {
+		w.define(schema)
+	}
+	for _, name := range p.messageNames {
+		message := p.Messages[name]
+		for _, field := range message.Request.Fields {
+			w.define(field.Type)
+		}
+		w.define(message.Response)
+		for _, schema := range message.Errors.Types[1:] {
+			w.define(schema)
+		}
+	}
+	w.buf.WriteByte(']')
+
+	w.key(protocolMessagesField)
+	w.buf.WriteByte('{')
+	for _, name := range p.messageNames {
+		w.key(name)
+		w.message(p.Messages[name])
+	}
+	w.buf.WriteString("}}")
+	return w.buf.String()
+}
+
+// protocolWriter writes JSON of a single Protocol for renderProtocol.
+type protocolWriter struct {
+	buf bytes.Buffer
+
+	// current namespace that names are written relative to
+	space string
+	// full names of already defined named schemas
+	named map[Schema]string
+}
+
+// define writes definitions of named schemas used by a given schema that are not defined yet as array elements.
+func (w *protocolWriter) define(schema Schema) {
+	switch s := actualSchema(schema).(type) {
+	case *RecordSchema, *EnumSchema, *FixedSchema:
+		if _, ok := w.named[s]; !ok {
+			w.separate()
+			w.schema(s)
+		}
+	case *ArraySchema:
+		w.define(s.Items)
+	case *MapSchema:
+		w.define(s.Values)
+	case *UnionSchema:
+		for _, t := range s.Types {
+			w.define(t)
+		}
+	}
+}
+
+func (w *protocolWriter) message(message *Message) {
+	w.buf.WriteByte('{')
+	if message.Doc != "" {
+		w.key(schemaDocField)
+		w.value(message.Doc)
+	}
+	w.properties(message.properties)
+	w.key(messageRequestField)
+	w.fields(message.Request.Fields)
+	w.key(messageResponseField)
+	if message.OneWay {
+		w.value(typeNull)
+		w.key(messageOneWayField)
+		w.value(true)
+	} else {
+		w.schema(message.Response)
+		if len(message.Errors.Types) > 1 {
+			w.key(messageErrorsField)
+			w.buf.WriteByte('[')
+			for _, schema := range message.Errors.Types[1:] {
+				w.separate()
+				w.schema(schema)
+			}
+			w.buf.WriteByte(']')
+		}
+	}
+	w.buf.WriteByte('}')
+}
+
+func (w *protocolWriter) schema(schema Schema) {
+	switch s := actualSchema(schema).(type) {
+	case *RecordSchema:
+		if w.reference(s) {
+			return
+		}
+		typ := typeRecord
+		if s.isError {
+			typ = typeError
+		}
+		w.open(typ)
+		space := w.name(s, s.Name, s.Namespace)
+		saved := w.space
+		w.space = space
+		if s.Doc != "" {
+			w.key(schemaDocField)
+			w.value(s.Doc)
+		}
+		w.key(schemaFieldsField)
+		w.fields(s.Fields)
+		w.properties(s.Properties)
+		w.aliases(s.Aliases, space)
+		w.buf.WriteByte('}')
+		w.space = saved
+	case *EnumSchema:
+		if w.reference(s) {
+			return
+		}
+		w.open(typeEnum)
+		space := w.name(s, s.Name, s.Namespace)
+		if s.Doc != "" {
+			w.key(schemaDocField)
+			w.value(s.Doc)
+		}
+		w.key(schemaSymbolsField)
+		w.value(s.Symbols)
+		w.properties(s.Properties, schemaDefaultField)
+		w.aliases(s.Aliases, space)
+		if def, ok := s.Properties[schemaDefaultField]; ok {
+			w.key(schemaDefaultField)
+			w.value(def)
+		}
+		w.buf.WriteByte('}')
+	case *FixedSchema:
+		if w.reference(s) {
+			return
+		}
+		w.open(typeFixed)
+		space := w.name(s, s.Name, s.Namespace)
+		w.key(schemaSizeField)
+		w.value(s.Size)
+		w.properties(s.Properties)
+		w.aliases(s.Aliases, space)
+		w.buf.WriteByte('}')
+	case *ArraySchema:
+		w.open(typeArray)
+		w.key(schemaItemsField)
+		w.schema(s.Items)
+		w.properties(s.Properties)
+		w.buf.WriteByte('}')
+	case *MapSchema:
+		w.open(typeMap)
+		w.key(schemaValuesField)
+		w.schema(s.Values)
+		w.properties(s.Properties)
+		w.buf.WriteByte('}')
+	case *UnionSchema:
+		w.buf.WriteByte('[')
+		for _, t := range s.Types {
+			w.separate()
+			w.schema(t)
+		}
+		w.buf.WriteByte(']')
+	default:
+		// primitives are written either as a plain name or along with their logical type
+		w.value(s)
+	}
+}
+
+func (w *protocolWriter) fields(fields []*SchemaField) {
+	w.buf.WriteByte('[')
+	for _, field := range fields {
+		w.separate()
+		w.buf.WriteByte('{')
+		w.key(schemaNameField)
+		w.value(field.Name)
+		w.key(schemaTypeField)
+		w.schema(field.Type)
+		if field.Doc != "" {
+			w.key(schemaDocField)
+			w.value(field.Doc)
+		}
+		if def, ok := field.Properties[schemaDefaultField]; ok {
+			w.key(schemaDefaultField)
+			w.value(def)
+		}
+		if order, ok := field.Properties[fieldOrderField]; ok && order != fieldOrderAscending {
+			w.key(fieldOrderField)
+			w.value(order)
+		}
+		if len(field.Aliases) > 0 {
+			w.key(schemaAliasesField)
+			w.value(field.Aliases)
+		}
+		w.properties(field.Properties, schemaDefaultField, fieldOrderField)
+		w.buf.WriteByte('}')
+	}
+	w.buf.WriteByte(']')
+}
+
+// reference writes the name of a given named schema if it is already defined.
+func (w *protocolWriter) reference(schema Schema) bool {
+	fullName, ok := w.named[schema]
+	if ok {
+		w.value(qualifiedName(fullName, w.space))
+	}
+	return ok
+}
+
+// name writes the name and namespace of a given named schema that is being defined and returns its namespace.
+func (w *protocolWriter) name(schema Schema, name string, namespace string) string {
+	if namespace == "" {
+		namespace = w.space
+	}
+	fullName := getFullName(name, namespace)
+	w.named[schema] = fullName
+
+	space, shortName := splitName(fullName)
+	w.key(schemaNameField)
+	w.value(shortName)
+	if space != w.space {
+		w.key(schemaNamespaceField)
+		w.value(space)
+	}
+	return space
+}
+
+func (w *protocolWriter) aliases(aliases []string, space string) {
+	if len(aliases) == 0 {
+		return
+	}
+
+	w.key(schemaAliasesField)
+	w.buf.WriteByte('[')
+	for _, alias := range aliases {
+		w.separate()
+		w.value(qualifiedName(alias, space))
+	}
+	w.buf.WriteByte(']')
+}
+
+func (w *protocolWriter) properties(props map[string]interface{}, skip ...string) {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		skipped := false
+		for _, s := range skip {
+			skipped = skipped || s == name
+		}
+		if !skipped {
+			w.key(name)
+			w.value(props[name])
+		}
+	}
+}
+
+func (w *protocolWriter) open(typ string) {
+	w.buf.WriteByte('{')
+	w.key(schemaTypeField)
+	w.value(typ)
+}
+
+func (w *protocolWriter) key(name string) {
+	w.separate()
+	w.value(name)
+	w.buf.WriteByte(':')
+}
+
+// separate writes a comma unless an object or array has just started.
+func (w *protocolWriter) separate() {
+	if last := w.buf.Bytes()[w.buf.Len()-1]; last != '{' && last != '[' {
+		w.buf.WriteByte(',')
+	}
+}
+
+func (w *protocolWriter) value(v interface{}) {
+	enc := json.NewEncoder(&w.buf)
+	enc.SetEscapeHTML(false)
+	// values are either parsed from JSON or schemas that always marshal
+	enc.Encode(v)
+	// Encode terminates each value with a newline
+	w.buf.Truncate(w.buf.Len() - 1)
+}
+
+// splitName splits a given full name into namespace and short name.
+func splitName(fullName string) (string, string) {
+	if i := strings.LastIndex(fullName, "."); i >= 0 {
+		return fullName[:i], fullName[i+1:]
+	}
+	return "", fullName
+}
+
+// qualifiedName returns a given full name relative to a given namespace, i.e. short name if it belongs to the
+// namespace or has none.
+func qualifiedName(fullName string, space string) string {
+	if namespace, shortName := splitName(fullName); namespace == "" || namespace == space {
+		return shortName
+	}
+	return fullName
+}
@@ -59,6 +59,7 @@ const (
 
 const (
 	typeRecord  = "record"
+	typeError   = "error"
 	typeUnion   = "union"
 	typeEnum    = "enum"
 	typeArray   = "array"
@@ -107,10 +108,17 @@ type Schema interface {
 }
 
 // StringSchema implements Schema and represents Avro string type.
-type StringSchema struct{}
+type StringSchema struct {
+	// Optional logical type annotation (uuid).
+	LogicalType *LogicalType
+}
 
 // Returns a JSON representation of StringSchema.
-func (*StringSchema) String() string {
+func (s *StringSchema) String() string {
+	if s.LogicalType != nil {
+		bytes, _ := s.MarshalJSON()
+		return string(bytes)
+	}
 	return `{"type": "string"}`
 }
 
@@ -130,21 +138,35 @@ func (*StringSchema) Prop(key string) (interface{}, bool) {
 }
 
 // Validate checks whether the given value is writeable to this schema.
-func (*StringSchema) Validate(v reflect.Value) bool {
+func (s *StringSchema) Validate(v reflect.Value) bool {
+	if s.LogicalType != nil && s.LogicalType.accepts(v) {
+		return true
+	}
+
 	_, ok := dereference(v).Interface().(string)
 	return ok
 }
 
 // MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*StringSchema) MarshalJSON() ([]byte, error) {
+func (s *StringSchema) MarshalJSON() ([]byte, error) {
+	if s.LogicalType != nil {
+		return s.LogicalType.marshalJSON(typeString)
+	}
 	return []byte(`"string"`), nil
 }
 
 // BytesSchema implements Schema and represents Avro bytes type.
-type BytesSchema struct{}
+type BytesSchema struct {
+	// Optional logical type annotation (decimal).
+	LogicalType *LogicalType
+}
 
 // String returns a JSON representation of BytesSchema.
-func (*BytesSchema) String() string {
+func (s *BytesSchema) String() string {
+	if s.LogicalType != nil {
+		bytes, _ := s.MarshalJSON()
+		return string(bytes)
+	}
 	return `{"type": "bytes"}`
 }
 
@@ -164,22 +186,36 @@ func (*BytesSchema) Prop(key string) (interface{}, bool) {
 }
 
 // Validate checks whether the given value is writeable to this schema.
-func (*BytesSchema) Validate(v reflect.Value) bool {
+func (s *BytesSchema) Validate(v reflect.Value) bool {
+	if s.LogicalType != nil && s.LogicalType.accepts(v) {
+		return true
+	}
+
 	v = dereference(v)
 
 	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8
 }
 
 // MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*BytesSchema) MarshalJSON() ([]byte, error) {
+func (s *BytesSchema) MarshalJSON() ([]byte, error) {
+	if s.LogicalType != nil {
+		return s.LogicalType.marshalJSON(typeBytes)
+	}
 	return []byte(`"bytes"`), nil
 }
 
 // IntSchema implements Schema and represents Avro int type.
-type IntSchema struct{}
+type IntSchema struct {
+	// Optional logical type annotation (date or time-millis).
+	LogicalType *LogicalType
+}
 
 // String returns a JSON representation of IntSchema.
-func (*IntSchema) String() string {
+func (s *IntSchema) String() string {
+	if s.LogicalType != nil {
+		bytes, _ := s.MarshalJSON()
+		return string(bytes)
+	}
 	return `{"type": "int"}`
 }
 
@@ -199,20 +235,34 @@ func (*IntSchema) Prop(key string) (interface{}, bool) {
 }
 
 // Validate checks whether the given value is writeable to this schema.
-func (*IntSchema) Validate(v reflect.Value) bool {
+func (s *IntSchema) Validate(v reflect.Value) bool {
+	if s.LogicalType != nil && s.LogicalType.accepts(v) {
+		return true
+	}
+
 	return reflect.TypeOf(dereference(v).Interface()).Kind() == reflect.Int32
 }
 
 // MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*IntSchema) MarshalJSON() ([]byte, error) {
+func (s *IntSchema) MarshalJSON() ([]byte, error) {
+	if s.LogicalType != nil {
+		return s.LogicalType.marshalJSON(typeInt)
+	}
 	return []byte(`"int"`), nil
 }
 
 // LongSchema implements Schema and represents Avro long type.
-type LongSchema struct{}
+type LongSchema struct {
+	// Optional logical type annotation (time-micros or timestamps).
+	LogicalType *LogicalType
+}
 
 // Returns a JSON representation of LongSchema.
-func (*LongSchema) String() string {
+func (s *LongSchema) String() string {
+	if s.LogicalType != nil {
+		bytes, _ := s.MarshalJSON()
+		return string(bytes)
+	}
 	return `{"type": "long"}`
 }
 
@@ -232,12 +282,19 @@ func (*LongSchema) Prop(key string) (interface{}, bool) {
 }
 
 // Validate checks whether the given value is writeable to this schema.
-func (*LongSchema) Validate(v reflect.Value) bool {
+func (s *LongSchema) Validate(v reflect.Value) bool {
+	if s.LogicalType != nil && s.LogicalType.accepts(v) {
+		return true
+	}
+
 	return reflect.TypeOf(dereference(v).Interface()).Kind() == reflect.Int64
 }
 
 // MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*LongSchema) MarshalJSON() ([]byte, error) {
+func (s *LongSchema) MarshalJSON() ([]byte, error) {
+	if s.LogicalType != nil {
+		return s.LogicalType.marshalJSON(typeLong)
+	}
 	return []byte(`"long"`), nil
 }
 
@@ -406,6 +463,9 @@ type RecordSchema struct {
 	Aliases    []string `json:"aliases,omitempty"`
 	Properties map[string]interface{}
 	Fields     []*SchemaField `json:"fields"`
+
+	// declared as a protocol error
+	isError bool
 }
 
 // String returns a JSON representation of RecordSchema.
@@ -418,22 +478,32 @@ func (s *RecordSchema) String() string {
 	return string(bytes)
 }
 
-// MarshalJSON serializes the given schema as JSON.
+// MarshalJSON serializes the given schema as JSON. Named types used more than once are defined on first use and
+// referenced by full name afterwards.
 func (s *RecordSchema) MarshalJSON() ([]byte, error) {
+	return namedTypes{}.marshal(s, "")
+}
+
+func (s *RecordSchema) marshalJSON(defined namedTypes, namespace string) ([]byte, error) {
+	fields := make([]*fieldJSON, len(s.Fields))
+	for i, field := range s.Fields {
+		fields[i] = &fieldJSON{field: field, defined: defined, namespace: namespace}
+	}
+
 	return json.Marshal(struct {
-		Type      string         `json:"type,omitempty"`
-		Namespace string         `json:"namespace,omitempty"`
-		Name      string         `json:"name,omitempty"`
-		Doc       string         `json:"doc,omitempty"`
-		Aliases   []string       `json:"aliases,omitempty"`
-		Fields    []*SchemaField `json:"fields"`
+		Type      string       `json:"type,omitempty"`
+		Namespace string       `json:"namespace,omitempty"`
+		Name      string       `json:"name,omitempty"`
+		Doc       string       `json:"doc,omitempty"`
+		Aliases   []string     `json:"aliases,omitempty"`
+		Fields    []*fieldJSON `json:"fields"`
 	}{
 		Type:      "record",
 		Namespace: s.Namespace,
 		Name:      s.Name,
 		Doc:       s.Doc,
 		Aliases:   s.Aliases,
-		Fields:    s.Fields,
+		Fields:    fields,
 	})
 }
 
@@ -541,6 +611,7 @@ func (s *RecursiveSchema) MarshalJSON() ([]byte, error) {
 type SchemaField struct {
 	Name       string      `json:"name,omitempty"`
 	Doc        string      `json:"doc,omitempty"`
+	Aliases    []string    `json:"aliases,omitempty"`
 	Default    interface{} `json:"default"`
 	Type       Schema      `json:"type,omitempty"`
 	Properties map[string]interface{}
@@ -558,33 +629,52 @@ func (this *SchemaField) Prop(key string) (interface{}, bool) {
 
 // MarshalJSON serializes the given schema field as JSON.
 func (s *SchemaField) MarshalJSON() ([]byte, error) {
+	return s.marshalJSON(namedTypes{}, "")
+}
+
+func (s *SchemaField) marshalJSON(defined namedTypes, namespace string) ([]byte, error) {
 	if s.Type.Type() == Null || (s.Type.Type() == Union && s.Type.(*UnionSchema).Types[0].Type() == Null) {
 		return json.Marshal(struct {
 			Name    string      `json:"name,omitempty"`
 			Doc     string      `json:"doc,omitempty"`
+			Aliases []string    `json:"aliases,omitempty"`
 			Default interface{} `json:"default"`
-			Type    Schema      `json:"type,omitempty"`
+			Type    *schemaJSON `json:"type,omitempty"`
 		}{
 			Name:    s.Name,
 			Doc:     s.Doc,
+			Aliases: s.Aliases,
 			Default: s.Default,
-			Type:    s.Type,
+			Type:    defined.nested(s.Type, namespace),
 		})
 	}
 
 	return json.Marshal(struct {
 		Name    string      `json:"name,omitempty"`
 		Doc     string      `json:"doc,omitempty"`
+		Aliases []string    `json:"aliases,omitempty"`
 		Default interface{} `json:"default,omitempty"`
-		Type    Schema      `json:"type,omitempty"`
+		Type    *schemaJSON `json:"type,omitempty"`
 	}{
 		Name:    s.Name,
 		Doc:     s.Doc,
+		Aliases: s.Aliases,
 		Default: s.Default,
-		Type:    s.Type,
+		Type:    defined.nested(s.Type, namespace),
 	})
 }
 
+// fieldJSON serializes a field of a record that is being serialized.
+type fieldJSON struct {
+	field     *SchemaField
+	defined   namedTypes
+	namespace string
+}
+
+func (f *fieldJSON) MarshalJSON() ([]byte, error) {
+	return f.field.marshalJSON(f.defined, f.namespace)
+}
+
 // String returns a JSON representation of SchemaField.
 func (s *SchemaField) String() string {
 	return fmt.Sprintf("[SchemaField: Name: %s, Doc: %s, Default: %v, Type: %s]", s.Name, s.Doc, s.Default, s.Type)
@@ -631,10 +721,58 @@ func (s *EnumSchema) Prop(key string) (interface{}, bool) {
 	return nil, false
 }
 
-// Validate checks whether the given value is writeable to this schema.
-func (*EnumSchema) Validate(v reflect.Value) bool {
-	//TODO implement
-	return true
+// Validate checks whether the given value is writeable to this schema: a GenericEnum, an integer within the range
+// of enum symbols or a string that is one of enum symbols.
+func (s *EnumSchema) Validate(v reflect.Value) bool {
+	_, ok := s.indexOf(v)
+	return ok
+}
+
+// indexOf returns the index of the enum symbol a given Go value represents and whether the value represents any.
+func (s *EnumSchema) indexOf(v reflect.Value) (int32, bool) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return 0, false
+	}
+	if v.CanInterface() {
+		switch enum := v.Interface().(type) {
+		case *GenericEnum:
+			if enum == nil {
+				return 0, false
+			}
+			return enum.GetIndex(), true
+		case GenericEnum:
+			return enum.index, true
+		}
+	}
+
+	v = dereference(v)
+	var index int64
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		index = v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt32 {
+			return 0, false
+		}
+		index = int64(v.Uint())
+	case reflect.String:
+		for i, symbol := range s.Symbols {
+			if symbol == v.String() {
+				return int32(i), true
+			}
+		}
+		return 0, false
+	default:
+		return 0, false
+	}
+
+	if index < 0 || index >= int64(len(s.Symbols)) {
+		return 0, false
+	}
+	return int32(index), true
 }
 
 // MarshalJSON serializes the given schema as JSON.
@@ -644,12 +782,14 @@ func (s *EnumSchema) MarshalJSON() ([]byte, error) {
 		Namespace string   `json:"namespace,omitempty"`
 		Name      string   `json:"name,omitempty"`
 		Doc       string   `json:"doc,omitempty"`
+		Aliases   []string `json:"aliases,omitempty"`
 		Symbols   []string `json:"symbols,omitempty"`
 	}{
 		Type:      "enum",
 		Namespace: s.Namespace,
 		Name:      s.Name,
 		Doc:       s.Doc,
+		Aliases:   s.Aliases,
 		Symbols:   s.Symbols,
 	})
 }
@@ -701,12 +841,16 @@ func (s *ArraySchema) Validate(v reflect.Value) bool {
 
 // MarshalJSON serializes the given schema as JSON.
 func (s *ArraySchema) MarshalJSON() ([]byte, error) {
+	return s.marshalJSON(namedTypes{}, "")
+}
+
+func (s *ArraySchema) marshalJSON(defined namedTypes, namespace string) ([]byte, error) {
 	return json.Marshal(struct {
-		Type  string `json:"type,omitempty"`
-		Items Schema `json:"items,omitempty"`
+		Type  string      `json:"type,omitempty"`
+		Items *schemaJSON `json:"items,omitempty"`
 	}{
 		Type:  "array",
-		Items: s.Items,
+		Items: defined.nested(s.Items, namespace),
 	})
 }
 
@@ -755,12 +899,16 @@ func (s *MapSchema) Validate(v reflect.Value) bool {
 
 // MarshalJSON serializes the given schema as JSON.
 func (s *MapSchema) MarshalJSON() ([]byte, error) {
+	return s.marshalJSON(namedTypes{}, "")
+}
+
+func (s *MapSchema) marshalJSON(defined namedTypes, namespace string) ([]byte, error) {
 	return json.Marshal(struct {
-		Type   string `json:"type,omitempty"`
-		Values Schema `json:"values,omitempty"`
+		Type   string      `json:"type,omitempty"`
+		Values *schemaJSON `json:"values,omitempty"`
 	}{
 		Type:   "map",
-		Values: s.Values,
+		Values: defined.nested(s.Values, namespace),
 	})
 }
 
@@ -821,15 +969,31 @@ func (s *UnionSchema) Validate(v reflect.Value) bool {
 
 // MarshalJSON serializes the given schema as JSON.
 func (s *UnionSchema) MarshalJSON() ([]byte, error) {
-	return json.Marshal(s.Types)
+	return s.marshalJSON(namedTypes{}, "")
+}
+
+func (s *UnionSchema) marshalJSON(defined namedTypes, namespace string) ([]byte, error) {
+	if s.Types == nil {
+		return json.Marshal(s.Types)
+	}
+
+	types := make([]*schemaJSON, len(s.Types))
+	for i, schema := range s.Types {
+		types[i] = defined.nested(schema, namespace)
+	}
+	return json.Marshal(types)
 }
 
 // FixedSchema implements Schema and represents Avro fixed type.
 type FixedSchema struct {
 	Namespace  string
 	Name       string
+	Aliases    []string
 	Size       int
 	Properties map[string]interface{}
+
+	// Optional logical type annotation (decimal or duration).
+	LogicalType *LogicalType
 }
 
 // String returns a JSON representation of FixedSchema.
@@ -864,6 +1028,10 @@ func (s *FixedSchema) Prop(key string) (interface{}, bool) {
 
 // Validate checks whether the given value is writeable to this schema.
 func (s *FixedSchema) Validate(v reflect.Value) bool {
+	if s.LogicalType != nil && s.LogicalType.accepts(v) {
+		return true
+	}
+
 	v = dereference(v)
 
 	return (v.Kind() == reflect.Array || v.Kind() == reflect.Slice) && v.Type().Elem().Kind() == reflect.Uint8 && v.Len() == s.Size
@@ -871,15 +1039,98 @@ func (s *FixedSchema) Validate(v reflect.Value) bool {
 
 // MarshalJSON serializes the given schema as JSON.
 func (s *FixedSchema) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		Type string `json:"type,omitempty"`
-		Size int    `json:"size,omitempty"`
-		Name string `json:"name,omitempty"`
+	fixed := struct {
+		Type        string   `json:"type,omitempty"`
+		Size        int      `json:"size,omitempty"`
+		Name        string   `json:"name,omitempty"`
+		Namespace   string   `json:"namespace,omitempty"`
+		Aliases     []string `json:"aliases,omitempty"`
+		LogicalType string   `json:"logicalType,omitempty"`
+		Precision   int      `json:"precision,omitempty"`
+		Scale       int      `json:"scale,omitempty"`
 	}{
-		Type: "fixed",
-		Size: s.Size,
-		Name: s.Name,
-	})
+		Type:      "fixed",
+		Size:      s.Size,
+		Name:      s.Name,
+		Namespace: s.Namespace,
+		Aliases:   s.Aliases,
+	}
+	if s.LogicalType != nil {
+		fixed.LogicalType = s.LogicalType.Name
+		fixed.Precision = s.LogicalType.Precision
+		fixed.Scale = s.LogicalType.Scale
+	}
+
+	return json.Marshal(fixed)
+}
+
+// namedTypes holds the full names of named types already defined in a schema being serialized as JSON, so that each
+// of them is defined once and referenced by full name afterwards as the specification requires.
+type namedTypes map[Schema]string
+
+// jsonMarshaler is implemented by schemas that contain other schemas to serialize them as JSON along with the named
+// types already defined and the namespace they are nested in.
+type jsonMarshaler interface {
+	marshalJSON(defined namedTypes, namespace string) ([]byte, error)
+}
+
+// marshal serializes a given schema nested in a given namespace as JSON, referencing it by full name if it is a named
+// type already defined.
+func (defined namedTypes) marshal(schema Schema, namespace string) ([]byte, error) {
+	if recursive, ok := schema.(*RecursiveSchema); ok {
+		if fullName, ok := defined[recursive.Actual]; ok {
+			return json.Marshal(fullName)
+		}
+	}
+	if fullName, ok := defined[schema]; ok {
+		return json.Marshal(fullName)
+	}
+
+	var fullName string
+	switch sch := schema.(type) {
+	case *RecordSchema:
+		fullName = definedName(sch.Name, sch.Namespace, namespace)
+	case *EnumSchema:
+		fullName = definedName(sch.Name, sch.Namespace, namespace)
+	case *FixedSchema:
+		fullName = definedName(sch.Name, sch.Namespace, namespace)
+	}
+	if fullName != "" {
+		defined[schema] = fullName
+		namespace, _ = splitName(fullName)
+	}
+
+	if marshaler, ok := schema.(jsonMarshaler); ok {
+		return marshaler.marshalJSON(defined, namespace)
+	}
+	return json.Marshal(schema)
+}
+
+// definedName returns the full name of a named type defined with a given name and namespace in an enclosing namespace.
+func definedName(name string, namespace string, enclosing string) string {
+	if namespace == "" {
+		namespace = enclosing
+	}
+	return getFullName(name, namespace)
+}
+
+// nested returns a schema nested in a given namespace to be serialized as JSON with the named types defined so far,
+// or nil if there is no such schema.
+func (defined namedTypes) nested(schema Schema, namespace string) *schemaJSON {
+	if schema == nil {
+		return nil
+	}
+	return &schemaJSON{schema: schema, defined: defined, namespace: namespace}
+}
+
+type schemaJSON struct {
+	schema    Schema
+	defined   namedTypes
+	namespace string
+}
+
+func (s *schemaJSON) MarshalJSON() ([]byte, error) {
+	return s.defined.marshal(s.schema, s.namespace)
 }
 
 // GetFullName returns a fully-qualified name for a schema if possible. The format is namespace.name.
@@ -981,17 +1232,17 @@ func schemaByType(i interface{}, registry map[string]Schema, namespace string) (
 		case typeBoolean:
 			return new(BooleanSchema), nil
 		case typeInt:
-			return new(IntSchema), nil
+			return &IntSchema{LogicalType: parseLogicalType(v, Int, 0)}, nil
 		case typeLong:
-			return new(LongSchema), nil
+			return &LongSchema{LogicalType: parseLogicalType(v, Long, 0)}, nil
 		case typeFloat:
 			return new(FloatSchema), nil
 		case typeDouble:
 			return new(DoubleSchema), nil
 		case typeBytes:
-			return new(BytesSchema), nil
+			return &BytesSchema{LogicalType: parseLogicalType(v, Bytes, 0)}, nil
 		case typeString:
-			return new(StringSchema), nil
+			return &StringSchema{LogicalType: parseLogicalType(v, String, 0)}, nil
 		case typeArray:
 			items, err := schemaByType(v[schemaItemsField], registry, namespace)
 			if err != nil {
@@ -1008,7 +1259,8 @@ func schemaByType(i interface{}, registry map[string]Schema, namespace string) (
 			return parseEnumSchema(v, registry, namespace)
 		case typeFixed:
 			return parseFixedSchema(v, registry, namespace)
-		case typeRecord:
+		case typeRecord, typeError:
+			// errors are declared in protocols and are records in every other respect
 			return parseRecordSchema(v, registry, namespace)
 		default:
 			// Type references can also be done as {"type": "otherType"}.
@@ -1030,10 +1282,16 @@ func parseEnumSchema(v map[string]interface{}, registry map[string]Schema, names
 
 	schema := &EnumSchema{Name: v[schemaNameField].(string), Symbols: symbols}
 	setOptionalField(&schema.Namespace, v, schemaNamespaceField)
+	setOptionalField(&namespace, v, schemaNamespaceField)
 	setOptionalField(&schema.Doc, v, schemaDocField)
 	schema.Properties = getProperties(v)
+	aliases, err := parseAliases(v, namespace)
+	if err != nil {
+		return nil, err
+	}
+	schema.Aliases = aliases
 
-	return addSchema(getFullName(v[schemaNameField].(string), namespace), schema, registry), nil
+	return addNamedSchema(getFullName(v[schemaNameField].(string), namespace), aliases, schema, registry), nil
 }
 
 func parseFixedSchema(v map[string]interface{}, registry map[string]Schema, namespace string) (Schema, error) {
@@ -1043,8 +1301,16 @@ func parseFixedSchema(v map[string]interface{}, registry map[string]Schema, name
 	}
 
 	schema := &FixedSchema{Name: v[schemaNameField].(string), Size: int(size), Properties: getProperties(v)}
+	schema.LogicalType = parseLogicalType(v, Fixed, schema.Size)
 	setOptionalField(&schema.Namespace, v, schemaNamespaceField)
-	return addSchema(getFullName(v[schemaNameField].(string), namespace), schema, registry), nil
+	setOptionalField(&namespace, v, schemaNamespaceField)
+	aliases, err := parseAliases(v, namespace)
+	if err != nil {
+		return nil, err
+	}
+	schema.Aliases = aliases
+
+	return addNamedSchema(getFullName(v[schemaNameField].(string), namespace), aliases, schema, registry), nil
 }
 
 func parseUnionSchema(v []interface{}, registry map[string]Schema, namespace string) (Schema, error) {
@@ -1060,11 +1326,16 @@ func parseUnionSchema(v []interface{}, registry map[string]Schema, namespace str
 }
 
 func parseRecordSchema(v map[string]interface{}, registry map[string]Schema, namespace string) (Schema, error) {
-	schema := &RecordSchema{Name: v[schemaNameField].(string)}
+	schema := &RecordSchema{Name: v[schemaNameField].(string), isError: v[schemaTypeField] == typeError}
 	setOptionalField(&schema.Namespace, v, schemaNamespaceField)
 	setOptionalField(&namespace, v, schemaNamespaceField)
 	setOptionalField(&schema.Doc, v, schemaDocField)
-	addSchema(getFullName(v[schemaNameField].(string), namespace), newRecursiveSchema(schema), registry)
+	aliases, err := parseAliases(v, namespace)
+	if err != nil {
+		return nil, err
+	}
+	schema.Aliases = aliases
+	addNamedSchema(getFullName(v[schemaNameField].(string), namespace), aliases, newRecursiveSchema(schema), registry)
 	fields := make([]*SchemaField, len(v[schemaFieldsField].([]interface{})))
 	for i := range fields {
 		field, err := parseSchemaField(v[schemaFieldsField].([]interface{})[i], registry, namespace)
@@ -1088,32 +1359,19 @@ func parseSchemaField(i interface{}, registry map[string]Schema, namespace strin
 		}
 		schemaField := &SchemaField{Name: name, Properties: getProperties(v)}
 		setOptionalField(&schemaField.Doc, v, schemaDocField)
+		// field aliases are plain names that are not affected by namespaces
+		aliases, err := parseAliases(v, "")
+		if err != nil {
+			return nil, err
+		}
+		schemaField.Aliases = aliases
 		fieldType, err := schemaByType(v[schemaTypeField], registry, namespace)
 		if err != nil {
 			return nil, err
 		}
 		schemaField.Type = fieldType
 		if def, exists := v[schemaDefaultField]; exists {
-			switch def.(type) {
-			case float64:
-				// JSON treats all numbers as float64 by default
-				switch schemaField.Type.Type() {
-				case Int:
-					var converted = int32(def.(float64))
-					schemaField.Default = converted
-				case Long:
-					var converted = int64(def.(float64))
-					schemaField.Default = converted
-				case Float:
-					var converted = float32(def.(float64))
-					schemaField.Default = converted
-
-				default:
-					schemaField.Default = def
-				}
-			default:
-				schemaField.Default = def
-			}
+			schemaField.Default = fieldDefault(schemaField.Type, def)
 		}
 		return schemaField, nil
 	}
@@ -1121,6 +1379,23 @@ func parseSchemaField(i interface{}, registry map[string]Schema, namespace strin
 	return nil, InvalidSchema
 }
 
+// fieldDefault converts a JSON default value to the Go type of a given field schema.
+func fieldDefault(fieldType Schema, def interface{}) interface{} {
+	if number, ok := def.(float64); ok {
+		// JSON treats all numbers as float64 by default
+		switch fieldType.Type() {
+		case Int:
+			return int32(number)
+		case Long:
+			return int64(number)
+		case Float:
+			return float32(number)
+		}
+	}
+
+	return def
+}
+
 func setOptionalField(where *string, v map[string]interface{}, fieldName string) {
 	if field, exists := v[fieldName]; exists {
 		*where = field.(string)
@@ -1139,6 +1414,38 @@ func addSchema(name string, schema Schema, schemas map[string]Schema) Schema {
 	return schema
 }
 
+// addNamedSchema adds a named schema to a given registry under its full name and the full names of its aliases,
+// so that it may be referenced by any of them.
+func addNamedSchema(name string, aliases []string, schema Schema, schemas map[string]Schema) Schema {
+	schema = addSchema(name, schema, schemas)
+	for _, alias := range aliases {
+		addSchema(alias, schema, schemas)
+	}
+	return schema
+}
+
+// parseAliases returns aliases of a given named type or field resolving names relative to a given namespace.
+func parseAliases(v map[string]interface{}, namespace string) ([]string, error) {
+	raw, exists := v[schemaAliasesField]
+	if !exists {
+		return nil, nil
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid aliases %v", raw)
+	}
+	aliases := make([]string, len(list))
+	for i, alias := range list {
+		name, ok := alias.(string)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("Invalid alias %v", alias)
+		}
+		aliases[i] = getFullName(name, namespace)
+	}
+	return aliases, nil
+}
+
 func getFullName(name string, namespace string) string {
 	if len(namespace) > 0 && !strings.ContainsRune(name, '.') {
 		return namespace + "." + name
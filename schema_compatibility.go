@@ -0,0 +1,291 @@
+package avro
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Compatibility is a schema evolution policy that defines which previous schema versions a new schema should be
+// compatible with and in what direction.
+type Compatibility int
+
+const (
+	// BackwardCompatibility means data written with the previous schema version can be read with the new one.
+	BackwardCompatibility Compatibility = iota
+
+	// ForwardCompatibility means data written with the new schema can be read with the previous schema version.
+	ForwardCompatibility
+
+	// FullCompatibility means the new schema is both backward and forward compatible with the previous schema version.
+	FullCompatibility
+
+	// BackwardTransitiveCompatibility means data written with any previous schema version can be read with the new one.
+	BackwardTransitiveCompatibility
+
+	// ForwardTransitiveCompatibility means data written with the new schema can be read with any previous schema version.
+	ForwardTransitiveCompatibility
+
+	// FullTransitiveCompatibility means the new schema is both backward and forward compatible with all previous
+	// schema versions.
+	FullTransitiveCompatibility
+)
+
+// IncompatibilityType describes why data written with one schema cannot be read with another.
+type IncompatibilityType string
+
+const (
+	// NameMismatch happens when named schemas (records, enums and fixed) have different names.
+	NameMismatch IncompatibilityType = "NAME_MISMATCH"
+
+	// FixedSizeMismatch happens when fixed schemas have different sizes.
+	FixedSizeMismatch IncompatibilityType = "FIXED_SIZE_MISMATCH"
+
+	// MissingEnumSymbols happens when writer enum has symbols that reader enum does not have.
+	MissingEnumSymbols IncompatibilityType = "MISSING_ENUM_SYMBOLS"
+
+	// ReaderFieldMissingDefaultValue happens when reader record has a field that writer record does not have and
+	// this field has no default value.
+	ReaderFieldMissingDefaultValue IncompatibilityType = "READER_FIELD_MISSING_DEFAULT_VALUE"
+
+	// TypeMismatch happens when writer type cannot be read or promoted to reader type.
+	TypeMismatch IncompatibilityType = "TYPE_MISMATCH"
+
+	// MissingUnionBranch happens when writer union branch or writer type does not match any reader union branch.
+	MissingUnionBranch IncompatibilityType = "MISSING_UNION_BRANCH"
+)
+
+// Incompatibility describes a single reason why data written with writer schema cannot be read with reader schema.
+type Incompatibility struct {
+	// Type of this incompatibility.
+	Type IncompatibilityType
+
+	// JSON path style location of the incompatible part in reader schema, e.g. "$.fields[1].type.symbols".
+	// Locations always refer to reader schema, e.g. a writer union branch that cannot be read is reported at
+	// the location of the reader schema that is read instead.
+	Location string
+
+	// Human readable description of this incompatibility.
+	Message string
+
+	// Reader and writer schemas at Location.
+	Reader Schema
+	Writer Schema
+
+	// Version is the index of the conflicting previous schema version when checked with CheckCompatibilityLevel.
+	Version int
+}
+
+// String returns a human readable representation of this Incompatibility.
+func (i *Incompatibility) String() string {
+	return fmt.Sprintf("%s at %s: %s", i.Type, i.Location, i.Message)
+}
+
+// CheckCompatibility checks whether data written with writer schema can be read with reader schema according to
+// Avro schema resolution rules. Returns all found incompatibilities, an empty result means the schemas are compatible.
+func CheckCompatibility(reader Schema, writer Schema) []*Incompatibility {
+	checker := &compatibilityChecker{checked: make(map[resolverKey]bool)}
+	checker.check(reader, writer, "")
+	return checker.incompatibilities
+}
+
+// CheckCompatibilityLevel checks the last schema in a given ordered list of schema versions against previous versions
+// according to the given Compatibility policy. Non-transitive policies check only against the version right before
+// the last one. Returns all found incompatibilities, an empty result means the last schema may be deployed.
+// Locations refer to the Reader schema of each incompatibility, that is the last version for backward checks and
+// a previous one for forward checks, both of which are done by full compatibility policies.
+func CheckCompatibilityLevel(level Compatibility, versions []Schema) []*Incompatibility {
+	if len(versions) < 2 {
+		return nil
+	}
+
+	latest := versions[len(versions)-1]
+	first := len(versions) - 2
+	switch level {
+	case BackwardTransitiveCompatibility, ForwardTransitiveCompatibility, FullTransitiveCompatibility:
+		first = 0
+	}
+
+	var incompatibilities []*Incompatibility
+	for version := first; version < len(versions)-1; version++ {
+		var found []*Incompatibility
+		previous := versions[version]
+		switch level {
+		case BackwardCompatibility, BackwardTransitiveCompatibility:
+			found = CheckCompatibility(latest, previous)
+		case ForwardCompatibility, ForwardTransitiveCompatibility:
+			found = CheckCompatibility(previous, latest)
+		case FullCompatibility, FullTransitiveCompatibility:
+			found = append(CheckCompatibility(latest, previous), CheckCompatibility(previous, latest)...)
+		}
+
+		for _, incompatibility := range found {
+			incompatibility.Version = version
+		}
+		incompatibilities = append(incompatibilities, found...)
+	}
+
+	return incompatibilities
+}
+
+type compatibilityChecker struct {
+	// record pairs that are already checked or are being checked. Prevents infinite recursion for recursive types.
+	checked           map[resolverKey]bool
+	incompatibilities []*Incompatibility
+}
+
+func (c *compatibilityChecker) report(typ IncompatibilityType, location string, reader Schema, writer Schema, format string, args ...interface{}) {
+	location = "$" + location
+
+	c.incompatibilities = append(c.incompatibilities, &Incompatibility{
+		Type:     typ,
+		Location: location,
+		Message:  fmt.Sprintf(format, args...),
+		Reader:   reader,
+		Writer:   writer,
+	})
+}
+
+// compatible checks schemas without reporting anything.
+func (c *compatibilityChecker) compatible(reader Schema, writer Schema) bool {
+	// probe works on a copy so that failed probes do not hide record pairs from the actual check
+	checked := make(map[resolverKey]bool, len(c.checked))
+	for key, value := range c.checked {
+		checked[key] = value
+	}
+
+	probe := &compatibilityChecker{checked: checked}
+	probe.check(reader, writer, "")
+	return len(probe.incompatibilities) == 0
+}
+
+func (c *compatibilityChecker) check(reader Schema, writer Schema, location string) {
+	reader = actualSchema(reader)
+	writer = actualSchema(writer)
+
+	if writer.Type() == Union {
+		c.checkWriterUnion(reader, writer.(*UnionSchema), location)
+		return
+	}
+
+	switch reader.Type() {
+	case Union:
+		c.checkReaderUnion(reader.(*UnionSchema), writer, location)
+	case Null, Boolean, Int, Long, Float, Double, Bytes, String:
+		if writer.Type() != reader.Type() && !isPromotable(writer.Type(), reader.Type()) {
+			c.reportTypeMismatch(reader, writer, location)
+		}
+	case Array:
+		if writer.Type() != Array {
+			c.reportTypeMismatch(reader, writer, location)
+			return
+		}
+		c.check(reader.(*ArraySchema).Items, writer.(*ArraySchema).Items, location+".items")
+	case Map:
+		if writer.Type() != Map {
+			c.reportTypeMismatch(reader, writer, location)
+			return
+		}
+		c.check(reader.(*MapSchema).Values, writer.(*MapSchema).Values, location+".values")
+	case Enum:
+		if !c.checkName(reader, writer, location) {
+			return
+		}
+		c.checkEnum(reader.(*EnumSchema), writer.(*EnumSchema), location)
+	case Fixed:
+		if !c.checkName(reader, writer, location) {
+			return
+		}
+		if reader.(*FixedSchema).Size != writer.(*FixedSchema).Size {
+			c.report(FixedSizeMismatch, location+".size", reader, writer, "expected: %d, found: %d", writer.(*FixedSchema).Size, reader.(*FixedSchema).Size)
+		}
+	case Record:
+		if !c.checkName(reader, writer, location) {
+			return
+		}
+		c.checkRecord(reader.(*RecordSchema), writer.(*RecordSchema), location)
+	}
+}
+
+func (c *compatibilityChecker) reportTypeMismatch(reader Schema, writer Schema, location string) {
+	c.report(TypeMismatch, location, reader, writer, "reader type: %s not compatible with writer type: %s", reader.GetName(), writer.GetName())
+}
+
+// checkName checks that writer is of the same type as reader and either both have the same name or the writer name
+// is one of reader aliases. Returns false if not.
+func (c *compatibilityChecker) checkName(reader Schema, writer Schema, location string) bool {
+	if writer.Type() != reader.Type() {
+		c.reportTypeMismatch(reader, writer, location)
+		return false
+	}
+	if !namesMatch(writer, reader) {
+		c.report(NameMismatch, location+".name", reader, writer, "expected: %s", writer.GetName())
+		return false
+	}
+	return true
+}
+
+func (c *compatibilityChecker) checkEnum(reader *EnumSchema, writer *EnumSchema, location string) {
+	symbols := make(map[string]bool)
+	for _, symbol := range reader.Symbols {
+		symbols[symbol] = true
+	}
+
+	var missing []string
+	for _, symbol := range writer.Symbols {
+		if !symbols[symbol] {
+			missing = append(missing, symbol)
+		}
+	}
+	if len(missing) > 0 {
+		c.report(MissingEnumSymbols, location+".symbols", reader, writer, "%v", missing)
+	}
+}
+
+func (c *compatibilityChecker) checkRecord(reader *RecordSchema, writer *RecordSchema, location string) {
+	key := resolverKey{writer: writer, reader: reader}
+	if c.checked[key] {
+		return
+	}
+	c.checked[key] = true
+
+	writerFields := make(map[string]*SchemaField)
+	for _, field := range writer.Fields {
+		writerFields[field.Name] = field
+	}
+
+	for i, readerField := range reader.Fields {
+		fieldLocation := location + ".fields[" + strconv.Itoa(i) + "]"
+		writerField, exists := writerFields[readerField.Name]
+		for i := 0; !exists && i < len(readerField.Aliases); i++ {
+			writerField, exists = writerFields[readerField.Aliases[i]]
+		}
+		if !exists {
+			if !hasDefault(readerField) {
+				c.report(ReaderFieldMissingDefaultValue, fieldLocation, reader, writer, "%s", readerField.Name)
+			}
+			continue
+		}
+
+		c.check(readerField.Type, writerField.Type, fieldLocation+".type")
+	}
+}
+
+// checkWriterUnion reports each branch of a writer union that a reader schema cannot read at the location of the
+// reader schema, the index of the branch in the writer union is a part of the message.
+func (c *compatibilityChecker) checkWriterUnion(reader Schema, writer *UnionSchema, location string) {
+	for i, branch := range writer.Types {
+		if !c.compatible(reader, branch) {
+			c.report(MissingUnionBranch, location, reader, branch, "reader union lacking writer type: %s (writer union branch %d)", actualSchema(branch).GetName(), i)
+		}
+	}
+}
+
+func (c *compatibilityChecker) checkReaderUnion(reader *UnionSchema, writer Schema, location string) {
+	for _, branch := range reader.Types {
+		if c.compatible(branch, writer) {
+			return
+		}
+	}
+
+	c.report(MissingUnionBranch, location, reader, writer, "reader union lacking writer type: %s", writer.GetName())
+}
@@ -0,0 +1,116 @@
+package avro
+
+import "testing"
+
+func TestCheckCompatibility(t *testing.T) {
+	assert(t, len(CheckCompatibility(resolutionReaderSchema, resolutionWriterSchema)), 0)
+
+	incompatibilities := CheckCompatibility(resolutionWriterSchema, resolutionReaderSchema)
+	assert(t, len(incompatibilities), 6)
+	assert(t, incompatibilities[0].Type, TypeMismatch)
+	assert(t, incompatibilities[0].Location, "$.fields[0].type")
+	assert(t, incompatibilities[1].Type, ReaderFieldMissingDefaultValue)
+	assert(t, incompatibilities[1].Location, "$.fields[1]")
+	assert(t, incompatibilities[1].Message, "removed")
+	assert(t, incompatibilities[2].Type, ReaderFieldMissingDefaultValue)
+	assert(t, incompatibilities[2].Location, "$.fields[2]")
+	assert(t, incompatibilities[3].Type, TypeMismatch)
+	assert(t, incompatibilities[3].Location, "$.fields[3].type")
+	assert(t, incompatibilities[4].Type, MissingEnumSymbols)
+	assert(t, incompatibilities[4].Location, "$.fields[4].type.symbols")
+	assert(t, incompatibilities[4].Message, "[D]")
+	assert(t, incompatibilities[5].Type, MissingUnionBranch)
+	assert(t, incompatibilities[5].Location, "$.fields[6].type")
+	assert(t, incompatibilities[5].Message, "reader union lacking writer type: long (writer union branch 1)")
+	assert(t, incompatibilities[5].Reader, resolutionWriterSchema.(*RecordSchema).Fields[6].Type)
+}
+
+func TestCheckCompatibilityNamedTypes(t *testing.T) {
+	incompatibilities := CheckCompatibility(MustParseSchema(`{"type": "fixed", "name": "md5", "size": 16}`),
+		MustParseSchema(`{"type": "fixed", "name": "md5", "size": 8}`))
+	assert(t, len(incompatibilities), 1)
+	assert(t, incompatibilities[0].Type, FixedSizeMismatch)
+	assert(t, incompatibilities[0].Location, "$.size")
+
+	incompatibilities = CheckCompatibility(MustParseSchema(`{"type": "enum", "name": "Kind", "symbols": ["A"]}`),
+		MustParseSchema(`{"type": "enum", "name": "Other", "symbols": ["A"]}`))
+	assert(t, len(incompatibilities), 1)
+	assert(t, incompatibilities[0].Type, NameMismatch)
+	assert(t, incompatibilities[0].Location, "$.name")
+
+	incompatibilities = CheckCompatibility(MustParseSchema(`{"type": "array", "items": "int"}`), MustParseSchema(`{"type": "map", "values": "int"}`))
+	assert(t, len(incompatibilities), 1)
+	assert(t, incompatibilities[0].Type, TypeMismatch)
+	assert(t, incompatibilities[0].Location, "$")
+}
+
+func TestCheckCompatibilityUnions(t *testing.T) {
+	assert(t, len(CheckCompatibility(MustParseSchema(`["null", "string", "long"]`), MustParseSchema(`"int"`))), 0)
+	assert(t, len(CheckCompatibility(MustParseSchema(`"long"`), MustParseSchema(`["int", "long"]`))), 0)
+
+	incompatibilities := CheckCompatibility(MustParseSchema(`["null", "string"]`), MustParseSchema(`"int"`))
+	assert(t, len(incompatibilities), 1)
+	assert(t, incompatibilities[0].Type, MissingUnionBranch)
+	assert(t, incompatibilities[0].Location, "$")
+}
+
+func TestCheckCompatibilityRecursive(t *testing.T) {
+	v1 := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+        {"name": "value", "type": "int"},
+        {"name": "next", "type": ["null", "Node"]}
+    ]}`)
+	v2 := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+        {"name": "value", "type": "long"},
+        {"name": "next", "type": ["null", "Node"]}
+    ]}`)
+
+	assert(t, len(CheckCompatibility(v2, v1)), 0)
+
+	incompatibilities := CheckCompatibility(v1, v2)
+	assert(t, len(incompatibilities), 1)
+	assert(t, incompatibilities[0].Type, TypeMismatch)
+	assert(t, incompatibilities[0].Location, "$.fields[0].type")
+}
+
+func TestCheckCompatibilityLevel(t *testing.T) {
+	v1 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+        {"name": "name", "type": "string"}
+    ]}`)
+	v2 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+        {"name": "name", "type": "string"},
+        {"name": "age", "type": "int", "default": 0}
+    ]}`)
+	v3 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+        {"name": "age", "type": "int", "default": 0}
+    ]}`)
+
+	assert(t, len(CheckCompatibilityLevel(FullCompatibility, []Schema{v1, v2})), 0)
+	assert(t, len(CheckCompatibilityLevel(FullTransitiveCompatibility, []Schema{v1})), 0)
+
+	assert(t, len(CheckCompatibilityLevel(BackwardCompatibility, []Schema{v1, v2, v3})), 0)
+	assert(t, len(CheckCompatibilityLevel(BackwardTransitiveCompatibility, []Schema{v1, v2, v3})), 0)
+
+	incompatibilities := CheckCompatibilityLevel(ForwardCompatibility, []Schema{v1, v2, v3})
+	assert(t, len(incompatibilities), 1)
+	assert(t, incompatibilities[0].Type, ReaderFieldMissingDefaultValue)
+	assert(t, incompatibilities[0].Version, 1)
+
+	incompatibilities = CheckCompatibilityLevel(FullTransitiveCompatibility, []Schema{v1, v2, v3})
+	assert(t, len(incompatibilities), 2)
+	assert(t, incompatibilities[0].Version, 0)
+	assert(t, incompatibilities[1].Version, 1)
+
+	// locations of full compatibility checks refer to the reader schema of each direction
+	v4 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+        {"name": "id", "type": "string"},
+        {"name": "age", "type": ["int", "string"]}
+    ]}`)
+	incompatibilities = CheckCompatibilityLevel(FullCompatibility, []Schema{v4, v3})
+	assert(t, len(incompatibilities), 2)
+	assert(t, incompatibilities[0].Type, MissingUnionBranch)
+	assert(t, incompatibilities[0].Location, "$.fields[0].type")
+	assert(t, incompatibilities[0].Reader, v3.(*RecordSchema).Fields[0].Type)
+	assert(t, incompatibilities[1].Type, ReaderFieldMissingDefaultValue)
+	assert(t, incompatibilities[1].Location, "$.fields[0]")
+	assert(t, incompatibilities[1].Reader, v4)
+}
@@ -0,0 +1,150 @@
+package avro
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"strconv"
+	"strings"
+)
+
+// emptyFingerprint64 is the CRC-64-AVRO fingerprint of empty input.
+const emptyFingerprint64 uint64 = 0xc15d213aa4d7a795
+
+var fingerprint64Table = func() [256]uint64 {
+	var table [256]uint64
+	for i := range table {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (emptyFingerprint64 & -(fp & 1))
+		}
+		table[i] = fp
+	}
+	return table
+}()
+
+// CanonicalForm returns the Parsing Canonical Form of a given schema as defined by the Avro specification
+// (https://avro.apache.org/docs/current/spec.html#Parsing+Canonical+Form+for+Schemas). Two schemas that have the same
+// canonical form read and write data the same way regardless of documentation, default values, logical types,
+// attribute order and formatting.
+func CanonicalForm(schema Schema) string {
+	buffer := &bytes.Buffer{}
+	writeCanonicalForm(buffer, schema, "", make(map[string]bool))
+	return buffer.String()
+}
+
+// Fingerprint64 returns the CRC-64-AVRO fingerprint of the canonical form of a given schema.
+func Fingerprint64(schema Schema) uint64 {
+	fp := emptyFingerprint64
+	for _, b := range []byte(CanonicalForm(schema)) {
+		fp = (fp >> 8) ^ fingerprint64Table[byte(fp)^b]
+	}
+	return fp
+}
+
+// FingerprintMD5 returns the MD5 fingerprint of the canonical form of a given schema.
+func FingerprintMD5(schema Schema) [md5.Size]byte {
+	return md5.Sum([]byte(CanonicalForm(schema)))
+}
+
+// FingerprintSHA256 returns the SHA-256 fingerprint of the canonical form of a given schema.
+func FingerprintSHA256(schema Schema) [sha256.Size]byte {
+	return sha256.Sum256([]byte(CanonicalForm(schema)))
+}
+
+// writeCanonicalForm writes a given schema in canonical form. Named types that were already written are replaced with
+// their full names.
+func writeCanonicalForm(buffer *bytes.Buffer, schema Schema, namespace string, named map[string]bool) {
+	schema = actualSchema(schema)
+	switch s := schema.(type) {
+	case *RecordSchema:
+		fullName := canonicalName(s.Name, s.Namespace, namespace)
+		if writeNamed(buffer, fullName, named) {
+			return
+		}
+		recordNamespace := namespaceOfFullName(fullName)
+		buffer.WriteString(`,"type":"record","fields":[`)
+		for i, field := range s.Fields {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			buffer.WriteString(`{"name":`)
+			buffer.WriteString(quoteJSONString(field.Name))
+			buffer.WriteString(`,"type":`)
+			writeCanonicalForm(buffer, field.Type, recordNamespace, named)
+			buffer.WriteByte('}')
+		}
+		buffer.WriteString("]}")
+	case *EnumSchema:
+		fullName := canonicalName(s.Name, s.Namespace, namespace)
+		if writeNamed(buffer, fullName, named) {
+			return
+		}
+		buffer.WriteString(`,"type":"enum","symbols":[`)
+		for i, symbol := range s.Symbols {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			buffer.WriteString(quoteJSONString(symbol))
+		}
+		buffer.WriteString("]}")
+	case *FixedSchema:
+		fullName := canonicalName(s.Name, s.Namespace, namespace)
+		if writeNamed(buffer, fullName, named) {
+			return
+		}
+		buffer.WriteString(`,"type":"fixed","size":`)
+		buffer.WriteString(strconv.Itoa(s.Size))
+		buffer.WriteByte('}')
+	case *ArraySchema:
+		buffer.WriteString(`{"type":"array","items":`)
+		writeCanonicalForm(buffer, s.Items, namespace, named)
+		buffer.WriteByte('}')
+	case *MapSchema:
+		buffer.WriteString(`{"type":"map","values":`)
+		writeCanonicalForm(buffer, s.Values, namespace, named)
+		buffer.WriteByte('}')
+	case *UnionSchema:
+		buffer.WriteByte('[')
+		for i, branch := range s.Types {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			writeCanonicalForm(buffer, branch, namespace, named)
+		}
+		buffer.WriteByte(']')
+	default:
+		buffer.WriteString(quoteJSONString(schema.GetName()))
+	}
+}
+
+// writeNamed writes a reference to a named type and returns true if the type was already defined, otherwise starts
+// a JSON object for its definition and returns false.
+func writeNamed(buffer *bytes.Buffer, fullName string, named map[string]bool) bool {
+	if named[fullName] {
+		buffer.WriteString(quoteJSONString(fullName))
+		return true
+	}
+
+	named[fullName] = true
+	buffer.WriteString(`{"name":`)
+	buffer.WriteString(quoteJSONString(fullName))
+	return false
+}
+
+// canonicalName returns the full name of a named type with a given namespace attribute that is defined inside
+// a given enclosing namespace.
+func canonicalName(name string, namespace string, enclosing string) string {
+	if namespace == "" {
+		namespace = enclosing
+	}
+	return getFullName(name, namespace)
+}
+
+// namespaceOfFullName returns the namespace part of a given full name.
+func namespaceOfFullName(fullName string) string {
+	if index := strings.LastIndex(fullName, "."); index >= 0 {
+		return fullName[:index]
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package avro
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestCanonicalFormPrimitives(t *testing.T) {
+	assert(t, CanonicalForm(MustParseSchema(`"int"`)), `"int"`)
+	assert(t, CanonicalForm(MustParseSchema(`{"type": "string", "logicalType": "uuid"}`)), `"string"`)
+	assert(t, CanonicalForm(MustParseSchema(`["null", {"type": "array", "items": {"type": "map", "values": "long"}}]`)),
+		`["null",{"type":"array","items":{"type":"map","values":"long"}}]`)
+}
+
+func TestCanonicalFormNamedTypes(t *testing.T) {
+	schema := MustParseSchema(`{
+        "doc": "An event",
+        "fields": [
+            {"name": "kind", "default": "A", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"], "doc": "kind"}},
+            {"name": "hash", "type": {"size": 16, "type": "fixed", "name": "Hash", "namespace": "com.other"}},
+            {"name": "other", "type": "Kind"},
+            {"name": "next", "type": ["null", "Event"]}
+        ],
+        "namespace": "com.example",
+        "name": "Event",
+        "type": "record",
+        "custom": "property"
+    }`)
+
+	assert(t, CanonicalForm(schema), `{"name":"com.example.Event","type":"record","fields":[`+
+		`{"name":"kind","type":{"name":"com.example.Kind","type":"enum","symbols":["A","B"]}},`+
+		`{"name":"hash","type":{"name":"com.other.Hash","type":"fixed","size":16}},`+
+		`{"name":"other","type":"com.example.Kind"},`+
+		`{"name":"next","type":["null","com.example.Event"]}]}`)
+}
+
+func TestFingerprints(t *testing.T) {
+	// test vectors from the reference implementation
+	assert(t, Fingerprint64(MustParseSchema(`"null"`)), uint64(7195948357588979594))
+	assert(t, Fingerprint64(MustParseSchema(`"boolean"`)), uint64(11476012395585140580))
+	assert(t, Fingerprint64(MustParseSchema(`"int"`)), uint64(8247732601305521295))
+
+	schema := MustParseSchema(`{"type": "fixed", "name": "Hash", "size": 16}`)
+	canonical := []byte(`{"name":"Hash","type":"fixed","size":16}`)
+	assert(t, FingerprintMD5(schema), md5.Sum(canonical))
+	assert(t, FingerprintSHA256(schema), sha256.Sum256(canonical))
+
+	// formatting and documentation do not affect fingerprints
+	formatted := MustParseSchema(`{"name": "Hash", "doc": "a hash", "size": 16, "type": "fixed"}`)
+	assert(t, Fingerprint64(formatted), Fingerprint64(schema))
+}
@@ -75,10 +75,13 @@ func (job *prepareJob) prepareRecordSchema(input *RecordSchema) *preparedRecordS
 		pool:         sync.Pool{New: func() interface{} { return make(map[reflect.Type]*recordPlan) }},
 	}
 	output.Fields = nil
+	// recursive references to this record are resolved while preparing its fields
+	job.seen[input] = output
 	for _, field := range input.Fields {
 		output.Fields = append(output.Fields, &SchemaField{
 			Name:    field.Name,
 			Doc:     field.Doc,
+			Aliases: field.Aliases,
 			Default: field.Default,
 			Type:    job.prepare(field.Type),
 		})
@@ -0,0 +1,311 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Options of avro struct tags understood by SchemaOf.
+const (
+	tagDefault   = "default"
+	tagNamespace = "namespace"
+	tagDoc       = "doc"
+	tagLogical   = "logical"
+	tagPrecision = "precision"
+	tagScale     = "scale"
+	tagNullable  = "nullable"
+)
+
+// SchemaOf builds a record schema of a given struct or pointer to struct using reflection. Fields are named after
+// `avro:"name"` tags or struct field names with the first letter lowercased, fields tagged with "-" and unexported
+// fields are skipped, embedded structs without tags are flattened. Tags may have options after the name:
+//
+//	default=<value>   field default value in JSON, strings are taken as is
+//	namespace=<ns>    namespace of a record or fixed type defined by this field
+//	logical=<type>    logical type, e.g. timestamp-micros, optionally with precision=<p> and scale=<s> for decimals
+//	nullable          makes a field a union with null
+//	doc=<doc>         field documentation, takes the rest of the tag
+//
+// The record itself may be named and documented with a blank field, e.g. _ struct{} `avro:"User,namespace=com.example"`
+// that should go first. Go types are mapped as follows: bool, int32, int64, float32, float64, string and []byte to
+// primitive types, pointers to unions with null, slices to arrays, maps with string keys to maps, [N]byte to fixed,
+// structs to records, time.Time to timestamp-millis, time.Duration to time-micros, *big.Rat to decimal and Duration
+// to duration. Recursive and repeated structs are referenced by name with RecursiveSchema, repeated named [N]byte
+// types are referenced by name as well. Unnamed [N]byte fields of the same name and size in different records of the
+// same namespace share a fixed type, any other types with the same full name are an error.
+func SchemaOf(v interface{}) (Schema, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("SchemaOf requires a struct, got %T", v)
+	}
+
+	inferrer := &schemaInferrer{
+		records: make(map[reflect.Type]*RecordSchema),
+		fixed:   make(map[reflect.Type]*FixedSchema),
+		names:   make(map[string]Schema),
+	}
+	return inferrer.record(t, t.Name(), "")
+}
+
+type schemaInferrer struct {
+	// records already built or being built by Go types
+	records map[reflect.Type]*RecordSchema
+	// fixed types of named Go types already built
+	fixed map[reflect.Type]*FixedSchema
+	// named types already built by full names
+	names map[string]Schema
+}
+
+func (si *schemaInferrer) record(t reflect.Type, name string, namespace string) (Schema, error) {
+	if record, exists := si.records[t]; exists {
+		return newRecursiveSchema(record), nil
+	}
+
+	record := &RecordSchema{Name: typeNameOr(t, name), Namespace: namespace}
+	si.records[t] = record
+	if err := si.fields(record, t); err != nil {
+		return nil, err
+	}
+	if _, err := si.define(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (si *schemaInferrer) fields(record *RecordSchema, t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, options := parseTag(f.Tag.Get("avro"))
+
+		switch {
+		case f.Name == "_":
+			if name != "" {
+				record.Name = name
+			}
+			if namespace, ok := options[tagNamespace]; ok {
+				record.Namespace = namespace
+			}
+			record.Doc = options[tagDoc]
+		case f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct:
+			if err := si.fields(record, f.Type); err != nil {
+				return err
+			}
+		case f.PkgPath != "" || name == "-":
+			continue
+		default:
+			if name == "" {
+				name = strings.ToLower(f.Name[:1]) + f.Name[1:]
+			}
+			field, err := si.field(f.Type, name, options, record.Namespace)
+			if err != nil {
+				return err
+			}
+			record.Fields = append(record.Fields, field)
+		}
+	}
+
+	return nil
+}
+
+func (si *schemaInferrer) field(t reflect.Type, name string, options map[string]string, namespace string) (*SchemaField, error) {
+	if fieldNamespace, ok := options[tagNamespace]; ok {
+		namespace = fieldNamespace
+	}
+
+	_, nullable := options[tagNullable]
+	if t.Kind() == reflect.Ptr && t.Elem() != ratType {
+		nullable = true
+		t = t.Elem()
+	}
+
+	schema, err := si.schema(t, strings.ToUpper(name[:1])+name[1:], namespace, options)
+	if err != nil {
+		return nil, fmt.Errorf("Field %s: %s", name, err)
+	}
+
+	field := &SchemaField{Name: name, Doc: options[tagDoc], Type: schema}
+	if raw, ok := options[tagDefault]; ok {
+		if schema.Type() == String {
+			field.Default = raw
+		} else {
+			var def interface{}
+			if err := json.Unmarshal([]byte(raw), &def); err != nil {
+				return nil, fmt.Errorf("Field %s: invalid default %s: %s", name, raw, err)
+			}
+			field.Default = fieldDefault(schema, def)
+		}
+	}
+
+	if nullable {
+		// union defaults must match the first branch
+		if field.Default == nil {
+			field.Type = &UnionSchema{Types: []Schema{new(NullSchema), schema}}
+		} else {
+			field.Type = &UnionSchema{Types: []Schema{schema, new(NullSchema)}}
+		}
+	}
+
+	return field, nil
+}
+
+func (si *schemaInferrer) schema(t reflect.Type, name string, namespace string, options map[string]string) (Schema, error) {
+	if _, ok := options[tagLogical]; ok {
+		return si.logical(t, name, namespace, options)
+	}
+	switch t {
+	case timeType, durationType, ratType, avroDurationType:
+		return si.logical(t, name, namespace, options)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return new(BooleanSchema), nil
+	case reflect.Int32:
+		return new(IntSchema), nil
+	case reflect.Int64:
+		return new(LongSchema), nil
+	case reflect.Float32:
+		return new(FloatSchema), nil
+	case reflect.Float64:
+		return new(DoubleSchema), nil
+	case reflect.String:
+		return new(StringSchema), nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return new(BytesSchema), nil
+		}
+		items, err := si.schema(elemType(t), name, namespace, options)
+		if err != nil {
+			return nil, err
+		}
+		return &ArraySchema{Items: items}, nil
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return si.fixedSchema(t, name, namespace)
+		}
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			values, err := si.schema(elemType(t), name, namespace, options)
+			if err != nil {
+				return nil, err
+			}
+			return &MapSchema{Values: values}, nil
+		}
+	case reflect.Struct:
+		return si.record(t, name, namespace)
+	}
+
+	return nil, fmt.Errorf("Unsupported Go type %s", t)
+}
+
+// fixedSchema builds a fixed schema of a given [N]byte type. A named type is built once and shared wherever it is used
+// again, so that it is serialized as a reference by full name there.
+func (si *schemaInferrer) fixedSchema(t reflect.Type, name string, namespace string) (Schema, error) {
+	if fixed, exists := si.fixed[t]; exists {
+		return fixed, nil
+	}
+
+	fixed, err := si.define(&FixedSchema{Name: typeNameOr(t, name), Namespace: namespace, Size: t.Len()})
+	if err != nil {
+		return nil, err
+	}
+	if t.Name() != "" {
+		si.fixed[t] = fixed.(*FixedSchema)
+	}
+	return fixed, nil
+}
+
+// define remembers the full name of a given named type. Returns a fixed type built before instead of a given one if
+// they are the same, or an error if another type with the same full name was built before.
+func (si *schemaInferrer) define(schema Schema) (Schema, error) {
+	fullName := GetFullName(schema)
+	defined, exists := si.names[fullName]
+	if !exists {
+		si.names[fullName] = schema
+		return schema, nil
+	}
+
+	if fixed, ok := schema.(*FixedSchema); ok {
+		if other, ok := defined.(*FixedSchema); ok && other.Size == fixed.Size && reflect.DeepEqual(other.LogicalType, fixed.LogicalType) {
+			return other, nil
+		}
+	}
+	return nil, fmt.Errorf("Duplicate type name %s", fullName)
+}
+
+func (si *schemaInferrer) logical(t reflect.Type, name string, namespace string, options map[string]string) (Schema, error) {
+	logicalName, ok := options[tagLogical]
+	if !ok {
+		switch t {
+		case timeType:
+			logicalName = LogicalTimestampMillis
+		case durationType:
+			logicalName = LogicalTimeMicros
+		case ratType:
+			logicalName = LogicalDecimal
+		case avroDurationType:
+			logicalName = LogicalDuration
+		}
+	}
+
+	// build the annotation as it would be parsed from JSON to validate it the same way
+	v := map[string]interface{}{schemaLogicalTypeField: logicalName}
+	for _, option := range []string{tagPrecision, tagScale} {
+		if raw, ok := options[option]; ok {
+			number, err := strconv.Atoi(raw)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid %s %s", option, raw)
+			}
+			v[option] = float64(number)
+		}
+	}
+
+	var schema Schema
+	switch logicalName {
+	case LogicalDate, LogicalTimeMillis:
+		schema = &IntSchema{LogicalType: parseLogicalType(v, Int, 0)}
+	case LogicalTimeMicros, LogicalTimestampMillis, LogicalTimestampMicros, LogicalLocalTimestampMillis, LogicalLocalTimestampMicros:
+		schema = &LongSchema{LogicalType: parseLogicalType(v, Long, 0)}
+	case LogicalUUID:
+		schema = &StringSchema{LogicalType: parseLogicalType(v, String, 0)}
+	case LogicalDecimal:
+		if t.Kind() == reflect.Array {
+			schema = &FixedSchema{Name: typeNameOr(t, name), Namespace: namespace, Size: t.Len(), LogicalType: parseLogicalType(v, Fixed, t.Len())}
+		} else {
+			schema = &BytesSchema{LogicalType: parseLogicalType(v, Bytes, 0)}
+		}
+	case LogicalDuration:
+		schema = &FixedSchema{Name: typeNameOr(t, name), Namespace: namespace, Size: durationSize, LogicalType: parseLogicalType(v, Fixed, durationSize)}
+	}
+
+	if schema == nil || GetLogicalType(schema) == nil {
+		return nil, fmt.Errorf("Invalid logical type %s for Go type %s", logicalName, t)
+	}
+	if schema.Type() == Fixed {
+		return si.define(schema)
+	}
+	return schema, nil
+}
+
+// elemType returns the element type of a slice or map dereferencing pointers to structs.
+func elemType(t reflect.Type) reflect.Type {
+	elem := t.Elem()
+	if elem.Kind() == reflect.Ptr && elem.Elem().Kind() == reflect.Struct {
+		return elem.Elem()
+	}
+	return elem
+}
+
+// typeNameOr returns the name of a given Go type or a given fallback name for unnamed types.
+func typeNameOr(t reflect.Type, name string) string {
+	if t.Name() != "" {
+		return t.Name()
+	}
+	return name
+}
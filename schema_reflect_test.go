@@ -0,0 +1,269 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type reflectAddress struct {
+	City    string
+	ZipCode string `avro:"zip_code"`
+}
+
+type reflectBase struct {
+	ID int64 `avro:"id"`
+}
+
+type reflectUser struct {
+	_ struct{} `avro:"User,namespace=com.example,doc=A user, with commas"`
+	reflectBase
+	Name    string            `avro:"name,doc=Full name"`
+	Age     int32             `avro:"age,default=18"`
+	Email   string            `avro:"email,nullable"`
+	Country string            `avro:"country,default=US"`
+	Score   float64           `avro:"score"`
+	Tags    []string          `avro:"tags"`
+	Attrs   map[string]int32  `avro:"attrs"`
+	Hash    [4]byte           `avro:"hash"`
+	Address *reflectAddress   `avro:"address"`
+	Home    reflectAddress    `avro:"home"`
+	Visited []*reflectAddress `avro:"visited"`
+	Created time.Time         `avro:"created"`
+	Updated time.Time         `avro:"updated,logical=timestamp-micros"`
+	Secret  string            `avro:"-"`
+	hidden  int32
+}
+
+type reflectNode struct {
+	Value int64
+	Next  *reflectNode
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema, err := SchemaOf(&reflectUser{})
+	assert(t, err, nil)
+
+	record := schema.(*RecordSchema)
+	assert(t, record.Name, "User")
+	assert(t, record.Namespace, "com.example")
+	assert(t, record.Doc, "A user, with commas")
+
+	names := make([]string, 0, len(record.Fields))
+	for _, field := range record.Fields {
+		names = append(names, field.Name)
+	}
+	assert(t, names, []string{"id", "name", "age", "email", "country", "score", "tags", "attrs", "hash", "address", "home", "visited", "created", "updated"})
+
+	assert(t, record.Fields[0].Type.Type(), Long)
+	assert(t, record.Fields[1].Doc, "Full name")
+	assert(t, record.Fields[2].Default, int32(18))
+	assert(t, record.Fields[3].Type.(*UnionSchema).Types[0].Type(), Null)
+	assert(t, record.Fields[3].Type.(*UnionSchema).Types[1].Type(), String)
+	assert(t, record.Fields[4].Default, "US")
+	assert(t, record.Fields[5].Type.Type(), Double)
+	assert(t, record.Fields[6].Type.(*ArraySchema).Items.Type(), String)
+	assert(t, record.Fields[7].Type.(*MapSchema).Values.Type(), Int)
+	assert(t, record.Fields[8].Type.(*FixedSchema).Size, 4)
+	assert(t, record.Fields[8].Type.(*FixedSchema).Name, "Hash")
+
+	address := record.Fields[9].Type.(*UnionSchema).Types[1].(*RecordSchema)
+	assert(t, address.Name, "reflectAddress")
+	assert(t, address.Namespace, "com.example")
+	assert(t, address.Fields[0].Name, "city")
+	assert(t, address.Fields[1].Name, "zip_code")
+	assert(t, record.Fields[10].Type.(*RecursiveSchema).Actual, address)
+	assert(t, record.Fields[11].Type.(*ArraySchema).Items.(*RecursiveSchema).Actual, address)
+
+	assert(t, GetLogicalType(record.Fields[12].Type).Name, LogicalTimestampMillis)
+	assert(t, GetLogicalType(record.Fields[13].Type).Name, LogicalTimestampMicros)
+
+	parsed, err := ParseSchema(schema.String())
+	assert(t, err, nil)
+	assert(t, CanonicalForm(parsed), CanonicalForm(schema))
+}
+
+func TestSchemaOfRecursive(t *testing.T) {
+	schema, err := SchemaOf(reflectNode{})
+	assert(t, err, nil)
+
+	record := schema.(*RecordSchema)
+	assert(t, record.Name, "reflectNode")
+	next := record.Fields[1].Type.(*UnionSchema)
+	assert(t, next.Types[1].(*RecursiveSchema).Actual, record)
+	assert(t, CanonicalForm(schema), `{"name":"reflectNode","type":"record","fields":[{"name":"value","type":"long"},{"name":"next","type":["null","reflectNode"]}]}`)
+}
+
+type reflectHash [4]byte
+
+func TestSchemaOfRepeatedFixed(t *testing.T) {
+	type Hashes struct {
+		_        struct{} `avro:"Hashes,namespace=com.example"`
+		Current  reflectHash
+		Previous reflectHash
+		Other    [4]byte
+	}
+	schema, err := SchemaOf(Hashes{})
+	assert(t, err, nil)
+	assert(t, schema.String(), `{
+    "type": "record",
+    "namespace": "com.example",
+    "name": "Hashes",
+    "fields": [
+        {
+            "name": "current",
+            "type": {
+                "type": "fixed",
+                "size": 4,
+                "name": "reflectHash",
+                "namespace": "com.example"
+            }
+        },
+        {
+            "name": "previous",
+            "type": "com.example.reflectHash"
+        },
+        {
+            "name": "other",
+            "type": {
+                "type": "fixed",
+                "size": 4,
+                "name": "Other",
+                "namespace": "com.example"
+            }
+        }
+    ]
+}`)
+
+	parsed, err := ParseSchema(schema.String())
+	assert(t, err, nil)
+	assert(t, CanonicalForm(parsed), CanonicalForm(schema))
+
+	in := &Hashes{Current: reflectHash{1, 2, 3, 4}, Previous: reflectHash{5, 6, 7, 8}, Other: [4]byte{9, 9, 9, 9}}
+	buffer := &bytes.Buffer{}
+	writer := NewSpecificDatumWriter()
+	writer.SetSchema(schema)
+	assert(t, writer.Write(in, NewBinaryEncoder(buffer)), nil)
+
+	out := &Hashes{}
+	reader := NewSpecificDatumReader()
+	reader.SetSchema(parsed)
+	assert(t, reader.Read(out, NewBinaryDecoder(buffer.Bytes())), nil)
+	assert(t, out, in)
+}
+
+func TestSchemaOfFixedFieldNames(t *testing.T) {
+	type Source struct {
+		Hash [4]byte
+	}
+	type Target struct {
+		Hash [4]byte
+	}
+	type Transfer struct {
+		_      struct{} `avro:"Transfer,namespace=com.example"`
+		Source Source
+		Target Target
+	}
+	schema, err := SchemaOf(Transfer{})
+	assert(t, err, nil)
+
+	record := schema.(*RecordSchema)
+	source := record.Fields[0].Type.(*RecordSchema)
+	target := record.Fields[1].Type.(*RecordSchema)
+	assert(t, target.Fields[0].Type == source.Fields[0].Type, true)
+
+	parsed, err := ParseSchema(schema.String())
+	assert(t, err, nil)
+	assert(t, CanonicalForm(parsed), CanonicalForm(schema))
+
+	type Digest struct {
+		Hash [16]byte
+	}
+	_, err = SchemaOf(struct {
+		Source Source
+		Digest Digest
+	}{})
+	assert(t, err.Error(), "Field digest: Field hash: Duplicate type name Hash")
+
+	type Other struct {
+		_ struct{} `avro:"Source"`
+	}
+	_, err = SchemaOf(struct {
+		Source Source
+		Other  Other
+	}{})
+	assert(t, err.Error(), "Field other: Duplicate type name Source")
+}
+
+func TestSchemaOfErrors(t *testing.T) {
+	_, err := SchemaOf(42)
+	assert(t, err.Error(), "SchemaOf requires a struct, got int")
+
+	_, err = SchemaOf(struct{ Count int }{})
+	assert(t, err.Error(), "Field count: Unsupported Go type int")
+
+	_, err = SchemaOf(struct {
+		Price int64 `avro:"price,logical=decimal"`
+	}{})
+	assert(t, err.Error(), "Field price: Invalid logical type decimal for Go type int64")
+
+	_, err = SchemaOf(struct {
+		Age int32 `avro:"age,default=old"`
+	}{})
+	assert(t, err != nil, true)
+}
+
+func TestSchemaOfRoundTrip(t *testing.T) {
+	schema, err := SchemaOf(reflectUser{})
+	assert(t, err, nil)
+
+	user := &reflectUser{
+		Name:    "alice",
+		Age:     30,
+		Email:   "alice@example.com",
+		Country: "NL",
+		Score:   0.75,
+		Tags:    []string{"a", "b"},
+		Attrs:   map[string]int32{"x": 1},
+		Hash:    [4]byte{1, 2, 3, 4},
+		Address: &reflectAddress{City: "Amsterdam", ZipCode: "1000"},
+		Home:    reflectAddress{City: "Utrecht", ZipCode: "3500"},
+		Visited: []*reflectAddress{{City: "Berlin"}},
+		Created: time.Unix(1500000000, 0).UTC(),
+		Updated: time.Unix(1500000000, 123000).UTC(),
+	}
+	user.ID = 7
+
+	buffer := &bytes.Buffer{}
+	writer := NewSpecificDatumWriter()
+	writer.SetSchema(schema)
+	assert(t, writer.Write(user, NewBinaryEncoder(buffer)), nil)
+
+	decoded := &reflectUser{}
+	reader := NewSpecificDatumReader()
+	reader.SetSchema(schema)
+	assert(t, reader.Read(decoded, NewBinaryDecoder(buffer.Bytes())), nil)
+	assert(t, decoded, user)
+}
+
+func TestSchemaOfOptionalPrimitives(t *testing.T) {
+	type optional struct {
+		Rank     *int32  `avro:"rank"`
+		Nickname *string `avro:"nickname"`
+	}
+	schema, err := SchemaOf(optional{})
+	assert(t, err, nil)
+
+	rank := int32(5)
+	buffer := &bytes.Buffer{}
+	writer := NewSpecificDatumWriter()
+	writer.SetSchema(schema)
+	assert(t, writer.Write(&optional{Rank: &rank}, NewBinaryEncoder(buffer)), nil)
+
+	decoded := &optional{}
+	reader := NewSpecificDatumReader()
+	reader.SetSchema(schema)
+	assert(t, reader.Read(decoded, NewBinaryDecoder(buffer.Bytes())), nil)
+	assert(t, *decoded.Rank, rank)
+	assert(t, decoded.Nickname == nil, true)
+}
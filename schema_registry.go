@@ -0,0 +1,218 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const schemaRegistryContentType = "application/vnd.schemaregistry.v1+json"
+
+// SchemaRegistry registers writer schemas and looks them up by IDs. It is used by ConfluentSerializer and
+// ConfluentDeserializer, SchemaRegistryClient implements it for Confluent Schema Registry.
+type SchemaRegistry interface {
+	// Register registers a given schema under a given subject and returns its ID. Registering an already registered
+	// schema returns the existing ID.
+	Register(subject string, schema Schema) (int, error)
+
+	// GetByID returns a schema with a given ID.
+	GetByID(id int) (Schema, error)
+}
+
+// RegisteredSchema is a schema version registered under a subject.
+type RegisteredSchema struct {
+	Subject string
+	Version int
+	ID      int
+	Schema  Schema
+}
+
+// SchemaRegistryError is returned by SchemaRegistryClient when Schema Registry responds with an error.
+type SchemaRegistryError struct {
+	// HTTP status code of the response.
+	StatusCode int
+
+	// Schema Registry specific error code, e.g. 40401 for unknown subject.
+	ErrorCode int `json:"error_code"`
+
+	// Error message returned by Schema Registry.
+	Message string `json:"message"`
+}
+
+// Error returns a human readable representation of this SchemaRegistryError.
+func (e *SchemaRegistryError) Error() string {
+	return fmt.Sprintf("Schema registry error %d (HTTP %d): %s", e.ErrorCode, e.StatusCode, e.Message)
+}
+
+// SchemaRegistryClient implements SchemaRegistry and talks to Confluent Schema Registry REST API. Registered schema IDs
+// and schemas looked up by IDs are cached as they never change. It is safe for concurrent use.
+type SchemaRegistryClient struct {
+	// HTTP client used to send requests, http.DefaultClient by default.
+	HTTPClient *http.Client
+
+	url string
+
+	// schema IDs by subject and schema JSON
+	ids map[string]int
+
+	// schemas by IDs
+	schemas map[int]Schema
+	lock    sync.RWMutex
+}
+
+// NewSchemaRegistryClient creates a new SchemaRegistryClient for Schema Registry with a given base URL,
+// e.g. "http://localhost:8081".
+func NewSchemaRegistryClient(url string) *SchemaRegistryClient {
+	return &SchemaRegistryClient{
+		HTTPClient: http.DefaultClient,
+		url:        strings.TrimSuffix(url, "/"),
+		ids:        make(map[string]int),
+		schemas:    make(map[int]Schema),
+	}
+}
+
+// Register registers a given schema under a given subject and returns its ID. Registering an already registered
+// schema returns the existing ID.
+func (c *SchemaRegistryClient) Register(subject string, schema Schema) (int, error) {
+	key := subject + "\x00" + schema.String()
+	c.lock.RLock()
+	id, ok := c.ids[key]
+	c.lock.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	response := &struct {
+		ID int `json:"id"`
+	}{}
+	if err := c.request(http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", schema, response); err != nil {
+		return 0, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.ids[key] = response.ID
+	c.schemas[response.ID] = schema
+	return response.ID, nil
+}
+
+// GetByID returns a schema with a given ID.
+func (c *SchemaRegistryClient) GetByID(id int) (Schema, error) {
+	c.lock.RLock()
+	schema, ok := c.schemas[id]
+	c.lock.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	response := &struct {
+		Schema string `json:"schema"`
+	}{}
+	if err := c.request(http.MethodGet, "/schemas/ids/"+strconv.Itoa(id), nil, response); err != nil {
+		return nil, err
+	}
+	schema, err := ParseSchema(response.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.schemas[id] = schema
+	return schema, nil
+}
+
+// GetVersions returns all schema versions registered under a given subject.
+func (c *SchemaRegistryClient) GetVersions(subject string) ([]int, error) {
+	var versions []int
+	if err := c.request(http.MethodGet, "/subjects/"+url.PathEscape(subject)+"/versions", nil, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetVersion returns a given schema version registered under a given subject.
+func (c *SchemaRegistryClient) GetVersion(subject string, version int) (*RegisteredSchema, error) {
+	return c.getVersion(subject, strconv.Itoa(version))
+}
+
+// GetLatestVersion returns the latest schema version registered under a given subject.
+func (c *SchemaRegistryClient) GetLatestVersion(subject string) (*RegisteredSchema, error) {
+	return c.getVersion(subject, "latest")
+}
+
+func (c *SchemaRegistryClient) getVersion(subject string, version string) (*RegisteredSchema, error) {
+	response := &struct {
+		Subject string `json:"subject"`
+		Version int    `json:"version"`
+		ID      int    `json:"id"`
+		Schema  string `json:"schema"`
+	}{}
+	if err := c.request(http.MethodGet, "/subjects/"+url.PathEscape(subject)+"/versions/"+version, nil, response); err != nil {
+		return nil, err
+	}
+	schema, err := ParseSchema(response.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.schemas[response.ID] = schema
+	return &RegisteredSchema{Subject: response.Subject, Version: response.Version, ID: response.ID, Schema: schema}, nil
+}
+
+// IsCompatible checks whether a given schema is compatible with the latest schema version registered under a given
+// subject according to the compatibility level configured in Schema Registry.
+func (c *SchemaRegistryClient) IsCompatible(subject string, schema Schema) (bool, error) {
+	response := &struct {
+		IsCompatible bool `json:"is_compatible"`
+	}{}
+	if err := c.request(http.MethodPost, "/compatibility/subjects/"+url.PathEscape(subject)+"/versions/latest", schema, response); err != nil {
+		return false, err
+	}
+	return response.IsCompatible, nil
+}
+
+// request sends a request with a given schema as body if not nil and decodes the JSON response into a given value.
+func (c *SchemaRegistryClient) request(method string, path string, schema Schema, response interface{}) error {
+	var body io.Reader
+	if schema != nil {
+		payload, err := json.Marshal(map[string]string{"schema": schema.String()})
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	request, err := http.NewRequest(method, c.url+path, body)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", schemaRegistryContentType)
+	if body != nil {
+		request.Header.Set("Content-Type", schemaRegistryContentType)
+	}
+
+	httpResponse, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		registryError := &SchemaRegistryError{StatusCode: httpResponse.StatusCode}
+		if err := json.NewDecoder(httpResponse.Body).Decode(registryError); err != nil {
+			registryError.Message = http.StatusText(httpResponse.StatusCode)
+		}
+		return registryError
+	}
+
+	return json.NewDecoder(httpResponse.Body).Decode(response)
+}
@@ -0,0 +1,186 @@
+package avro
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSchemaRegistry is an in-memory stand-in for Confluent Schema Registry REST API.
+type fakeSchemaRegistry struct {
+	schemas  []string
+	subjects map[string][]int
+	requests int
+	lock     sync.Mutex
+}
+
+func newFakeSchemaRegistry() *httptest.Server {
+	registry := &fakeSchemaRegistry{subjects: make(map[string][]int)}
+	return httptest.NewServer(registry)
+}
+
+func (f *fakeSchemaRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.requests++
+
+	w.Header().Set("Content-Type", schemaRegistryContentType)
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 3 && parts[0] == "subjects" && r.Method == http.MethodPost:
+		schema := f.readSchema(r)
+		for id, registered := range f.schemas {
+			if registered == schema {
+				json.NewEncoder(w).Encode(map[string]int{"id": id + 1})
+				return
+			}
+		}
+		f.schemas = append(f.schemas, schema)
+		f.subjects[parts[1]] = append(f.subjects[parts[1]], len(f.schemas))
+		json.NewEncoder(w).Encode(map[string]int{"id": len(f.schemas)})
+	case len(parts) == 3 && parts[0] == "subjects":
+		if versions, ok := f.subjects[parts[1]]; ok {
+			result := make([]int, len(versions))
+			for i := range versions {
+				result[i] = i + 1
+			}
+			json.NewEncoder(w).Encode(result)
+			return
+		}
+		f.notFound(w, 40401, "Subject not found.")
+	case len(parts) == 4 && parts[0] == "subjects":
+		versions := f.subjects[parts[1]]
+		version := len(versions)
+		if parts[3] != "latest" {
+			version, _ = strconv.Atoi(parts[3])
+		}
+		if version < 1 || version > len(versions) {
+			f.notFound(w, 40402, "Version not found.")
+			return
+		}
+		id := versions[version-1]
+		json.NewEncoder(w).Encode(map[string]interface{}{"subject": parts[1], "version": version, "id": id, "schema": f.schemas[id-1]})
+	case len(parts) == 3 && parts[0] == "schemas":
+		id, _ := strconv.Atoi(parts[2])
+		if id < 1 || id > len(f.schemas) {
+			f.notFound(w, 40403, "Schema not found")
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"schema": f.schemas[id-1]})
+	case len(parts) == 5 && parts[0] == "compatibility":
+		versions := f.subjects[parts[2]]
+		latest := MustParseSchema(f.schemas[versions[len(versions)-1]-1])
+		compatible := len(CheckCompatibility(MustParseSchema(f.readSchema(r)), latest)) == 0
+		json.NewEncoder(w).Encode(map[string]bool{"is_compatible": compatible})
+	default:
+		f.notFound(w, 404, "Not found")
+	}
+}
+
+func (f *fakeSchemaRegistry) readSchema(r *http.Request) string {
+	body := make(map[string]string)
+	json.NewDecoder(r.Body).Decode(&body)
+	return body["schema"]
+}
+
+func (f *fakeSchemaRegistry) notFound(w http.ResponseWriter, code int, message string) {
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error_code": code, "message": message})
+}
+
+var registryTestSchema = MustParseSchema(`{"type": "record", "name": "User", "fields": [
+    {"name": "name", "type": "string"}
+]}`)
+
+var registryTestSchemaV2 = MustParseSchema(`{"type": "record", "name": "User", "fields": [
+    {"name": "name", "type": "string"},
+    {"name": "age", "type": "int", "default": 0}
+]}`)
+
+func TestSchemaRegistryClient(t *testing.T) {
+	server := newFakeSchemaRegistry()
+	defer server.Close()
+	client := NewSchemaRegistryClient(server.URL + "/")
+
+	id, err := client.Register("users-value", registryTestSchema)
+	assert(t, err, nil)
+	assert(t, id, 1)
+
+	compatible, err := client.IsCompatible("users-value", registryTestSchemaV2)
+	assert(t, err, nil)
+	assert(t, compatible, true)
+	compatible, err = client.IsCompatible("users-value", MustParseSchema(`"int"`))
+	assert(t, err, nil)
+	assert(t, compatible, false)
+
+	id, err = client.Register("users-value", registryTestSchemaV2)
+	assert(t, err, nil)
+	assert(t, id, 2)
+
+	versions, err := client.GetVersions("users-value")
+	assert(t, err, nil)
+	assert(t, versions, []int{1, 2})
+
+	version, err := client.GetVersion("users-value", 1)
+	assert(t, err, nil)
+	assert(t, version.ID, 1)
+	assert(t, version.Version, 1)
+	assert(t, CanonicalForm(version.Schema), CanonicalForm(registryTestSchema))
+
+	latest, err := client.GetLatestVersion("users-value")
+	assert(t, err, nil)
+	assert(t, latest.ID, 2)
+
+	schema, err := NewSchemaRegistryClient(server.URL).GetByID(2)
+	assert(t, err, nil)
+	assert(t, CanonicalForm(schema), CanonicalForm(registryTestSchemaV2))
+
+	_, err = client.GetVersions("unknown")
+	registryError, ok := err.(*SchemaRegistryError)
+	assert(t, ok, true)
+	assert(t, registryError.StatusCode, http.StatusNotFound)
+	assert(t, registryError.ErrorCode, 40401)
+}
+
+func TestSchemaRegistryClientCaching(t *testing.T) {
+	registry := &fakeSchemaRegistry{subjects: make(map[string][]int)}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	client := NewSchemaRegistryClient(server.URL)
+
+	for i := 0; i < 3; i++ {
+		id, err := client.Register("users-value", registryTestSchema)
+		assert(t, err, nil)
+		schema, err := client.GetByID(id)
+		assert(t, err, nil)
+		assert(t, schema, registryTestSchema)
+	}
+	assert(t, registry.requests, 1)
+}
+
+func TestConfluentRoundTrip(t *testing.T) {
+	server := newFakeSchemaRegistry()
+	defer server.Close()
+
+	serializer := NewConfluentSerializer(NewSchemaRegistryClient(server.URL), "users-value", registryTestSchema, NewSpecificDatumWriter())
+	data, err := serializer.Serialize(&struct{ Name string }{Name: "alice"})
+	assert(t, err, nil)
+	assert(t, data[:5], []byte{0, 0, 0, 0, 1})
+
+	id, err := ConfluentSchemaID(data)
+	assert(t, err, nil)
+	assert(t, id, 1)
+
+	deserializer := NewConfluentDeserializer(NewSchemaRegistryClient(server.URL), func() DatumReader { return NewGenericDatumReader() })
+	deserializer.SetReaderSchema(registryTestSchemaV2)
+	record := NewGenericRecord(registryTestSchemaV2)
+	assert(t, deserializer.Deserialize(record, data), nil)
+	assert(t, record.Get("name"), "alice")
+	assert(t, record.Get("age"), int32(0))
+
+	assert(t, deserializer.Deserialize(record, []byte{1, 0, 0, 0, 1}), NotConfluentMessage)
+}
@@ -0,0 +1,666 @@
+package avro
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Artificial schema type constants for schemas produced by resolving a writer schema against a reader schema.
+// These never appear in parsed schemas and are only understood by datum readers.
+const (
+	resolvedRecord = Recursive + 1 + iota
+	resolvedEnum
+	resolvedUnion
+	unionBranch
+	promoted
+)
+
+// publicType returns the type constant of a given schema, replacing artificial types with the types they stand for.
+func publicType(schema Schema) int {
+	switch s := schema.(type) {
+	case *resolvedRecordSchema, *RecursiveSchema:
+		return Record
+	case *resolvedEnumSchema:
+		return Enum
+	case *resolvedUnionSchema:
+		return Union
+	case *unionBranchSchema:
+		return publicType(s.Schema)
+	case *promotedSchema:
+		return publicType(s.writer)
+	}
+	return schema.Type()
+}
+
+// resolvedRecordSchema describes how to read a record written with one schema into a record of another.
+type resolvedRecordSchema struct {
+	*RecordSchema
+
+	// fields are in writer order. Fields that do not exist in reader schema have an empty name and are skipped.
+	fields []*resolvedField
+
+	// defaults are reader fields that are absent in writer schema and should be filled with default values.
+	defaults []*SchemaField
+}
+
+type resolvedField struct {
+	name   string
+	schema Schema
+
+	// writer field schema that is used to skip this field
+	writer Schema
+}
+
+// Type returns an artificial type constant for this resolvedRecordSchema.
+func (*resolvedRecordSchema) Type() int {
+	return resolvedRecord
+}
+
+// resolvedEnumSchema maps writer enum indexes to reader enum indexes. Unknown symbols are mapped to -1.
+type resolvedEnumSchema struct {
+	*EnumSchema
+	writer  *EnumSchema
+	mapping []int32
+}
+
+// Type returns an artificial type constant for this resolvedEnumSchema.
+func (*resolvedEnumSchema) Type() int {
+	return resolvedEnum
+}
+
+func (s *resolvedEnumSchema) readerIndex(writerIndex int32) (int32, error) {
+	if writerIndex < 0 || writerIndex >= int32(len(s.mapping)) {
+		return 0, fmt.Errorf("Invalid enum index %d for enum %s", writerIndex, s.writer.Name)
+	}
+	index := s.mapping[writerIndex]
+	if index < 0 {
+		return 0, fmt.Errorf("Enum symbol %s does not exist in reader enum %s", s.writer.Symbols[writerIndex], s.Name)
+	}
+	return index, nil
+}
+
+// resolvedUnionSchema holds a resolved schema for each branch of a writer union.
+// Branches that cannot be read with reader schema are nil.
+type resolvedUnionSchema struct {
+	Schema
+	writer   *UnionSchema
+	branches []Schema
+}
+
+// Type returns an artificial type constant for this resolvedUnionSchema.
+func (*resolvedUnionSchema) Type() int {
+	return resolvedUnion
+}
+
+func (s *resolvedUnionSchema) branch(index int32) (Schema, error) {
+	if index < 0 || index >= int32(len(s.branches)) {
+		return nil, UnionTypeOverflow
+	}
+	branch := s.branches[index]
+	if branch == nil {
+		return nil, fmt.Errorf("Writer union branch %s cannot be read as %s", s.writer.Types[index].GetName(), s.Schema.GetName())
+	}
+	return branch, nil
+}
+
+// unionBranchSchema is a resolved schema of a reader union branch along with the index of the branch.
+type unionBranchSchema struct {
+	Schema
+	index int
+}
+
+// Type returns an artificial type constant for this unionBranchSchema.
+func (*unionBranchSchema) Type() int {
+	return unionBranch
+}
+
+// promotedSchema reads a primitive value of writer type and converts it to reader type.
+type promotedSchema struct {
+	Schema
+	writer Schema
+}
+
+// Type returns an artificial type constant for this promotedSchema.
+func (*promotedSchema) Type() int {
+	return promoted
+}
+
+func (s *promotedSchema) promote(value interface{}) interface{} {
+	switch s.Schema.Type() {
+	case Long:
+		return int64(value.(int32))
+	case Float:
+		switch v := value.(type) {
+		case int32:
+			return float32(v)
+		case int64:
+			return float32(v)
+		}
+	case Double:
+		switch v := value.(type) {
+		case int32:
+			return float64(v)
+		case int64:
+			return float64(v)
+		case float32:
+			return float64(v)
+		}
+	case String:
+		return string(value.([]byte))
+	case Bytes:
+		return []byte(value.(string))
+	}
+
+	return value
+}
+
+// schemaResolution keeps both writer and reader schemas for a datum reader and the result of resolving them.
+type schemaResolution struct {
+	writer   Schema
+	reader   Schema
+	resolved Schema
+	err      error
+}
+
+func (sr *schemaResolution) setWriterSchema(schema Schema) {
+	sr.writer = schema
+	sr.resolve()
+}
+
+func (sr *schemaResolution) setReaderSchema(schema Schema) {
+	sr.reader = schema
+	sr.resolve()
+}
+
+func (sr *schemaResolution) resolve() {
+	sr.resolved, sr.err = sr.writer, nil
+	if sr.writer != nil && sr.reader != nil && sr.writer != sr.reader {
+		sr.resolved, sr.err = resolveSchema(sr.writer, sr.reader)
+	}
+}
+
+// schema returns the schema to read data with.
+func (sr *schemaResolution) schema() (Schema, error) {
+	if sr.err != nil {
+		return nil, sr.err
+	}
+	if sr.resolved == nil {
+		return nil, SchemaNotSet
+	}
+	return sr.resolved, nil
+}
+
+// resolving returns true if the data is read with a schema different from writer schema.
+func (sr *schemaResolution) resolving() bool {
+	return sr.resolved != sr.writer
+}
+
+// resolveSchema resolves writer schema against reader schema according to the Avro schema resolution rules
+// (https://avro.apache.org/docs/1.8.0/spec.html#Schema+Resolution) and returns a schema to read data with.
+// May return an error if the data written with writer schema cannot be read with reader schema.
+func resolveSchema(writer Schema, reader Schema) (Schema, error) {
+	r := &resolver{records: make(map[resolverKey]*resolvedRecordSchema)}
+	return r.resolve(writer, reader)
+}
+
+type resolverKey struct {
+	writer *RecordSchema
+	reader *RecordSchema
+}
+
+type resolver struct {
+	// records that are already resolved or are being resolved. Prevents infinite recursion for recursive types.
+	records map[resolverKey]*resolvedRecordSchema
+}
+
+func (r *resolver) resolve(writer Schema, reader Schema) (Schema, error) {
+	writer = actualSchema(writer)
+	reader = actualSchema(reader)
+
+	if writer.Type() == Union {
+		return r.resolveWriterUnion(writer.(*UnionSchema), reader)
+	}
+
+	switch reader.Type() {
+	case Union:
+		return r.resolveReaderUnion(writer, reader.(*UnionSchema))
+	case Null, Boolean, Int, Long, Float, Double, Bytes, String:
+		if writer.Type() == reader.Type() {
+			return reader, nil
+		}
+		if isPromotable(writer.Type(), reader.Type()) {
+			return &promotedSchema{Schema: reader, writer: writer}, nil
+		}
+	case Array:
+		if writer.Type() == Array {
+			items, err := r.resolve(writer.(*ArraySchema).Items, reader.(*ArraySchema).Items)
+			if err != nil {
+				return nil, err
+			}
+			return &ArraySchema{Items: items, Properties: reader.(*ArraySchema).Properties}, nil
+		}
+	case Map:
+		if writer.Type() == Map {
+			values, err := r.resolve(writer.(*MapSchema).Values, reader.(*MapSchema).Values)
+			if err != nil {
+				return nil, err
+			}
+			return &MapSchema{Values: values, Properties: reader.(*MapSchema).Properties}, nil
+		}
+	case Enum:
+		if writer.Type() == Enum && namesMatch(writer, reader) {
+			return r.resolveEnum(writer.(*EnumSchema), reader.(*EnumSchema)), nil
+		}
+	case Fixed:
+		if writer.Type() == Fixed && namesMatch(writer, reader) {
+			if writer.(*FixedSchema).Size != reader.(*FixedSchema).Size {
+				return nil, fmt.Errorf("Fixed %s size mismatch: writer %d, reader %d", reader.GetName(), writer.(*FixedSchema).Size, reader.(*FixedSchema).Size)
+			}
+			return reader, nil
+		}
+	case Record:
+		if writer.Type() == Record && namesMatch(writer, reader) {
+			return r.resolveRecord(writer.(*RecordSchema), reader.(*RecordSchema))
+		}
+	}
+
+	return nil, fmt.Errorf("Writer schema %s cannot be read as %s", writer.GetName(), reader.GetName())
+}
+
+func (r *resolver) resolveRecord(writer *RecordSchema, reader *RecordSchema) (Schema, error) {
+	key := resolverKey{writer: writer, reader: reader}
+	if resolved, exists := r.records[key]; exists {
+		return resolved, nil
+	}
+
+	resolved := &resolvedRecordSchema{RecordSchema: reader}
+	r.records[key] = resolved
+
+	readerFields := fieldsByName(reader.Fields)
+	written := make(map[string]bool)
+	for _, writerField := range writer.Fields {
+		readerField, exists := readerFields[writerField.Name]
+		if !exists || written[readerField.Name] {
+			resolved.fields = append(resolved.fields, &resolvedField{writer: writerField.Type})
+			continue
+		}
+
+		schema, err := r.resolve(writerField.Type, readerField.Type)
+		if err != nil {
+			return nil, fmt.Errorf("Field %s.%s: %s", reader.Name, readerField.Name, err)
+		}
+		resolved.fields = append(resolved.fields, &resolvedField{name: readerField.Name, schema: schema, writer: writerField.Type})
+		written[readerField.Name] = true
+	}
+
+	for _, readerField := range reader.Fields {
+		if written[readerField.Name] {
+			continue
+		}
+		if !hasDefault(readerField) {
+			return nil, fmt.Errorf("Field %s.%s is missing in writer schema and has no default value", reader.Name, readerField.Name)
+		}
+		resolved.defaults = append(resolved.defaults, readerField)
+	}
+
+	return resolved, nil
+}
+
+// namesMatch checks whether a writer named type may be read as a reader one: either their names are the same or the
+// writer name is one of reader aliases. Like names, aliases are compared without namespaces.
+func namesMatch(writer Schema, reader Schema) bool {
+	name := writer.GetName()
+	if name == reader.GetName() {
+		return true
+	}
+
+	var aliases []string
+	switch s := actualSchema(reader).(type) {
+	case *RecordSchema:
+		aliases = s.Aliases
+	case *EnumSchema:
+		aliases = s.Aliases
+	case *FixedSchema:
+		aliases = s.Aliases
+	}
+	for _, alias := range aliases {
+		if alias[strings.LastIndex(alias, ".")+1:] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldsByName returns given record fields by their names and aliases, names take precedence over aliases.
+func fieldsByName(fields []*SchemaField) map[string]*SchemaField {
+	byName := make(map[string]*SchemaField)
+	for _, field := range fields {
+		for _, alias := range field.Aliases {
+			byName[alias] = field
+		}
+	}
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+	return byName
+}
+
+func (r *resolver) resolveEnum(writer *EnumSchema, reader *EnumSchema) Schema {
+	if reflect.DeepEqual(writer.Symbols, reader.Symbols) {
+		return reader
+	}
+
+	readerIndexes := make(map[string]int32)
+	for index, symbol := range reader.Symbols {
+		readerIndexes[symbol] = int32(index)
+	}
+	mapping := make([]int32, len(writer.Symbols))
+	for index, symbol := range writer.Symbols {
+		if readerIndex, exists := readerIndexes[symbol]; exists {
+			mapping[index] = readerIndex
+		} else {
+			mapping[index] = -1
+		}
+	}
+
+	return &resolvedEnumSchema{EnumSchema: reader, writer: writer, mapping: mapping}
+}
+
+func (r *resolver) resolveWriterUnion(writer *UnionSchema, reader Schema) (Schema, error) {
+	resolved := &resolvedUnionSchema{Schema: reader, writer: writer, branches: make([]Schema, len(writer.Types))}
+	matched := false
+	for i, branch := range writer.Types {
+		schema, err := r.resolve(branch, reader)
+		if err == nil {
+			resolved.branches[i] = schema
+			matched = true
+		}
+	}
+
+	if !matched {
+		return nil, fmt.Errorf("None of writer union branches can be read as %s", reader.GetName())
+	}
+	return resolved, nil
+}
+
+// resolveReaderUnion picks the first reader union branch that matches writer schema exactly
+// and falls back to the first branch writer schema can be promoted to.
+func (r *resolver) resolveReaderUnion(writer Schema, reader *UnionSchema) (Schema, error) {
+	for i, branch := range reader.Types {
+		branch = actualSchema(branch)
+		if branch.Type() == writer.Type() && namesMatch(writer, branch) {
+			return r.resolveUnionBranch(writer, branch, i)
+		}
+	}
+
+	for i, branch := range reader.Types {
+		if isPromotable(writer.Type(), actualSchema(branch).Type()) {
+			return r.resolveUnionBranch(writer, branch, i)
+		}
+	}
+
+	return nil, fmt.Errorf("Writer schema %s does not match any reader union branch", writer.GetName())
+}
+
+// resolveUnionBranch resolves a writer schema against a reader union branch keeping the branch index, so that
+// values can be read into union wrappers.
+func (r *resolver) resolveUnionBranch(writer Schema, branch Schema, index int) (Schema, error) {
+	schema, err := r.resolve(writer, branch)
+	if err != nil {
+		return nil, err
+	}
+	return &unionBranchSchema{Schema: schema, index: index}, nil
+}
+
+func isPromotable(writer int, reader int) bool {
+	switch writer {
+	case Int:
+		return reader == Long || reader == Float || reader == Double
+	case Long:
+		return reader == Float || reader == Double
+	case Float:
+		return reader == Double
+	case String:
+		return reader == Bytes
+	case Bytes:
+		return reader == String
+	}
+
+	return false
+}
+
+// actualSchema unwraps recursive and prepared schemas.
+func actualSchema(schema Schema) Schema {
+	switch s := schema.(type) {
+	case *RecursiveSchema:
+		return s.Actual
+	case *preparedRecordSchema:
+		return &s.RecordSchema
+	}
+
+	return schema
+}
+
+// hasDefault returns true if a given field has a default value. As JSON null defaults are indistinguishable from
+// missing ones, fields that may be null are considered to always have a default.
+func hasDefault(field *SchemaField) bool {
+	if field.Default != nil {
+		return true
+	}
+
+	switch field.Type.Type() {
+	case Null:
+		return true
+	case Union:
+		return len(field.Type.(*UnionSchema).Types) > 0 && field.Type.(*UnionSchema).Types[0].Type() == Null
+	}
+
+	return false
+}
+
+// defaultValue converts a JSON default value of a given schema to a value GenericDatumReader would produce.
+func defaultValue(schema Schema, value interface{}) (interface{}, error) {
+	converted, err := rawDefaultValue(schema, value)
+	if logicalType := GetLogicalType(schema); logicalType != nil && err == nil && converted != nil {
+		return logicalType.fromRaw(converted)
+	}
+
+	return converted, err
+}
+
+// rawDefaultValue converts a JSON default value of a given schema ignoring logical types.
+func rawDefaultValue(schema Schema, value interface{}) (interface{}, error) {
+	schema = actualSchema(schema)
+	if value == nil {
+		return nil, nil
+	}
+
+	invalid := fmt.Errorf("Invalid default value %v for type %s", value, schema.GetName())
+	switch schema.Type() {
+	case Null:
+		return nil, nil
+	case Boolean:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	case Int, Long, Float, Double:
+		var number float64
+		switch v := value.(type) {
+		case float64:
+			number = v
+		case float32:
+			number = float64(v)
+		case int32:
+			number = float64(v)
+		case int64:
+			number = float64(v)
+		default:
+			return nil, invalid
+		}
+		switch schema.Type() {
+		case Int:
+			return int32(number), nil
+		case Long:
+			return int64(number), nil
+		case Float:
+			return float32(number), nil
+		default:
+			return number, nil
+		}
+	case Bytes, Fixed:
+		if v, ok := value.(string); ok {
+			// Default values for bytes and fixed are JSON strings where Unicode code points 0-255 are mapped to
+			// unsigned 8-bit byte values 0-255.
+			bytes := make([]byte, 0, len(v))
+			for _, r := range v {
+				bytes = append(bytes, byte(r))
+			}
+			return bytes, nil
+		}
+	case String, Enum:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	case Array:
+		if v, ok := value.([]interface{}); ok {
+			array := make([]interface{}, len(v))
+			for i := range v {
+				item, err := defaultValue(schema.(*ArraySchema).Items, v[i])
+				if err != nil {
+					return nil, err
+				}
+				array[i] = item
+			}
+			return array, nil
+		}
+	case Map:
+		if v, ok := value.(map[string]interface{}); ok {
+			m := make(map[string]interface{})
+			for key := range v {
+				item, err := defaultValue(schema.(*MapSchema).Values, v[key])
+				if err != nil {
+					return nil, err
+				}
+				m[key] = item
+			}
+			return m, nil
+		}
+	case Union:
+		// Default value for union corresponds to the first schema in the union.
+		return defaultValue(schema.(*UnionSchema).Types[0], value)
+	case Record:
+		if v, ok := value.(map[string]interface{}); ok {
+			record := NewGenericRecord(schema)
+			for _, field := range schema.(*RecordSchema).Fields {
+				fieldValue, exists := v[field.Name]
+				if !exists {
+					fieldValue = field.Default
+				}
+				converted, err := defaultValue(field.Type, fieldValue)
+				if err != nil {
+					return nil, err
+				}
+				record.Set(field.Name, converted)
+			}
+			return record, nil
+		}
+	}
+
+	return nil, invalid
+}
+
+// setDefault sets a default value produced by defaultValue to a Go struct field.
+func setDefault(where reflect.Value, schema Schema, value interface{}) error {
+	schema = actualSchema(schema)
+	if value == nil {
+		return nil
+	}
+	if schema.Type() == Union {
+		return setDefault(where, schema.(*UnionSchema).Types[0], value)
+	}
+
+	switch v := value.(type) {
+	case *GenericRecord:
+		if where.Kind() == reflect.Ptr {
+			if where.IsNil() {
+				where.Set(reflect.New(where.Type().Elem()))
+			}
+			where = where.Elem()
+		}
+		if where.Kind() == reflect.Interface {
+			where.Set(reflect.ValueOf(v))
+			return nil
+		}
+		for _, field := range schema.(*RecordSchema).Fields {
+			structField, err := findField(where, field.Name)
+			if err != nil {
+				continue
+			}
+			if err := setDefault(structField, field.Type, v.Get(field.Name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		if where.Kind() != reflect.Slice {
+			break
+		}
+		array := reflect.MakeSlice(where.Type(), len(v), len(v))
+		for i := range v {
+			if err := setDefault(array.Index(i), schema.(*ArraySchema).Items, v[i]); err != nil {
+				return err
+			}
+		}
+		where.Set(array)
+		return nil
+	case map[string]interface{}:
+		if where.Kind() != reflect.Map {
+			break
+		}
+		m := reflect.MakeMap(where.Type())
+		for key := range v {
+			item := reflect.New(where.Type().Elem()).Elem()
+			if err := setDefault(item, schema.(*MapSchema).Values, v[key]); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key), item)
+		}
+		where.Set(m)
+		return nil
+	case string:
+		if schema.Type() == Enum && where.Type() == reflect.TypeOf(&GenericEnum{}) {
+			enum := NewGenericEnum(schema.(*EnumSchema).Symbols)
+			if _, exists := enum.symbolsToIndex[v]; !exists {
+				return fmt.Errorf("Invalid default enum symbol %s", v)
+			}
+			enum.Set(v)
+			where.Set(reflect.ValueOf(enum))
+			return nil
+		}
+	}
+
+	if logicalType := GetLogicalType(schema); logicalType != nil {
+		raw, err := logicalType.toRaw(value, schema)
+		if err != nil {
+			return err
+		}
+		converted, err := logicalType.specificValue(raw, where.Type())
+		if err != nil {
+			return err
+		}
+		value = converted.Interface()
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Type().AssignableTo(where.Type()) {
+		where.Set(rv)
+		return nil
+	}
+	if rv.Type().ConvertibleTo(where.Type()) {
+		where.Set(rv.Convert(where.Type()))
+		return nil
+	}
+
+	return fmt.Errorf("Cannot set default value %v to %s", value, where.Type())
+}
@@ -0,0 +1,382 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+var resolutionWriterSchema = MustParseSchema(`{
+    "type": "record",
+    "name": "Event",
+    "fields": [
+        {"name": "id", "type": "int"},
+        {"name": "removed", "type": {"type": "array", "items": {"type": "record", "name": "Removed", "fields": [
+            {"name": "value", "type": "string"}
+        ]}}},
+        {"name": "name", "type": "string"},
+        {"name": "score", "type": "float"},
+        {"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B", "C"]}},
+        {"name": "payload", "type": "string"},
+        {"name": "optional", "type": ["null", "int"]}
+    ]
+}`)
+
+var resolutionReaderSchema = MustParseSchema(`{
+    "type": "record",
+    "name": "Event",
+    "fields": [
+        {"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["C", "B", "A", "D"]}},
+        {"name": "optional", "type": ["null", "long"]},
+        {"name": "id", "type": "long"},
+        {"name": "score", "type": "double"},
+        {"name": "payload", "type": "bytes"},
+        {"name": "added", "type": "string", "default": "hello"},
+        {"name": "addedInt", "type": "int", "default": 42},
+        {"name": "addedArray", "type": {"type": "array", "items": "long"}, "default": [1, 2]},
+        {"name": "addedUnion", "type": ["null", "string"], "default": null}
+    ]
+}`)
+
+func resolutionWriterRecord() *GenericRecord {
+	removed := NewGenericRecord(resolutionWriterSchema)
+	removed.Set("value", "gone")
+
+	record := NewGenericRecord(resolutionWriterSchema)
+	record.Set("id", int32(123))
+	record.Set("removed", []interface{}{removed, removed})
+	record.Set("name", "event name")
+	record.Set("score", float32(1.5))
+	record.Set("kind", "B")
+	record.Set("payload", "some payload")
+	record.Set("optional", int32(7))
+	return record
+}
+
+func encodeGeneric(t *testing.T, schema Schema, value interface{}) []byte {
+	buffer := &bytes.Buffer{}
+	writer := NewGenericDatumWriter()
+	writer.SetSchema(schema)
+	err := writer.Write(value, NewBinaryEncoder(buffer))
+	assert(t, err, nil)
+	return buffer.Bytes()
+}
+
+func TestGenericDatumReaderResolution(t *testing.T) {
+	buf := encodeGeneric(t, resolutionWriterSchema, resolutionWriterRecord())
+
+	reader := NewGenericDatumReader()
+	reader.SetSchema(resolutionWriterSchema)
+	reader.SetReaderSchema(resolutionReaderSchema)
+
+	decoder := NewBinaryDecoder(buf)
+	record := NewGenericRecord(resolutionReaderSchema)
+	err := reader.Read(record, decoder)
+	assert(t, err, nil)
+	assert(t, decoder.Tell(), int64(len(buf)))
+
+	assert(t, record.Get("id"), int64(123))
+	assert(t, record.Get("name"), nil)
+	assert(t, record.Get("removed"), nil)
+	assert(t, record.Get("score"), float64(1.5))
+	assert(t, record.Get("kind"), "B")
+	assert(t, record.Get("payload"), []byte("some payload"))
+	assert(t, record.Get("optional"), int64(7))
+	assert(t, record.Get("added"), "hello")
+	assert(t, record.Get("addedInt"), int32(42))
+	assert(t, record.Get("addedArray"), []interface{}{int64(1), int64(2)})
+	assert(t, record.Get("addedUnion"), nil)
+	assert(t, record.Schema(), resolutionReaderSchema)
+}
+
+type resolvedEvent struct {
+	Kind       *GenericEnum
+	Optional   int64
+	Id         int64
+	Score      float64
+	Payload    []byte
+	Added      string
+	AddedInt   int32
+	AddedArray []int64
+}
+
+func TestSpecificDatumReaderResolution(t *testing.T) {
+	buf := encodeGeneric(t, resolutionWriterSchema, resolutionWriterRecord())
+
+	reader := NewSpecificDatumReader()
+	reader.SetSchema(resolutionWriterSchema)
+	reader.SetReaderSchema(resolutionReaderSchema)
+
+	decoder := NewBinaryDecoder(buf)
+	event := &resolvedEvent{}
+	err := reader.Read(event, decoder)
+	assert(t, err, nil)
+	assert(t, decoder.Tell(), int64(len(buf)))
+
+	assert(t, event.Id, int64(123))
+	assert(t, event.Score, float64(1.5))
+	assert(t, event.Kind.Get(), "B")
+	assert(t, event.Kind.Symbols, []string{"C", "B", "A", "D"})
+	assert(t, event.Payload, []byte("some payload"))
+	assert(t, event.Optional, int64(7))
+	assert(t, event.Added, "hello")
+	assert(t, event.AddedInt, int32(42))
+	assert(t, event.AddedArray, []int64{1, 2})
+}
+
+func TestSpecificDatumReaderResolutionSkip(t *testing.T) {
+	// field a is resolved to each of the artificial schema types, but the struct has no field for it
+	types := map[string][2]string{
+		"promoted":       {`"int"`, `"long"`},
+		"resolvedRecord": {`{"type": "record", "name": "R", "fields": [{"name": "x", "type": "int"}]}`, `{"type": "record", "name": "R", "fields": [{"name": "x", "type": "long"}]}`},
+		"resolvedEnum":   {`{"type": "enum", "name": "E", "symbols": ["X", "Y"]}`, `{"type": "enum", "name": "E", "symbols": ["Y", "X"]}`},
+		"resolvedUnion":  {`["null", "int"]`, `["null", "long"]`},
+		"unionBranch":    {`"int"`, `["null", "int"]`},
+	}
+	values := map[string]interface{}{
+		"promoted":       int32(5),
+		"resolvedRecord": nil,
+		"resolvedEnum":   "Y",
+		"resolvedUnion":  int32(5),
+		"unionBranch":    int32(5),
+	}
+	record := `{"type": "record", "name": "Rec", "fields": [{"name": "a", "type": %s}, {"name": "b", "type": "int"}]}`
+
+	for name, schemas := range types {
+		writerSchema := MustParseSchema(fmt.Sprintf(record, schemas[0]))
+		value := values[name]
+		if value == nil {
+			nested := NewGenericRecord(writerSchema.(*RecordSchema).Fields[0].Type)
+			nested.Set("x", int32(5))
+			value = nested
+		}
+		writerRecord := NewGenericRecord(writerSchema)
+		writerRecord.Set("a", value)
+		writerRecord.Set("b", int32(7))
+		buf := encodeGeneric(t, writerSchema, writerRecord)
+
+		reader := NewSpecificDatumReader()
+		reader.SetSchema(writerSchema)
+		reader.SetReaderSchema(MustParseSchema(fmt.Sprintf(record, schemas[1])))
+		decoder := NewBinaryDecoder(buf)
+		target := &struct{ B int32 }{}
+		if err := reader.Read(target, decoder); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		assert(t, target.B, int32(7))
+		assert(t, decoder.Tell(), int64(len(buf)))
+	}
+}
+
+func TestResolutionUnknownEnumSymbol(t *testing.T) {
+	writerSchema := MustParseSchema(`{"type": "enum", "name": "Kind", "symbols": ["A", "B", "C"]}`)
+	readerSchema := MustParseSchema(`{"type": "enum", "name": "Kind", "symbols": ["A", "B"]}`)
+
+	reader := NewGenericDatumReader()
+	reader.SetSchema(writerSchema)
+	reader.SetReaderSchema(readerSchema)
+
+	var value interface{}
+	err := reader.Read(&value, NewBinaryDecoder([]byte{0x02}))
+	assert(t, err, nil)
+	enum := value.(GenericEnum)
+	assert(t, enum.Get(), "B")
+
+	err = reader.Read(&value, NewBinaryDecoder([]byte{0x04}))
+	if err == nil {
+		t.Fatal("Expected an error for enum symbol missing in reader schema")
+	}
+}
+
+func TestResolutionWriterUnion(t *testing.T) {
+	writerSchema := MustParseSchema(`["int", "string"]`)
+	readerSchema := MustParseSchema(`"long"`)
+
+	reader := NewGenericDatumReader()
+	reader.SetSchema(writerSchema)
+	reader.SetReaderSchema(readerSchema)
+
+	var value interface{}
+	err := reader.Read(&value, NewBinaryDecoder(encodeGeneric(t, writerSchema, int32(5))))
+	assert(t, err, nil)
+	assert(t, value, int64(5))
+
+	err = reader.Read(&value, NewBinaryDecoder(encodeGeneric(t, writerSchema, "five")))
+	if err == nil {
+		t.Fatal("Expected an error for union branch missing in reader schema")
+	}
+}
+
+// stringOrBytes is a UnionWrapper of ["string", "bytes"].
+type stringOrBytes struct {
+	String *string
+	Bytes  *[]byte
+}
+
+func (u *stringOrBytes) UnionBranch() (int, interface{}) {
+	switch {
+	case u.String != nil:
+		return 0, *u.String
+	case u.Bytes != nil:
+		return 1, *u.Bytes
+	}
+	return -1, nil
+}
+
+func (u *stringOrBytes) SetUnionBranch(index int) (interface{}, error) {
+	*u = stringOrBytes{}
+	switch index {
+	case 0:
+		u.String = new(string)
+		return u.String, nil
+	case 1:
+		u.Bytes = new([]byte)
+		return u.Bytes, nil
+	}
+	return nil, UnionTypeOverflow
+}
+
+func TestResolutionUnionWrapper(t *testing.T) {
+	type Wrapped struct {
+		Value stringOrBytes
+	}
+	readerSchema := MustParseSchema(`{"type": "record", "name": "Wrapped", "fields": [{"name": "value", "type": ["string", "bytes"]}]}`)
+
+	for _, writer := range []struct {
+		schema string
+		value  interface{}
+	}{
+		{`"bytes"`, []byte{1, 2}},
+		{`["null", "bytes", "string"]`, []byte{1, 2}},
+		{`["null", "bytes", "string"]`, "text"},
+	} {
+		writerSchema := MustParseSchema(`{"type": "record", "name": "Wrapped", "fields": [{"name": "value", "type": ` + writer.schema + `}]}`)
+		record := NewGenericRecord(writerSchema)
+		record.Set("value", writer.value)
+
+		reader := NewSpecificDatumReader()
+		reader.SetSchema(writerSchema)
+		reader.SetReaderSchema(readerSchema)
+
+		wrapped := &Wrapped{}
+		err := reader.Read(wrapped, NewBinaryDecoder(encodeGeneric(t, writerSchema, record)))
+		assert(t, err, nil)
+		_, value := wrapped.Value.UnionBranch()
+		assert(t, value, writer.value)
+	}
+}
+
+func TestResolutionAliases(t *testing.T) {
+	writerSchema := MustParseSchema(`{"type": "record", "name": "Person", "namespace": "org.legacy", "fields": [
+		{"name": "fullName", "type": "string"},
+		{"name": "kind", "type": {"type": "enum", "name": "Type", "symbols": ["A", "B"]}},
+		{"name": "hash", "type": {"type": "fixed", "name": "Md5", "size": 2}}
+	]}`)
+	readerSchema := MustParseSchema(`{"type": "record", "name": "User", "namespace": "com.example", "aliases": ["org.legacy.Person"], "fields": [
+		{"name": "name", "type": "string", "aliases": ["fullName"]},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "aliases": ["Type"], "symbols": ["B", "A"]}},
+		{"name": "hash", "type": ["null", {"type": "fixed", "name": "Hash", "aliases": ["Md5"], "size": 2}]}
+	]}`)
+
+	record := NewGenericRecord(writerSchema)
+	record.Set("fullName", "John")
+	record.Set("kind", "B")
+	record.Set("hash", []byte{1, 2})
+	buf := encodeGeneric(t, writerSchema, record)
+
+	reader := NewGenericDatumReader()
+	reader.SetSchema(writerSchema)
+	reader.SetReaderSchema(readerSchema)
+	decoded := NewGenericRecord(readerSchema)
+	err := reader.Read(decoded, NewBinaryDecoder(buf))
+	assert(t, err, nil)
+	assert(t, decoded.Get("name"), "John")
+	assert(t, decoded.Get("kind"), "B")
+	assert(t, decoded.Get("hash"), []byte{1, 2})
+
+	// prepared schemas keep field aliases
+	prepared := Prepare(readerSchema)
+	assert(t, actualSchema(prepared).(*RecordSchema).Fields[0].Aliases, []string{"fullName"})
+	reader.SetReaderSchema(prepared)
+	decoded = NewGenericRecord(readerSchema)
+	err = reader.Read(decoded, NewBinaryDecoder(buf))
+	assert(t, err, nil)
+	assert(t, decoded.Get("name"), "John")
+
+	assert(t, len(CheckCompatibility(readerSchema, writerSchema)), 0)
+	// aliases are not symmetric
+	incompatibilities := CheckCompatibility(writerSchema, readerSchema)
+	assert(t, len(incompatibilities), 1)
+	assert(t, incompatibilities[0].Type, NameMismatch)
+}
+
+func TestResolutionIncompatibleSchemas(t *testing.T) {
+	readerSchema := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+        {"name": "id", "type": "long"},
+        {"name": "required", "type": "string"}
+    ]}`)
+
+	reader := NewGenericDatumReader()
+	reader.SetSchema(resolutionWriterSchema)
+	reader.SetReaderSchema(readerSchema)
+
+	record := NewGenericRecord(readerSchema)
+	err := reader.Read(record, NewBinaryDecoder(encodeGeneric(t, resolutionWriterSchema, resolutionWriterRecord())))
+	if err == nil {
+		t.Fatal("Expected an error for reader field without default value")
+	}
+
+	_, err = resolveSchema(MustParseSchema(`"long"`), MustParseSchema(`"int"`))
+	if err == nil {
+		t.Fatal("Expected an error for long to int resolution")
+	}
+}
+
+func TestResolutionRecursive(t *testing.T) {
+	writerSchema := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+        {"name": "value", "type": "int"},
+        {"name": "next", "type": ["null", "Node"]}
+    ]}`)
+	readerSchema := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+        {"name": "next", "type": ["null", "Node"]},
+        {"name": "value", "type": "double"}
+    ]}`)
+
+	tail := NewGenericRecord(writerSchema)
+	tail.Set("value", int32(2))
+	head := NewGenericRecord(writerSchema)
+	head.Set("value", int32(1))
+	head.Set("next", tail)
+
+	reader := NewGenericDatumReader()
+	reader.SetSchema(writerSchema)
+	reader.SetReaderSchema(readerSchema)
+
+	record := NewGenericRecord(readerSchema)
+	err := reader.Read(record, NewBinaryDecoder(encodeGeneric(t, writerSchema, head)))
+	assert(t, err, nil)
+	assert(t, record.Get("value"), float64(1))
+	assert(t, record.Get("next").(*GenericRecord).Get("value"), float64(2))
+	assert(t, record.Get("next").(*GenericRecord).Get("next"), nil)
+}
+
+func TestDataFileReaderResolution(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	datumWriter := NewGenericDatumWriter()
+	fileWriter, err := NewDataFileWriter(buffer, resolutionWriterSchema, datumWriter)
+	assert(t, err, nil)
+	assert(t, fileWriter.Write(resolutionWriterRecord()), nil)
+	assert(t, fileWriter.Close(), nil)
+
+	datumReader := NewSpecificDatumReader()
+	datumReader.SetReaderSchema(resolutionReaderSchema)
+	fileReader, err := newDataFileReaderBytes(buffer.Bytes(), datumReader)
+	assert(t, err, nil)
+
+	event := &resolvedEvent{}
+	ok, err := fileReader.Next(event)
+	assert(t, err, nil)
+	assert(t, ok, true)
+	assert(t, event.Id, int64(123))
+	assert(t, event.Added, "hello")
+}
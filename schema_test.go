@@ -1,6 +1,8 @@
 package avro
 
 import (
+	"bytes"
+	"encoding/json"
 	"testing"
 )
 
@@ -259,6 +261,53 @@ func TestFixedSchema(t *testing.T) {
 	}
 }
 
+func TestFixedSchemaNamespace(t *testing.T) {
+	schema, err := ParseSchema(`{"type": "record", "name": "Digest", "namespace": "com.example", "fields": [
+		{"name": "value", "type": {"type": "fixed", "name": "Md5", "namespace": "com.example.hash", "size": 16}},
+		{"name": "salt", "type": {"type": "fixed", "name": "Salt", "size": 4}}
+	]}`)
+	assert(t, err, nil)
+
+	var out bytes.Buffer
+	assert(t, json.Compact(&out, []byte(schema.String())), nil)
+	assert(t, out.String(), `{"type":"record","namespace":"com.example","name":"Digest","fields":[`+
+		`{"name":"value","type":{"type":"fixed","size":16,"name":"Md5","namespace":"com.example.hash"}},`+
+		`{"name":"salt","type":{"type":"fixed","size":4,"name":"Salt"}}]}`)
+
+	// the full name of a fixed type from another namespace survives serialization
+	reparsed, err := ParseSchema(schema.String())
+	assert(t, err, nil)
+	assert(t, GetFullName(reparsed.(*RecordSchema).Fields[0].Type), "com.example.hash.Md5")
+	assert(t, CanonicalForm(reparsed), CanonicalForm(schema))
+}
+
+func TestSchemaNamedTypesDefinedOnce(t *testing.T) {
+	schema, err := ParseSchema(`{"type": "record", "name": "Transfer", "namespace": "com.example", "fields": [
+		{"name": "from", "type": {"type": "record", "name": "Account", "fields": [
+			{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 4}},
+			{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"]}}
+		]}},
+		{"name": "to", "type": ["null", "Account"]},
+		{"name": "hashes", "type": {"type": "array", "items": "Hash"}},
+		{"name": "kinds", "type": {"type": "map", "values": "Kind"}}
+	]}`)
+	assert(t, err, nil)
+
+	var out bytes.Buffer
+	assert(t, json.Compact(&out, []byte(schema.String())), nil)
+	assert(t, out.String(), `{"type":"record","namespace":"com.example","name":"Transfer","fields":[`+
+		`{"name":"from","type":{"type":"record","name":"Account","fields":[`+
+		`{"name":"hash","type":{"type":"fixed","size":4,"name":"Hash"}},`+
+		`{"name":"kind","type":{"type":"enum","name":"Kind","symbols":["A","B"]}}]}},`+
+		`{"name":"to","default":null,"type":["null","com.example.Account"]},`+
+		`{"name":"hashes","type":{"type":"array","items":"com.example.Hash"}},`+
+		`{"name":"kinds","type":{"type":"map","values":"com.example.Kind"}}]}`)
+
+	reparsed, err := ParseSchema(schema.String())
+	assert(t, err, nil)
+	assert(t, CanonicalForm(reparsed), CanonicalForm(schema))
+}
+
 func TestSchemaRegistryMap(t *testing.T) {
 	rawSchema1 := `{"type": "record", "name": "TestRecord", "namespace": "com.github.elodina", "fields": [
 		{"name": "longRecordField", "type": "long"}
@@ -299,6 +348,43 @@ func TestSchemaRegistryMap(t *testing.T) {
 	assert(t, len(registry), 4)
 }
 
+func TestSchemaAliases(t *testing.T) {
+	registry := make(map[string]Schema)
+	schema, err := ParseSchemaWithRegistry(`{"type": "record", "name": "User", "namespace": "com.example", "aliases": ["Person", "org.legacy.Account"], "fields": [
+		{"name": "name", "type": "string", "aliases": ["fullName", "title"]},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "aliases": ["Type"], "symbols": ["A", "B"]}},
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "namespace": "com.example.hash", "aliases": ["Md5"], "size": 16}}
+	]}`, registry)
+	assert(t, err, nil)
+
+	record := schema.(*RecordSchema)
+	assert(t, record.Aliases, []string{"com.example.Person", "org.legacy.Account"})
+	assert(t, record.Fields[0].Aliases, []string{"fullName", "title"})
+	assert(t, record.Fields[1].Type.(*EnumSchema).Aliases, []string{"com.example.Type"})
+	assert(t, record.Fields[2].Type.(*FixedSchema).Aliases, []string{"com.example.hash.Md5"})
+
+	assert(t, registry["com.example.Person"].(*RecursiveSchema).Actual, record)
+	assert(t, registry["org.legacy.Account"].(*RecursiveSchema).Actual, record)
+	assert(t, registry["com.example.Type"], record.Fields[1].Type)
+	assert(t, registry["com.example.hash.Md5"], record.Fields[2].Type)
+
+	reference, err := ParseSchemaWithRegistry(`{"type": "record", "name": "Ref", "namespace": "com.example", "fields": [
+		{"name": "kind", "type": "Type"}
+	]}`, registry)
+	assert(t, err, nil)
+	assert(t, reference.(*RecordSchema).Fields[0].Type, record.Fields[1].Type)
+
+	// aliases survive serialization
+	reparsed, err := ParseSchema(schema.String())
+	assert(t, err, nil)
+	assert(t, reparsed.(*RecordSchema).Aliases, record.Aliases)
+	assert(t, reparsed.(*RecordSchema).Fields[0].Aliases, record.Fields[0].Aliases)
+	assert(t, reparsed.(*RecordSchema).Fields[2].Type.(*FixedSchema).Aliases, []string{"com.example.hash.Md5"})
+
+	_, err = ParseSchema(`{"type": "enum", "name": "Kind", "aliases": "Type", "symbols": ["A"]}`)
+	assert(t, err != nil, true)
+}
+
 func TestRecordCustomProps(t *testing.T) {
 	raw := `{"type": "record", "name": "TestRecord", "hello": "world", "fields": [
      	{"name": "longRecordField", "type": "long"},
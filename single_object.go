@@ -0,0 +1,197 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// singleObjectMarker is the two byte marker that starts each single-object encoded value.
+var singleObjectMarker = []byte{0xC3, 0x01}
+
+// singleObjectHeaderSize is the size of the marker and the CRC-64-AVRO schema fingerprint.
+const singleObjectHeaderSize = 10
+
+// SchemaStore looks up writer schemas of single-object encoded values by their fingerprints.
+type SchemaStore interface {
+	// GetSchema returns a schema with a given CRC-64-AVRO fingerprint or an error if the schema is unknown.
+	GetSchema(fingerprint uint64) (Schema, error)
+}
+
+// InMemorySchemaStore implements SchemaStore and keeps schemas in memory. It is safe for concurrent use.
+type InMemorySchemaStore struct {
+	schemas map[uint64]Schema
+	lock    sync.RWMutex
+}
+
+// NewInMemorySchemaStore creates a new InMemorySchemaStore seeded with given schemas, e.g. returned by LoadSchemas.
+func NewInMemorySchemaStore(schemas map[string]Schema) *InMemorySchemaStore {
+	store := &InMemorySchemaStore{schemas: make(map[uint64]Schema)}
+	for _, schema := range schemas {
+		store.Add(schema)
+	}
+	return store
+}
+
+// Add adds a given schema to this InMemorySchemaStore and returns its CRC-64-AVRO fingerprint.
+func (s *InMemorySchemaStore) Add(schema Schema) uint64 {
+	fingerprint := Fingerprint64(schema)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.schemas[fingerprint] = schema
+	return fingerprint
+}
+
+// GetSchema returns a schema with a given CRC-64-AVRO fingerprint or an error if the schema is unknown.
+func (s *InMemorySchemaStore) GetSchema(fingerprint uint64) (Schema, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if schema, ok := s.schemas[fingerprint]; ok {
+		return schema, nil
+	}
+
+	return nil, fmt.Errorf("Unknown schema fingerprint: %016x", fingerprint)
+}
+
+// SingleObjectWriter writes values in Avro single-object encoding
+// (https://avro.apache.org/docs/current/spec.html#single_object_encoding): a two byte marker, the little-endian
+// CRC-64-AVRO fingerprint of the writer schema and the binary encoded value.
+type SingleObjectWriter struct {
+	header []byte
+	datum  DatumWriter
+}
+
+// NewSingleObjectWriter creates a new SingleObjectWriter that writes values of a given schema with a given DatumWriter.
+func NewSingleObjectWriter(schema Schema, datumWriter DatumWriter) *SingleObjectWriter {
+	header := make([]byte, singleObjectHeaderSize)
+	copy(header, singleObjectMarker)
+	binary.LittleEndian.PutUint64(header[len(singleObjectMarker):], Fingerprint64(schema))
+	datumWriter.SetSchema(schema)
+
+	return &SingleObjectWriter{header: header, datum: datumWriter}
+}
+
+// Write writes a given value in single-object encoding to a given io.Writer.
+func (w *SingleObjectWriter) Write(obj interface{}, output io.Writer) error {
+	buffer := &bytes.Buffer{}
+	buffer.Write(w.header)
+	enc := AcquireBinaryEncoder(buffer)
+	defer ReleaseBinaryEncoder(enc)
+	if err := w.datum.Write(obj, enc); err != nil {
+		return err
+	}
+
+	_, err := output.Write(buffer.Bytes())
+	return err
+}
+
+// Encode returns a given value in single-object encoding.
+func (w *SingleObjectWriter) Encode(obj interface{}) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	if err := w.Write(obj, buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// readerSchemaSetter is implemented by DatumReaders that are able to resolve data against a reader schema.
+type readerSchemaSetter interface {
+	SetReaderSchema(Schema)
+}
+
+// datumReaderCache creates DatumReaders for writer schemas identified by fingerprints or registry IDs and reuses them.
+type datumReaderCache struct {
+	newDatumReader func() DatumReader
+	readerSchema   Schema
+	readers        map[uint64]DatumReader
+	lock           sync.RWMutex
+}
+
+func newDatumReaderCache(newDatumReader func() DatumReader) *datumReaderCache {
+	return &datumReaderCache{newDatumReader: newDatumReader, readers: make(map[uint64]DatumReader)}
+}
+
+func (c *datumReaderCache) setReaderSchema(schema Schema) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.readerSchema = schema
+	c.readers = make(map[uint64]DatumReader)
+}
+
+// get returns a DatumReader for a writer schema with a given key looking the schema up if there is no such reader yet.
+func (c *datumReaderCache) get(key uint64, lookup func() (Schema, error)) (DatumReader, error) {
+	c.lock.RLock()
+	reader, ok := c.readers[key]
+	c.lock.RUnlock()
+	if ok {
+		return reader, nil
+	}
+
+	schema, err := lookup()
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	reader = c.newDatumReader()
+	reader.SetSchema(schema)
+	if c.readerSchema != nil {
+		setter, ok := reader.(readerSchemaSetter)
+		if !ok {
+			return nil, fmt.Errorf("%T does not support reader schemas", reader)
+		}
+		setter.SetReaderSchema(c.readerSchema)
+	}
+	c.readers[key] = reader
+	return reader, nil
+}
+
+// SingleObjectReader reads values in Avro single-object encoding looking up writer schemas in a SchemaStore.
+// It is safe for concurrent use if DatumReaders created by the given factory are.
+type SingleObjectReader struct {
+	store   SchemaStore
+	readers *datumReaderCache
+}
+
+// NewSingleObjectReader creates a new SingleObjectReader that looks up writer schemas in a given SchemaStore and
+// reads values with DatumReaders created by a given factory, one per writer schema.
+func NewSingleObjectReader(store SchemaStore, newDatumReader func() DatumReader) *SingleObjectReader {
+	return &SingleObjectReader{store: store, readers: newDatumReaderCache(newDatumReader)}
+}
+
+// SetReaderSchema sets the schema values should be read as. Values are resolved from their writer schemas according
+// to Avro schema resolution rules, this requires DatumReaders to support SetReaderSchema as GenericDatumReader and
+// SpecificDatumReader do. Should be called before reading any value.
+func (r *SingleObjectReader) SetReaderSchema(schema Schema) {
+	r.readers.setReaderSchema(schema)
+}
+
+// Read reads a single-object encoded value from a given buffer into a given value that MUST be of pointer type.
+// Returns an error if the buffer does not start with the single-object marker, the writer schema is unknown or
+// the value cannot be decoded.
+func (r *SingleObjectReader) Read(obj interface{}, data []byte) error {
+	fingerprint, err := SingleObjectFingerprint(data)
+	if err != nil {
+		return err
+	}
+
+	reader, err := r.readers.get(fingerprint, func() (Schema, error) { return r.store.GetSchema(fingerprint) })
+	if err != nil {
+		return err
+	}
+
+	return reader.Read(obj, NewBinaryDecoder(data[singleObjectHeaderSize:]))
+}
+
+// SingleObjectFingerprint returns the writer schema fingerprint of a given single-object encoded value.
+// Returns NotSingleObject if the value does not start with the single-object marker.
+func SingleObjectFingerprint(data []byte) (uint64, error) {
+	if len(data) < singleObjectHeaderSize || !bytes.HasPrefix(data, singleObjectMarker) {
+		return 0, NotSingleObject
+	}
+
+	return binary.LittleEndian.Uint64(data[len(singleObjectMarker):singleObjectHeaderSize]), nil
+}
@@ -0,0 +1,79 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestSingleObjectRoundTrip(t *testing.T) {
+	schemas := LoadSchemas("test/schemas/")
+	store := NewInMemorySchemaStore(schemas)
+	schema := schemas["example.avro.Complex"]
+
+	writer := NewSingleObjectWriter(schema, NewGenericDatumWriter())
+	record := NewGenericRecord(schema)
+	record.Set("stringArray", []string{"a", "b"})
+	record.Set("longArray", []int64{1})
+	record.Set("enumField", "C")
+	record.Set("mapOfInts", map[string]interface{}{"one": int32(1)})
+	record.Set("unionField", "union")
+	record.Set("fixedField", []byte("0123456789abcdef"))
+	record.Set("recordField", NewGenericRecord(schema))
+	record.Get("recordField").(*GenericRecord).Set("longRecordField", int64(2))
+	record.Get("recordField").(*GenericRecord).Set("stringRecordField", "s")
+	record.Get("recordField").(*GenericRecord).Set("intRecordField", int32(3))
+	record.Get("recordField").(*GenericRecord).Set("floatRecordField", float32(4))
+
+	data, err := writer.Encode(record)
+	assert(t, err, nil)
+	assert(t, data[:2], []byte{0xC3, 0x01})
+	assert(t, binary.LittleEndian.Uint64(data[2:10]), Fingerprint64(schema))
+
+	fingerprint, err := SingleObjectFingerprint(data)
+	assert(t, err, nil)
+	assert(t, fingerprint, Fingerprint64(schema))
+
+	reader := NewSingleObjectReader(store, func() DatumReader { return NewGenericDatumReader() })
+	decoded := NewGenericRecord(schema)
+	assert(t, reader.Read(decoded, data), nil)
+	assert(t, decoded.Get("enumField"), "C")
+	assert(t, decoded.Get("unionField"), "union")
+	assert(t, decoded.Get("recordField").(*GenericRecord).Get("intRecordField"), int32(3))
+}
+
+func TestSingleObjectReaderSchema(t *testing.T) {
+	writerSchema := MustParseSchema(`{"type": "record", "name": "Point", "fields": [
+        {"name": "x", "type": "int"}
+    ]}`)
+	readerSchema := MustParseSchema(`{"type": "record", "name": "Point", "fields": [
+        {"name": "x", "type": "long"},
+        {"name": "y", "type": "long", "default": 5}
+    ]}`)
+
+	store := NewInMemorySchemaStore(nil)
+	store.Add(writerSchema)
+
+	buffer := &bytes.Buffer{}
+	point := NewGenericRecord(writerSchema)
+	point.Set("x", int32(1))
+	assert(t, NewSingleObjectWriter(writerSchema, NewGenericDatumWriter()).Write(point, buffer), nil)
+
+	reader := NewSingleObjectReader(store, func() DatumReader { return NewSpecificDatumReader() })
+	reader.SetReaderSchema(readerSchema)
+	decoded := &struct {
+		X int64
+		Y int64
+	}{}
+	assert(t, reader.Read(decoded, buffer.Bytes()), nil)
+	assert(t, decoded.X, int64(1))
+	assert(t, decoded.Y, int64(5))
+}
+
+func TestSingleObjectReaderErrors(t *testing.T) {
+	reader := NewSingleObjectReader(NewInMemorySchemaStore(nil), func() DatumReader { return NewGenericDatumReader() })
+
+	var value interface{}
+	assert(t, reader.Read(&value, []byte{0x00, 0x01, 0x02}), NotSingleObject)
+	assert(t, reader.Read(&value, []byte{0xC3, 0x01, 1, 2, 3, 4, 5, 6, 7, 8}).Error(), "Unknown schema fingerprint: 0807060504030201")
+}
@@ -0,0 +1,100 @@
+package avro
+
+import "fmt"
+
+// SkipValue skips a single value of a given schema without materializing it. Arrays and maps written with their
+// block sizes in bytes are skipped without reading their items.
+// Returns an error if the value cannot be decoded.
+func SkipValue(schema Schema, dec Decoder) error {
+	schema = actualSchema(schema)
+	switch schema.Type() {
+	case Null:
+		_, err := dec.ReadNull()
+		return err
+	case Boolean:
+		_, err := dec.ReadBoolean()
+		return err
+	case Int:
+		_, err := dec.ReadInt()
+		return err
+	case Long:
+		_, err := dec.ReadLong()
+		return err
+	case Float:
+		_, err := dec.ReadFloat()
+		return err
+	case Double:
+		_, err := dec.ReadDouble()
+		return err
+	case Bytes:
+		return dec.SkipBytes()
+	case String:
+		return dec.SkipString()
+	case Enum:
+		_, err := dec.ReadEnum()
+		return err
+	case Fixed:
+		return dec.SkipFixed(schema.(*FixedSchema).Size)
+	case Array:
+		if err := enterValue(dec); err != nil {
+			return err
+		}
+		defer leaveValue(dec)
+		return skipItems(dec, dec.SkipArray, schema.(*ArraySchema).Items, nil)
+	case Map:
+		if err := enterValue(dec); err != nil {
+			return err
+		}
+		defer leaveValue(dec)
+		return skipItems(dec, dec.SkipMap, schema.(*MapSchema).Values, dec.SkipString)
+	case Union:
+		index, err := dec.ReadLong()
+		if err != nil {
+			return err
+		}
+		types := schema.(*UnionSchema).Types
+		if index < 0 || index >= int64(len(types)) {
+			return UnionTypeOverflow
+		}
+		return SkipValue(types[index], dec)
+	case Record:
+		if err := enterValue(dec); err != nil {
+			return err
+		}
+		defer leaveValue(dec)
+		for _, field := range assertRecordSchema(schema).Fields {
+			if err := SkipValue(field.Type, dec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("Unknown schema type: %d", schema.Type())
+}
+
+// skipItems skips array or map blocks with a given skip function, items that cannot be skipped by block are skipped
+// one by one preceded by a map key if skipKey is not nil. Item counts are checked against DecoderOptions the same way
+// they are when items are read.
+func skipItems(dec Decoder, skip func() (int64, error), items Schema, skipKey func() error) error {
+	for first := true; ; first = false {
+		count, err := skip()
+		if err != nil {
+			return err
+		}
+		if err := skippedBlock(dec, count, first); err != nil || count == 0 {
+			return err
+		}
+
+		for i := int64(0); i < count; i++ {
+			if skipKey != nil {
+				if err := skipKey(); err != nil {
+					return err
+				}
+			}
+			if err := SkipValue(items, dec); err != nil {
+				return err
+			}
+		}
+	}
+}
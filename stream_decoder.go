@@ -0,0 +1,337 @@
+package avro
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// streamReadChunk is the amount of memory allocated at once for bytes and strings read by StreamDecoder, so that
+// a corrupted length does not make it allocate more than the data that is actually there.
+const streamReadChunk = 64 * 1024
+
+// StreamDecoder implements Decoder and deserializes binary encoded Avro values from an io.Reader, e.g. a network
+// connection, without reading all of the data into memory first. Back-to-back values are read with a DatumReader
+// one after another while More returns true. Values that end prematurely result in io.ErrUnexpectedEOF.
+type StreamDecoder struct {
+	reader *bufio.Reader
+	pos    int64
+	decoderLimits
+}
+
+// NewStreamDecoder creates a new StreamDecoder that reads from a given io.Reader with buffering.
+func NewStreamDecoder(reader io.Reader) *StreamDecoder {
+	return NewStreamDecoderWithOptions(reader, DecoderOptions{})
+}
+
+// NewStreamDecoderWithOptions creates a new StreamDecoder that reads from a given io.Reader with buffering and
+// limits the sizes of read values with given options.
+func NewStreamDecoderWithOptions(reader io.Reader, options DecoderOptions) *StreamDecoder {
+	return &StreamDecoder{reader: bufio.NewReader(reader), decoderLimits: decoderLimits{options: options}}
+}
+
+// More returns false if the underlying io.Reader is at the end of the data, i.e. there are no more values to read.
+// Other read errors are returned by the next read.
+func (sd *StreamDecoder) More() bool {
+	_, err := sd.reader.Peek(1)
+	return err != io.EOF
+}
+
+// ReadNull reads a null value. Returns a decoded value and an error if it occurs.
+func (sd *StreamDecoder) ReadNull() (interface{}, error) {
+	return nil, nil
+}
+
+// ReadBoolean reads a boolean value. Returns a decoded value and an error if it occurs.
+func (sd *StreamDecoder) ReadBoolean() (bool, error) {
+	b, err := sd.readByte()
+	if err != nil {
+		return false, err
+	}
+	if b != 0 && b != 1 {
+		return false, InvalidBool
+	}
+	return b == 1, nil
+}
+
+// ReadInt reads an int value. Returns a decoded value and an error if it occurs.
+func (sd *StreamDecoder) ReadInt() (int32, error) {
+	value, err := sd.readVarint(maxIntBufSize, IntOverflow)
+	return int32((uint32(value) >> 1) ^ -(uint32(value) & 1)), err
+}
+
+// ReadLong reads a long value. Returns a decoded value and an error if it occurs.
+func (sd *StreamDecoder) ReadLong() (int64, error) {
+	value, err := sd.readVarint(maxLongBufSize, LongOverflow)
+	return int64((value >> 1) ^ -(value & 1)), err
+}
+
+// ReadFloat reads a float value. Returns a decoded value and an error if it occurs.
+func (sd *StreamDecoder) ReadFloat() (float32, error) {
+	var buf [4]byte
+	if err := sd.readFull(buf[:]); err != nil {
+		return 0, err
+	}
+	return math.Float32frombits(binary.LittleEndian.Uint32(buf[:])), nil
+}
+
+// ReadDouble reads a double value. Returns a decoded value and an error if it occurs.
+func (sd *StreamDecoder) ReadDouble() (float64, error) {
+	var buf [8]byte
+	if err := sd.readFull(buf[:]); err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(buf[:])), nil
+}
+
+// ReadBytes reads a bytes value. Returns a decoded value and an error if it occurs.
+func (sd *StreamDecoder) ReadBytes() ([]byte, error) {
+	length, err := sd.ReadLong()
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 {
+		return nil, NegativeBytesLength
+	}
+	if err := checkLimit("bytes length", length, sd.options.MaxBytesLength); err != nil {
+		return nil, err
+	}
+	return sd.readN(length)
+}
+
+// ReadString reads a string value. Returns a decoded value and an error if it occurs.
+func (sd *StreamDecoder) ReadString() (string, error) {
+	length, err := sd.ReadLong()
+	if err != nil {
+		return "", err
+	}
+	if length < 0 {
+		return "", InvalidStringLength
+	}
+	if err := checkLimit("string length", length, sd.options.MaxStringLength); err != nil {
+		return "", err
+	}
+	value, err := sd.readN(length)
+	return string(value), err
+}
+
+// ReadEnum reads an enum value (which is an Avro int value). Returns a decoded value and an error if it occurs.
+func (sd *StreamDecoder) ReadEnum() (int32, error) {
+	return sd.ReadInt()
+}
+
+// ReadArrayStart reads and returns the size of the first block of an array. If call to this return non-zero, then
+// the caller should read the indicated number of items and then call ArrayNext() to find out the number of items in
+// the next block. Returns a decoded value and an error if it occurs.
+func (sd *StreamDecoder) ReadArrayStart() (int64, error) {
+	count, err := sd.readItemCount()
+	if err != nil {
+		return 0, err
+	}
+	return count, sd.startCollection(count)
+}
+
+// ArrayNext processes the next block of an array and returns the number of items in the block.
+// Returns a decoded value and an error if it occurs.
+func (sd *StreamDecoder) ArrayNext() (int64, error) {
+	count, err := sd.readItemCount()
+	if err != nil {
+		return 0, err
+	}
+	return count, sd.nextBlock(count)
+}
+
+// ReadMapStart reads and returns the size of the first block of map entries. If call to this return non-zero, then
+// the caller should read the indicated number of items and then call MapNext() to find out the number of items in
+// the next block. Usage is similar to ReadArrayStart(). Returns a decoded value and an error if it occurs.
+func (sd *StreamDecoder) ReadMapStart() (int64, error) {
+	count, err := sd.readItemCount()
+	if err != nil {
+		return 0, err
+	}
+	return count, sd.startCollection(count)
+}
+
+// MapNext processes the next block of map entries and returns the number of items in the block.
+// Returns a decoded value and an error if it occurs.
+func (sd *StreamDecoder) MapNext() (int64, error) {
+	count, err := sd.readItemCount()
+	if err != nil {
+		return 0, err
+	}
+	return count, sd.nextBlock(count)
+}
+
+// ReadFixed reads fixed sized binary object into the provided buffer.
+// Returns an error if it occurs.
+func (sd *StreamDecoder) ReadFixed(bytes []byte) error {
+	return sd.readFull(bytes)
+}
+
+// ReadFixedWithBounds reads fixed sized binary object into the provided buffer.
+// The second parameter is the position where the data needs to be written, the third is the size of binary object.
+// Returns an error if it occurs.
+func (sd *StreamDecoder) ReadFixedWithBounds(bytes []byte, start int, length int) error {
+	if start < 0 || length < 0 || start+length > len(bytes) {
+		return NegativeBytesLength
+	}
+	return sd.readFull(bytes[start : start+length])
+}
+
+// SkipString skips a string value. Returns an error if it occurs.
+func (sd *StreamDecoder) SkipString() error {
+	length, err := sd.ReadLong()
+	if err != nil {
+		return err
+	}
+	if length < 0 {
+		return InvalidStringLength
+	}
+	return sd.skip(length)
+}
+
+// SkipBytes skips a bytes value. Returns an error if it occurs.
+func (sd *StreamDecoder) SkipBytes() error {
+	length, err := sd.ReadLong()
+	if err != nil {
+		return err
+	}
+	if length < 0 {
+		return NegativeBytesLength
+	}
+	return sd.skip(length)
+}
+
+// SkipFixed skips a fixed sized binary object of a given size. Returns an error if it occurs.
+func (sd *StreamDecoder) SkipFixed(length int) error {
+	if length < 0 {
+		return NegativeBytesLength
+	}
+	return sd.skip(int64(length))
+}
+
+// SkipArray skips array blocks which sizes in bytes are known and returns the number of items in the first block that
+// has to be skipped item by item. The caller should skip the indicated number of items and call SkipArray() again
+// until it returns 0. Returns an error if it occurs.
+func (sd *StreamDecoder) SkipArray() (int64, error) {
+	return sd.skipBlocks()
+}
+
+// SkipMap skips map blocks which sizes in bytes are known. Usage is similar to SkipArray(). Returns the number of
+// entries in the first block that has to be skipped entry by entry and an error if it occurs.
+func (sd *StreamDecoder) SkipMap() (int64, error) {
+	return sd.skipBlocks()
+}
+
+// SetBlock does nothing as StreamDecoder reads values sequentially.
+func (sd *StreamDecoder) SetBlock(block *DataBlock) {}
+
+// Seek skips data up to a given position. StreamDecoder reads values sequentially, so positions before the current
+// one are ignored.
+func (sd *StreamDecoder) Seek(pos int64) {
+	if pos > sd.pos {
+		sd.skip(pos - sd.pos)
+	}
+}
+
+// Tell returns the number of bytes read by this StreamDecoder.
+func (sd *StreamDecoder) Tell() int64 {
+	return sd.pos
+}
+
+func (sd *StreamDecoder) readByte() (byte, error) {
+	b, err := sd.reader.ReadByte()
+	if err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	sd.pos++
+	return b, nil
+}
+
+func (sd *StreamDecoder) readVarint(maxSize int, overflow error) (uint64, error) {
+	var value uint64
+	for offset := 0; ; offset++ {
+		if offset == maxSize {
+			return 0, overflow
+		}
+
+		b, err := sd.readByte()
+		if err != nil {
+			return 0, err
+		}
+		value |= uint64(b&0x7F) << uint(7*offset)
+		if b&0x80 == 0 {
+			return value, nil
+		}
+	}
+}
+
+func (sd *StreamDecoder) readFull(buf []byte) error {
+	n, err := io.ReadFull(sd.reader, buf)
+	sd.pos += int64(n)
+	return unexpectedEOF(err)
+}
+
+// readN reads a given number of bytes growing the buffer as data arrives.
+func (sd *StreamDecoder) readN(length int64) ([]byte, error) {
+	if length <= streamReadChunk {
+		buf := make([]byte, length)
+		return buf, sd.readFull(buf)
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, streamReadChunk))
+	n, err := io.CopyN(buf, sd.reader, length)
+	sd.pos += n
+	return buf.Bytes(), unexpectedEOF(err)
+}
+
+func (sd *StreamDecoder) skip(length int64) error {
+	n, err := io.CopyN(ioutil.Discard, sd.reader, length)
+	sd.pos += n
+	return unexpectedEOF(err)
+}
+
+func (sd *StreamDecoder) readItemCount() (int64, error) {
+	count, err := sd.ReadLong()
+	if err != nil {
+		return 0, err
+	}
+
+	if count < 0 {
+		// the block size in bytes is not needed to read items
+		if _, err := sd.ReadLong(); err != nil {
+			return 0, err
+		}
+		count = -count
+	}
+	return count, nil
+}
+
+// skipBlocks skips array or map blocks that are prefixed with their sizes in bytes, i.e. have negative item counts.
+// Returns the item count of the first block without the size, that the caller checks against DecoderOptions as it
+// knows whether the block starts a collection.
+func (sd *StreamDecoder) skipBlocks() (int64, error) {
+	for {
+		count, err := sd.ReadLong()
+		if err != nil || count >= 0 {
+			return count, err
+		}
+		if err := checkLimit("block count", -count, sd.options.MaxBlockCount); err != nil {
+			return 0, err
+		}
+
+		size, err := sd.ReadLong()
+		if err != nil {
+			return 0, err
+		}
+		if size < 0 {
+			return 0, NegativeBytesLength
+		}
+		if err := sd.skip(size); err != nil {
+			return 0, err
+		}
+	}
+}
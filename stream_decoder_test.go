@@ -0,0 +1,127 @@
+package avro
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestStreamDecoderPrimitives(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enc := NewBinaryEncoder(buf)
+	enc.WriteBoolean(true)
+	enc.WriteInt(-123456)
+	enc.WriteLong(1 << 50)
+	enc.WriteFloat(1.5)
+	enc.WriteDouble(-2.25)
+	enc.WriteBytes([]byte{1, 2, 3})
+	enc.WriteString("hello")
+	enc.WriteRaw([]byte{9, 8})
+	enc.WriteString("skipped")
+	enc.WriteLong(7)
+
+	// reading byte by byte makes sure values spanning several reads are put together
+	dec := NewStreamDecoder(iotest.OneByteReader(bytes.NewReader(buf.Bytes())))
+	b, err := dec.ReadBoolean()
+	assert(t, err, nil)
+	assert(t, b, true)
+	i, err := dec.ReadInt()
+	assert(t, err, nil)
+	assert(t, i, int32(-123456))
+	l, err := dec.ReadLong()
+	assert(t, err, nil)
+	assert(t, l, int64(1<<50))
+	f, err := dec.ReadFloat()
+	assert(t, err, nil)
+	assert(t, f, float32(1.5))
+	d, err := dec.ReadDouble()
+	assert(t, err, nil)
+	assert(t, d, float64(-2.25))
+	bs, err := dec.ReadBytes()
+	assert(t, err, nil)
+	assert(t, bs, []byte{1, 2, 3})
+	s, err := dec.ReadString()
+	assert(t, err, nil)
+	assert(t, s, "hello")
+	fixed := make([]byte, 3)
+	assert(t, dec.ReadFixedWithBounds(fixed, 1, 2), nil)
+	assert(t, fixed, []byte{0, 9, 8})
+	assert(t, dec.SkipString(), nil)
+	assert(t, dec.More(), true)
+	l, err = dec.ReadLong()
+	assert(t, err, nil)
+	assert(t, l, int64(7))
+	assert(t, dec.Tell(), int64(buf.Len()))
+	assert(t, dec.More(), false)
+}
+
+func TestStreamDecoderBackToBackDatums(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	reader, writer := io.Pipe()
+	go func() {
+		datumWriter := NewSpecificDatumWriter()
+		datumWriter.SetSchema(schema)
+		for i := 0; i < 10; i++ {
+			// each value is written separately as it would arrive from a socket
+			buf := &bytes.Buffer{}
+			if err := datumWriter.Write(&primitive{IntField: int32(i), StringField: "value"}, NewBinaryEncoder(buf)); err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+			writer.Write(buf.Bytes())
+		}
+		writer.Close()
+	}()
+
+	datumReader := NewSpecificDatumReader()
+	datumReader.SetSchema(schema)
+	dec := NewStreamDecoder(reader)
+	count := 0
+	for dec.More() {
+		value := &primitive{}
+		assert(t, datumReader.Read(value, dec), nil)
+		assert(t, value.IntField, int32(count))
+		assert(t, value.StringField, "value")
+		count++
+	}
+	assert(t, count, 10)
+}
+
+func TestStreamDecoderUnexpectedEOF(t *testing.T) {
+	buf := &bytes.Buffer{}
+	NewBinaryEncoder(buf).WriteString("truncated")
+	for _, data := range [][]byte{buf.Bytes()[:4], {0x80}} {
+		_, err := NewStreamDecoder(bytes.NewReader(data)).ReadString()
+		assert(t, err, io.ErrUnexpectedEOF)
+	}
+
+	_, err := NewStreamDecoder(bytes.NewReader(nil)).ReadDouble()
+	assert(t, err, io.ErrUnexpectedEOF)
+	assert(t, NewStreamDecoder(bytes.NewReader([]byte{0x04})).SkipBytes(), io.ErrUnexpectedEOF)
+
+	// a huge length is not allocated upfront
+	_, err = NewStreamDecoder(bytes.NewReader([]byte{0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0x0f, 0x01})).ReadBytes()
+	assert(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestStreamDecoderLimits(t *testing.T) {
+	options := DecoderOptions{MaxStringLength: 4, MaxBytesLength: 4, MaxBlockCount: 100}
+	encoded := func(write func(enc *BinaryEncoder)) io.Reader {
+		buf := &bytes.Buffer{}
+		write(NewBinaryEncoder(buf))
+		return buf
+	}
+
+	_, err := NewStreamDecoderWithOptions(encoded(func(enc *BinaryEncoder) { enc.WriteString("too long") }), options).ReadString()
+	assert(t, err, &LimitError{Limit: "string length", Value: 8, Max: 4})
+	_, err = NewStreamDecoderWithOptions(encoded(func(enc *BinaryEncoder) { enc.WriteBytes([]byte("too long")) }), options).ReadBytes()
+	assert(t, err, &LimitError{Limit: "bytes length", Value: 8, Max: 4})
+	_, err = NewStreamDecoderWithOptions(encoded(func(enc *BinaryEncoder) { enc.WriteMapStart(-1000); enc.WriteLong(10) }), options).ReadMapStart()
+	assert(t, err.Error(), "Max block count exceeded: 1000 > 100")
+
+	// skipping is not limited
+	dec := NewStreamDecoderWithOptions(encoded(func(enc *BinaryEncoder) { enc.WriteString("too long") }), options)
+	assert(t, dec.SkipString(), nil)
+	assert(t, dec.More(), false)
+}